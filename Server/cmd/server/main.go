@@ -2,9 +2,8 @@ package main
 
 import (
 	"context"
-	"database/sql"
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -13,85 +12,154 @@ import (
 
 	"server/internal/api"
 	"server/internal/config"
-	"server/internal/supabase"
-
-	_ "github.com/lib/pq" // PostgreSQL driver
+	"server/internal/infrastructure/database"
+	"server/internal/logging"
+	"server/internal/metrics"
+	"server/internal/tracing"
 )
 
 func main() {
-	if err := config.LoadEnvFiles(".env", "../.env"); err != nil {
-		log.Printf("failed to load env file: %v", err)
+	envErr := config.LoadEnvFiles(".env", "../.env")
+
+	// ロガーは他の設定より先に初期化する（log パッケージの出力も slog に流れる）
+	logConfig, err := config.LoadLogging()
+	if err != nil {
+		fatal("failed to load logging config", err)
+	}
+	slog.SetDefault(logging.New(os.Stdout, logging.Options{
+		Level:     logConfig.Level,
+		Format:    logConfig.Format,
+		ProjectID: logConfig.ProjectID,
+	}))
+	if envErr != nil {
+		slog.Warn("failed to load env file", "error", envErr)
 	}
 
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
+	// server migrate [up|down N|status] はマイグレーションのみを実行して終了する
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(ctx, os.Args[2:]); err != nil {
+			fatal("migrate failed", err)
+		}
+		return
+	}
+
 	// 設定を読み込み
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		fatal("failed to load config", err)
 	}
 
-	// データベース接続を初期化
-	var db *sql.DB
-	if cfg.Database.URL != "" {
-		db, err = sql.Open("postgres", cfg.Database.URL)
+	// トレースを初期化（OTEL_TRACES_EXPORTER=none でもトレースコンテキストの伝搬は行う）
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Options{
+		Exporter:    cfg.Tracing.Exporter,
+		ServiceName: cfg.Tracing.ServiceName,
+	})
+	if err != nil {
+		fatal("failed to set up tracing", err)
+	}
+
+	// データベース接続プールを初期化（リポジトリ全体で共有）
+	// STORAGE_BACKEND=memory の場合はデータベースへ接続せずに起動する
+	var db *database.DB
+	if cfg.UsesMemoryStorage() {
+		slog.Info("STORAGE_BACKEND=memory: skipping database connection")
+	} else if cfg.Database.URL != "" {
+		db, err = openDatabase(ctx, &cfg.Database)
 		if err != nil {
-			log.Fatalf("Failed to connect to database: %v", err)
+			fatal("failed to connect to database", err)
 		}
 		defer db.Close()
+		slog.Info("database connection established")
 
-		// データベース接続をテスト
-		if err := db.Ping(); err != nil {
-			log.Fatalf("Failed to ping database: %v", err)
+		// スキーマが古いまま起動しない（DB_AUTO_MIGRATE=true なら適用してから起動）
+		if err := ensureSchema(ctx, db, cfg.Database.AutoMigrate); err != nil {
+			fatal("database schema check failed", err)
 		}
-		log.Println("Database connection established")
+	} else {
+		slog.Warn("DATABASE_URL is not set: database-backed endpoints are disabled")
 	}
 
-	// Supabaseクライアントを初期化
-	startupCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	supabaseClient, err := supabase.NewClientFromEnv(startupCtx)
-	cancel()
+	// ルーターを初期化（データベース接続を渡す）。バックグラウンド処理はシャットダウンのシグナルで止める
+	router, err := api.NewRouter(ctx, db, cfg)
 	if err != nil {
-		log.Printf("Supabase Postgres client disabled: %v", err)
-	} else if supabaseClient != nil && supabaseClient.Ready() {
-		log.Println("Supabase client connected")
-	} else {
-		log.Println("Supabase client initialised without active connection")
-	}
-	if supabaseClient != nil {
-		defer supabaseClient.Close()
+		fatal("failed to initialize router", err)
 	}
 
-	// ルーターを初期化（データベース接続を渡す）
-	router := api.NewRouter(supabaseClient, db, cfg)
-
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
 	server := &http.Server{
-		Addr:    ":" + port,
-		Handler: router,
+		Addr:     ":" + port,
+		Handler:  router,
+		ErrorLog: slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+
+	// 管理用ポートで /metrics を公開（Cloud Run など単一ポートの環境では METRICS_TOKEN を使う）
+	var adminServer *http.Server
+	if cfg.Metrics.Addr != "" {
+		adminServer = newAdminServer(cfg.Metrics)
+		go func() {
+			slog.Info("admin server listening", "addr", adminServer.Addr)
+			if err := adminServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				fatal("admin server error", err)
+			}
+		}()
+	} else if cfg.Metrics.Token == "" {
+		slog.Info("metrics endpoint is disabled (set METRICS_ADDR or METRICS_TOKEN)")
 	}
 
 	go func() {
-		log.Printf("HTTP server listening on %s", server.Addr)
+		slog.Info("HTTP server listening", "addr", server.Addr)
 		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Fatalf("server error: %v", err)
+			fatal("server error", err)
 		}
 	}()
 
 	<-ctx.Done()
-	log.Printf("shutdown signal received")
+	slog.Info("shutdown signal received")
 
 	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancelShutdown()
 
 	if err := server.Shutdown(shutdownCtx); err != nil {
-		log.Printf("graceful shutdown failed: %v", err)
+		slog.Error("graceful shutdown failed", "error", err)
 	}
+	if adminServer != nil {
+		if err := adminServer.Shutdown(shutdownCtx); err != nil {
+			slog.Error("admin server shutdown failed", "error", err)
+		}
+	}
+	// 送信待ちのスパンを書き出す
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("tracing shutdown failed", "error", err)
+	}
+
+	slog.Info("server stopped")
+}
+
+// newAdminServer は /metrics を公開する管理用サーバーを作成します
+func newAdminServer(cfg config.MetricsConfig) *http.Server {
+	var handler http.Handler = metrics.Handler()
+	if cfg.Token != "" {
+		handler = metrics.RequireToken(cfg.Token, handler)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", handler)
+	return &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+}
 
-	log.Printf("server stopped")
+// fatal はエラーを記録して異常終了します
+func fatal(message string, err error) {
+	slog.Error(message, "error", err)
+	os.Exit(1)
 }
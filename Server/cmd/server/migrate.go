@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"server/internal/config"
+	"server/internal/infrastructure/database"
+	"server/migrations"
+)
+
+const migrateUsage = "usage: server migrate [up | down [N] | status]"
+
+// runMigrate は migrate サブコマンドを実行します
+func runMigrate(ctx context.Context, args []string) error {
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	steps := 1
+	switch command {
+	case "up", "status":
+		if len(args) > 1 {
+			return errors.New(migrateUsage)
+		}
+	case "down":
+		if len(args) > 2 {
+			return errors.New(migrateUsage)
+		}
+		if len(args) == 2 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("invalid step count %q: %s", args[1], migrateUsage)
+			}
+			steps = n
+		}
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	dbConfig, err := config.LoadDatabase()
+	if err != nil {
+		return err
+	}
+	if dbConfig.URL == "" {
+		return errors.New("DATABASE_URL or SUPABASE_DB_URL is required")
+	}
+
+	db, err := openDatabase(ctx, dbConfig)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := database.NewMigrator(db, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			slog.Info("applied migration", "version", migration.Version, "name", migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			slog.Info("schema is up to date")
+		}
+	case "down":
+		reverted, err := migrator.Down(ctx, steps)
+		for _, migration := range reverted {
+			slog.Info("reverted migration", "version", migration.Version, "name", migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		slog.Info("schema status", "current_version", status.Current, "pending", len(status.Pending))
+		for _, migration := range status.Pending {
+			slog.Info("pending migration", "version", migration.Version, "name", migration.Name)
+		}
+	}
+	return nil
+}
+
+// ensureSchema は未適用のマイグレーションがあれば起動を中止します。autoMigrate が true の場合は適用します。
+func ensureSchema(ctx context.Context, db *database.DB, autoMigrate bool) error {
+	migrator, err := database.NewMigrator(db, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	if autoMigrate {
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			slog.Info("applied migration", "version", migration.Version, "name", migration.Name)
+		}
+		return err
+	}
+
+	if err := migrator.CheckCurrent(ctx); err != nil {
+		if errors.Is(err, database.ErrSchemaBehind) {
+			return fmt.Errorf("%w (run `server migrate up` or set DB_AUTO_MIGRATE=true)", err)
+		}
+		return err
+	}
+	return nil
+}
+
+// openDatabase は設定から接続プールを作成します
+func openDatabase(ctx context.Context, cfg *config.DatabaseConfig) (*database.DB, error) {
+	return database.Open(ctx, database.Options{
+		URL:              cfg.URL,
+		MaxConns:         int32(cfg.MaxConns),
+		MinConns:         int32(cfg.MinConns),
+		MaxConnLifetime:  cfg.MaxConnLifetime,
+		MaxConnIdleTime:  cfg.MaxConnIdleTime,
+		StatementTimeout: cfg.StatementTimeout,
+		ConnectTimeout:   cfg.ConnectTimeout,
+		SlowQuery:        cfg.SlowQuery,
+	})
+}
@@ -0,0 +1,200 @@
+// Command simulate は魔法の MP コストとダメージのバランスを対戦シミュレーションで評価します。
+//
+//	go run ./cmd/simulate -duels 5000 -strategies random,greedy,efficient -format csv -table spells
+//
+// 魔法は埋め込みの magic_types.json（-magic-types でファイル、-db で magic_types テーブル）から読み込みます。
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"server/internal/config"
+	"server/internal/data"
+	domainmagic "server/internal/domain/magic"
+	"server/internal/game/battle"
+	"server/internal/game/simulation"
+	"server/internal/infrastructure/database"
+	"server/internal/infrastructure/repository"
+)
+
+func main() {
+	if err := config.LoadEnvFiles(".env", "../.env"); err != nil {
+		slog.Warn("failed to load env file", "error", err)
+	}
+
+	// 防御行動はサーバーと同じ BATTLE_* の設定を使う
+	battleConfig, err := config.LoadBattle()
+	if err != nil {
+		fatal("failed to load config", err)
+	}
+	defaults := simulation.DefaultRules(battle.DefenseRules{
+		GuardMPCost:    battleConfig.GuardMPCost,
+		GuardDuration:  battleConfig.GuardDuration,
+		GuardReduction: battleConfig.GuardReduction,
+		ShieldMPCost:   battleConfig.ShieldMPCost,
+		ShieldDuration: battleConfig.ShieldDuration,
+		ShieldAbsorb:   battleConfig.ShieldAbsorb,
+	})
+
+	duels := flag.Int("duels", 1000, "組み合わせごとの対戦回数")
+	strategies := flag.String("strategies", "random,greedy,efficient,defensive", "総当たりで対戦させる戦略（random, greedy, efficient, defensive, only:<id> のカンマ区切り）")
+	seed := flag.Uint64("seed", 1, "乱数のシード（同じ値なら同じ結果）")
+	format := flag.String("format", "json", "出力形式（json または csv）")
+	table := flag.String("table", "spells", "CSV で出力する表（spells または matchups）")
+	output := flag.String("o", "", "出力先のファイル（省略時は標準出力）")
+	magicTypesPath := flag.String("magic-types", "", "魔法の定義の JSON ファイル（magic_types.json の形式。省略時は埋め込みの初期データ）")
+	fromDB := flag.Bool("db", false, "DATABASE_URL の magic_types テーブルから魔法を読み込む")
+	hp := flag.Int("hp", defaults.StartHP, "開始時の HP")
+	mp := flag.Int("mp", defaults.StartMP, "開始時の MP（対戦中は回復しない）")
+	chantErrorRate := flag.Float64("chant-error-rate", defaults.ChantErrorRate, "詠唱の 1 文字が聞き取れずに抜け落ちる確率（0〜1）")
+	actionInterval := flag.Duration("action-interval", defaults.ActionInterval, "行動してから次の行動を始めるまでの時間")
+	maxDuration := flag.Duration("max-duration", defaults.MaxDuration, "この時間で決着しなければ引き分け")
+	flag.Parse()
+
+	if *duels <= 0 || *hp <= 0 || *mp <= 0 || *chantErrorRate < 0 || *chantErrorRate > 1 || *actionInterval <= 0 || *maxDuration <= 0 {
+		fatal("invalid flags", errors.New("duels, hp, mp, action-interval and max-duration must be positive and chant-error-rate must be between 0 and 1"))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	magicTypes, err := loadMagicTypes(ctx, *magicTypesPath, *fromDB)
+	if err != nil {
+		fatal("failed to load magic types", err)
+	}
+	if len(magicTypes) == 0 {
+		fatal("failed to load magic types", errors.New("no magic types"))
+	}
+
+	spells := make([]simulation.Spell, 0, len(magicTypes))
+	for _, magicType := range magicTypes {
+		if err := magicType.Validate(); err != nil {
+			fatal("invalid magic type", err)
+		}
+		spells = append(spells, simulation.Spell{MagicType: magicType})
+	}
+
+	var players []simulation.Strategy
+	for _, name := range strings.Split(*strategies, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		strategy, err := simulation.ParseStrategy(name, spells)
+		if err != nil {
+			fatal("invalid strategy", err)
+		}
+		players = append(players, strategy)
+	}
+	if len(players) == 0 {
+		fatal("invalid strategy", errors.New("at least one strategy is required"))
+	}
+
+	rules := defaults
+	rules.StartHP = *hp
+	rules.StartMP = *mp
+	rules.ChantErrorRate = *chantErrorRate
+	rules.ActionInterval = *actionInterval
+	rules.MaxDuration = *maxDuration
+
+	report := simulation.Run(simulation.Options{
+		Rules:      rules,
+		Spells:     spells,
+		Strategies: players,
+		Duels:      *duels,
+		Seed:       *seed,
+	})
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fatal("failed to create output file", err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	switch *format {
+	case "json":
+		err = simulation.WriteJSON(w, report)
+	case "csv":
+		err = simulation.WriteCSV(w, report, *table)
+	default:
+		err = fmt.Errorf("unknown format %q (use json or csv)", *format)
+	}
+	if err != nil {
+		fatal("failed to write report", err)
+	}
+}
+
+// loadMagicTypes は魔法の定義をファイル・データベース・埋め込みの初期データのいずれかから読み込みます
+func loadMagicTypes(ctx context.Context, path string, fromDB bool) ([]domainmagic.MagicType, error) {
+	if fromDB {
+		dbConfig, err := config.LoadDatabase()
+		if err != nil {
+			return nil, err
+		}
+		if dbConfig.URL == "" {
+			return nil, errors.New("DATABASE_URL or SUPABASE_DB_URL is required with -db")
+		}
+		db, err := database.Open(ctx, database.Options{URL: dbConfig.URL, ConnectTimeout: dbConfig.ConnectTimeout})
+		if err != nil {
+			return nil, err
+		}
+		defer db.Close()
+		return repository.NewMagicTypeRepository(db).List(ctx)
+	}
+
+	var list *data.MagicTypeList
+	if path == "" {
+		var err error
+		if list, err = data.DefaultMagicTypes(); err != nil {
+			return nil, err
+		}
+	} else {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		list = &data.MagicTypeList{}
+		if err := json.Unmarshal(content, list); err != nil {
+			return nil, fmt.Errorf("decode %s: %w", path, err)
+		}
+	}
+
+	magicTypes := make([]domainmagic.MagicType, 0, len(list.MagicTypes))
+	for _, m := range list.MagicTypes {
+		magicTypes = append(magicTypes, domainmagic.MagicType{
+			ID:          m.ID,
+			Name:        m.Name,
+			Kind:        domainmagic.Kind(m.Kind),
+			Target:      domainmagic.Target(m.Target),
+			MPCost:      m.MPCost,
+			Description: m.Description,
+			Damage:      m.Damage,
+			Heal:        m.Heal,
+			Sound:       m.Sound,
+			Chant:       m.Chant,
+			ChantMatch:  domainmagic.ChantMatch(m.ChantMatch),
+			Cooldown:    time.Duration(m.CooldownMS) * time.Millisecond,
+			CastTime:    time.Duration(m.CastTimeMS) * time.Millisecond,
+			Stun:        time.Duration(m.StunMS) * time.Millisecond,
+		})
+	}
+	return magicTypes, nil
+}
+
+// fatal はエラーを記録して異常終了します
+func fatal(message string, err error) {
+	slog.Error(message, "error", err)
+	os.Exit(1)
+}
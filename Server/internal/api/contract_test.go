@@ -0,0 +1,320 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+
+	"server/internal/config"
+	"server/internal/idempotency"
+	"server/internal/openapi"
+)
+
+const (
+	contractMetricsToken = "contract-metrics-token"
+	contractAdminToken   = "contract-admin-token"
+)
+
+// contractStep は契約テストで送る 1 リクエストです。
+// path / body / auth の $name は前のステップで capture した値に置き換えます。
+type contractStep struct {
+	method string
+	path   string
+	body   string
+	auth   string
+	want   int    // 0 の場合はステータスを問わない（スキーマのみ検証）
+	allow  string // 405 の場合に期待する Allow ヘッダー
+
+	idempotencyKey string // Idempotency-Key ヘッダー
+	replayed       bool   // 保存したレスポンスの再送（Idempotent-Replayed: true）を期待する
+
+	invalidRequest bool                                        // 仕様に反するリクエストをわざと送る（リクエストの検証をしない）
+	capture        func(body map[string]any) map[string]string // レスポンスから後続で使う値を取り出す
+}
+
+// TestContract_RoutesMatchOpenAPI は NewRouter に登録したルートと openapi.yaml が一致し、
+// 実際のハンドラーのレスポンスが仕様のスキーマに従うことを確認します。
+func TestContract_RoutesMatchOpenAPI(t *testing.T) {
+	doc, err := openapi.Load()
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+
+	t.Setenv("STORAGE_BACKEND", config.StorageBackendMemory)
+	t.Setenv("APP_ENV", config.EnvironmentDevelopment)
+	t.Setenv("JWT_SECRET", "contract-secret")
+	t.Setenv("METRICS_TOKEN", contractMetricsToken)
+	t.Setenv("ADMIN_TOKEN", contractAdminToken)
+	// 認証エンドポイントは 5 回目まで受け付け、最後のステップで 429 を確認する
+	t.Setenv("RATE_LIMIT_AUTH", "5/1h")
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	router, patterns, err := newRouter(ctx, nil, cfg)
+	if err != nil {
+		t.Fatalf("new router: %v", err)
+	}
+
+	// ルートと仕様のパスが 1 対 1 に対応していること
+	registered := make(map[string]bool, len(patterns))
+	for _, pattern := range patterns {
+		registered[pattern] = true
+		if doc.Paths.Value(pattern) == nil {
+			t.Errorf("route %q is registered but missing from openapi.yaml", pattern)
+		}
+	}
+	for path := range doc.Paths.Map() {
+		if !registered[path] {
+			t.Errorf("openapi.yaml documents %q but NewRouter does not register it", path)
+		}
+	}
+
+	startsAt := time.Now().Add(2 * time.Hour).UTC().Truncate(time.Minute)
+	reservation := `{"stageId":"a0000000-0000-4000-8000-000000000002","startsAt":"` + startsAt.Format(time.RFC3339) +
+		`","endsAt":"` + startsAt.Add(30*time.Minute).Format(time.RFC3339) + `"}`
+	credentials := `{"email":"contract@example.com","password":"Passw0rd!"}`
+	captureToken := func(body map[string]any) map[string]string {
+		return map[string]string{"token": body["access_token"].(string)}
+	}
+
+	steps := []contractStep{
+		{method: http.MethodGet, path: "/health", want: http.StatusOK},
+		{method: http.MethodPost, path: "/health", want: http.StatusMethodNotAllowed, allow: "GET, HEAD", invalidRequest: true},
+		{method: http.MethodGet, path: "/readyz", want: http.StatusOK},
+		{method: http.MethodGet, path: "/supabase/health", want: http.StatusServiceUnavailable},
+		{method: http.MethodGet, path: "/metrics", auth: "Bearer " + contractMetricsToken, want: http.StatusOK},
+		{method: http.MethodGet, path: "/metrics", want: http.StatusUnauthorized, invalidRequest: true},
+		{method: http.MethodGet, path: "/openapi.json", want: http.StatusOK},
+		{method: http.MethodGet, path: "/ws", want: http.StatusBadRequest},
+		{method: http.MethodGet, path: "/api/magic-types", want: http.StatusOK},
+
+		{method: http.MethodPost, path: "/admin/magic-types", auth: "Bearer " + contractAdminToken, want: http.StatusCreated,
+			body: `{"id":"ice_lance","name":"アイスランス","mp_cost":30,"description":"氷の槍を放つ。","damage":25,"sound":"","chant":"凍てつく槍よ、貫け","chant_match":"keyword","cooldown_ms":3000,"cast_time_ms":1000}`},
+		{method: http.MethodPost, path: "/admin/magic-types", auth: "Bearer " + contractAdminToken, want: http.StatusConflict,
+			body: `{"id":"fireball","name":"ファイアボール","mp_cost":40,"damage":30,"chant":"炎よ"}`},
+		{method: http.MethodPost, path: "/admin/magic-types", auth: "Bearer " + contractAdminToken, want: http.StatusBadRequest, invalidRequest: true,
+			body: `{"id":"Ice Lance","name":"アイスランス","mp_cost":0,"damage":5000,"chant":"","chant_match":"loose","cooldown_ms":-1}`},
+		{method: http.MethodPost, path: "/admin/magic-types", auth: "Bearer not-the-admin-token", want: http.StatusUnauthorized, invalidRequest: true,
+			body: `{"id":"ice_lance","name":"アイスランス","mp_cost":30,"damage":25,"chant":"氷よ"}`},
+		{method: http.MethodPut, path: "/admin/magic-types/ice_lance", auth: "Bearer " + contractAdminToken, want: http.StatusOK,
+			body: `{"name":"アイスランス","mp_cost":35,"description":"氷の槍を放つ。","damage":28,"sound":"ice.mp3","chant":"凍てつく槍よ、貫け","chant_match":"fuzzy"}`},
+		{method: http.MethodPut, path: "/admin/magic-types/no_such_magic", auth: "Bearer " + contractAdminToken, want: http.StatusNotFound,
+			body: `{"name":"なし","mp_cost":1,"damage":1,"chant":"なし"}`},
+		{method: http.MethodDelete, path: "/admin/magic-types/ice_lance", auth: "Bearer " + contractAdminToken, want: http.StatusNoContent},
+		{method: http.MethodDelete, path: "/admin/magic-types/ice_lance", auth: "Bearer " + contractAdminToken, want: http.StatusNotFound},
+		{method: http.MethodPost, path: "/admin/magic-types", auth: "Bearer " + contractAdminToken, want: http.StatusCreated,
+			body: `{"id":"mend","name":"メンド","kind":"heal","target":"ally","mp_cost":20,"heal":15,"chant":"癒やしの風よ"}`},
+		{method: http.MethodPost, path: "/admin/magic-types", auth: "Bearer " + contractAdminToken, want: http.StatusBadRequest,
+			body: `{"id":"drain","name":"ドレイン","kind":"heal","target":"enemy","mp_cost":20,"heal":15,"chant":"奪え"}`},
+		{method: http.MethodDelete, path: "/admin/magic-types/mend", auth: "Bearer " + contractAdminToken, want: http.StatusNoContent},
+
+		{method: http.MethodPost, path: "/auth/signup", body: `{"email":"contract@example.com","password":"Passw0rd!","full_name":"Contract"}`, want: http.StatusCreated, capture: captureToken},
+		{method: http.MethodPost, path: "/auth/signup", body: `{"email":"contract@example.com","password":"Passw0rd!"}`, want: http.StatusConflict},
+		{method: http.MethodPost, path: "/auth/signin", body: credentials, want: http.StatusOK, capture: captureToken},
+		{method: http.MethodPost, path: "/auth/signin", body: `{"email":"contract@example.com","password":"wrong-password"}`, want: http.StatusUnauthorized},
+		{method: http.MethodGet, path: "/protected", auth: "Bearer $token", want: http.StatusOK},
+		{method: http.MethodGet, path: "/protected", want: http.StatusUnauthorized, invalidRequest: true},
+
+		{method: http.MethodGet, path: "/api/hp", auth: "Bearer $token", want: http.StatusOK},
+		{method: http.MethodPut, path: "/api/hp/update", auth: "Bearer $token", body: `{"hp":50}`, want: http.StatusOK},
+		{method: http.MethodPut, path: "/api/hp/update", auth: "Bearer $token", body: `{"hp":80}`, want: http.StatusBadRequest},
+		{method: http.MethodPut, path: "/api/hp/update", auth: "Bearer $token", body: `{"hp":5000}`, want: http.StatusBadRequest, invalidRequest: true},
+		{method: http.MethodPut, path: "/api/hp/update", auth: "Bearer $token", body: `{}`, want: http.StatusBadRequest, invalidRequest: true},
+		{method: http.MethodGet, path: "/api/mp", auth: "Bearer $token", want: http.StatusOK},
+		{method: http.MethodPut, path: "/api/mp/update", auth: "Bearer $token", body: `{"mp":300}`, want: http.StatusBadRequest},
+		{method: http.MethodPut, path: "/api/mp/update", auth: "Bearer $token", body: `{"mp":60}`, idempotencyKey: "contract-mp", want: http.StatusOK},
+		{method: http.MethodPut, path: "/api/mp/update", auth: "Bearer $token", body: `{"mp":60}`, idempotencyKey: "contract-mp", want: http.StatusOK, replayed: true},
+		{method: http.MethodPut, path: "/api/mp/update", auth: "Bearer $token", body: `{"mp":40}`, idempotencyKey: "contract-mp", want: http.StatusUnprocessableEntity},
+
+		{method: http.MethodGet, path: "/game?lat=35.6595&lng=139.7005&radius=3000&limit=2", want: http.StatusOK},
+		{method: http.MethodGet, path: "/game?lat=north&lng=139.7005", want: http.StatusBadRequest, invalidRequest: true},
+		{method: http.MethodPost, path: "/api/reservations", auth: "Bearer $token", body: reservation, want: http.StatusCreated,
+			capture: func(body map[string]any) map[string]string {
+				return map[string]string{"reservationId": body["id"].(string)}
+			}},
+		{method: http.MethodPost, path: "/api/reservations", auth: "Bearer $token", body: reservation, want: http.StatusConflict},
+		{method: http.MethodGet, path: "/game?lat=35.6717&lng=139.6949&radius=3000", want: http.StatusOK},
+		{method: http.MethodDelete, path: "/api/reservations/$reservationId", auth: "Bearer $token", want: http.StatusNoContent},
+		{method: http.MethodDelete, path: "/api/reservations/$reservationId", auth: "Bearer $token", want: http.StatusNotFound},
+		{method: http.MethodGet, path: "/api/reservations", auth: "Bearer $token", want: http.StatusMethodNotAllowed, allow: "POST", invalidRequest: true},
+		{method: http.MethodDelete, path: "/api/reservations?id=$reservationId", auth: "Bearer $token", want: http.StatusMethodNotAllowed, allow: "POST", invalidRequest: true},
+
+		{method: http.MethodPost, path: "/api/battles", auth: "Bearer $token", body: `{"stageId":"a0000000-0000-4000-8000-000000000001"}`, want: http.StatusCreated,
+			capture: func(body map[string]any) map[string]string {
+				return map[string]string{"sessionId": body["id"].(string)}
+			}},
+		{method: http.MethodPost, path: "/api/battles", auth: "Bearer $token", body: `{"stageId":"no-such-stage"}`, want: http.StatusNotFound},
+		{method: http.MethodPost, path: "/api/battles", auth: "Bearer $token", body: `{"stage":"a0000000-0000-4000-8000-000000000001"}`, want: http.StatusBadRequest, invalidRequest: true},
+		{method: http.MethodGet, path: "/api/battles", auth: "Bearer $token", want: http.StatusMethodNotAllowed, allow: "POST", invalidRequest: true},
+		// WebSocket のハンドシェイクを伴わないため、参加する前のアップグレードで 400 になる
+		{method: http.MethodGet, path: "/ws/battle?sessionId=$sessionId", auth: "Bearer $token", want: http.StatusBadRequest},
+
+		{method: http.MethodPost, path: "/auth/refresh", auth: "Bearer $token", want: http.StatusOK, capture: captureToken},
+		{method: http.MethodPost, path: "/auth/logout", auth: "Bearer $token", want: http.StatusOK},
+		{method: http.MethodPost, path: "/auth/signin", body: credentials, want: http.StatusTooManyRequests},
+	}
+
+	options := &openapi3filter.Options{
+		IncludeResponseStatus: true,
+		AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+	}
+	vars := map[string]string{}
+	expand := func(s string) string {
+		return os.Expand(s, func(name string) string { return vars[name] })
+	}
+	covered := map[string]bool{}
+
+	for _, step := range steps {
+		target, body, auth := expand(step.path), expand(step.body), expand(step.auth)
+		name := step.method + " " + target
+
+		requestURL, err := url.Parse(target)
+		if err != nil {
+			t.Fatalf("%s: parse url: %v", name, err)
+		}
+		specPath, pathParams := matchSpecPath(doc, requestURL.Path)
+		pathItem := doc.Paths.Value(specPath)
+		if pathItem == nil {
+			t.Errorf("%s: path is not documented", name)
+			continue
+		}
+		operation := pathItem.GetOperation(step.method)
+		switch {
+		case operation != nil:
+			covered[step.method+" "+specPath] = true
+		case step.want == http.StatusMethodNotAllowed:
+			// 405 はどの操作にも属さないため、同じパスの別の操作に定義した 405 の形式で検証する
+			for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete} {
+				if other := pathItem.GetOperation(method); other != nil && other.Responses.Status(http.StatusMethodNotAllowed) != nil {
+					operation = other
+					break
+				}
+			}
+		default:
+			t.Errorf("%s: operation is not documented", name)
+			continue
+		}
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, newContractRequest(step.method, target, body, auth, step.idempotencyKey))
+
+		if step.want != 0 && rec.Code != step.want {
+			t.Errorf("%s: status = %d, want %d (body: %s)", name, rec.Code, step.want, rec.Body.String())
+			continue
+		}
+		if step.allow != "" && rec.Header().Get("Allow") != step.allow {
+			t.Errorf("%s: Allow = %q, want %q", name, rec.Header().Get("Allow"), step.allow)
+		}
+		if replayed := rec.Header().Get(idempotency.ReplayedHeader) == "true"; replayed != step.replayed {
+			t.Errorf("%s: replayed = %v, want %v", name, replayed, step.replayed)
+		}
+
+		route := &routers.Route{Spec: doc, Path: specPath, PathItem: pathItem, Method: step.method, Operation: operation}
+		requestInput := &openapi3filter.RequestValidationInput{
+			Request:    newContractRequest(step.method, target, body, auth, step.idempotencyKey),
+			PathParams: pathParams,
+			Route:      route,
+			Options:    options,
+		}
+		if !step.invalidRequest {
+			if err := openapi3filter.ValidateRequest(context.Background(), requestInput); err != nil {
+				t.Errorf("%s: request does not match openapi.yaml: %v", name, err)
+			}
+		}
+		responseInput := &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: requestInput,
+			Status:                 rec.Code,
+			Header:                 rec.Header(),
+			Options:                options,
+		}
+		responseInput.SetBodyBytes(rec.Body.Bytes())
+		if err := openapi3filter.ValidateResponse(context.Background(), responseInput); err != nil {
+			t.Errorf("%s: response does not match openapi.yaml: %v", name, err)
+		}
+
+		if step.capture != nil {
+			var payload map[string]any
+			if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
+				t.Fatalf("%s: decode response: %v", name, err)
+			}
+			for key, value := range step.capture(payload) {
+				vars[key] = value
+			}
+		}
+	}
+
+	// 仕様にあるすべての操作を少なくとも 1 回は呼んでいること
+	var missing []string
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			if !covered[method+" "+path] {
+				missing = append(missing, method+" "+path)
+			}
+		}
+	}
+	sort.Strings(missing)
+	if len(missing) > 0 {
+		t.Errorf("operations without a contract step: %s", strings.Join(missing, ", "))
+	}
+}
+
+// matchSpecPath は実際のパスに一致する仕様のパス（"/api/reservations/{id}" など）とパスパラメーターを返します
+func matchSpecPath(doc *openapi3.T, path string) (string, map[string]string) {
+	if doc.Paths.Value(path) != nil {
+		return path, nil
+	}
+	segments := strings.Split(path, "/")
+	for template := range doc.Paths.Map() {
+		templateSegments := strings.Split(template, "/")
+		if len(templateSegments) != len(segments) {
+			continue
+		}
+		params := map[string]string{}
+		for i, segment := range templateSegments {
+			if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+				params[strings.Trim(segment, "{}")] = segments[i]
+			} else if segment != segments[i] {
+				params = nil
+				break
+			}
+		}
+		if params != nil {
+			return template, params
+		}
+	}
+	return path, nil
+}
+
+func newContractRequest(method, target, body, auth, idempotencyKey string) *http.Request {
+	var reader io.Reader
+	if body != "" {
+		reader = bytes.NewBufferString(body)
+	}
+	req := httptest.NewRequest(method, target, reader)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	if idempotencyKey != "" {
+		req.Header.Set(idempotency.Header, idempotencyKey)
+	}
+	return req
+}
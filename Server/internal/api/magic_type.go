@@ -0,0 +1,297 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"server/internal/apierror"
+	"server/internal/data"
+	domainmagic "server/internal/domain/magic"
+	"server/internal/request"
+)
+
+// MagicTypeCatalog は魔法の定義のユースケースのインターフェースです。
+type MagicTypeCatalog interface {
+	List(ctx context.Context) ([]domainmagic.MagicType, error)
+	Create(ctx context.Context, magicType *domainmagic.MagicType) error
+	Update(ctx context.Context, magicType *domainmagic.MagicType) error
+	Delete(ctx context.Context, id string) error
+}
+
+type createMagicTypeRequest struct {
+	ID          string `json:"id" validate:"required,max=64,slug"`
+	Name        string `json:"name" validate:"required,max=100"`
+	Kind        string `json:"kind" validate:"omitempty,oneof=attack heal"`
+	Target      string `json:"target" validate:"omitempty,oneof=enemy self ally"`
+	MPCost      int    `json:"mp_cost" validate:"min=1,max=1000"`
+	Description string `json:"description" validate:"max=500"`
+	Damage      int    `json:"damage" validate:"min=0,max=1000"`
+	Heal        int    `json:"heal" validate:"min=0,max=1000"`
+	Sound       string `json:"sound" validate:"max=200"`
+	Chant       string `json:"chant" validate:"required,max=200"`
+	ChantMatch  string `json:"chant_match" validate:"omitempty,oneof=exact fuzzy keyword"`
+	CooldownMS  int    `json:"cooldown_ms" validate:"min=0,max=60000"`
+	CastTimeMS  int    `json:"cast_time_ms" validate:"min=0,max=10000"`
+	StunMS      int    `json:"stun_ms" validate:"min=0,max=10000"`
+}
+
+// Normalize は前後の空白を除き、省略された照合方法・種類・対象に既定値を入れます
+func (r *createMagicTypeRequest) Normalize() {
+	r.ID = strings.TrimSpace(r.ID)
+	r.Name = strings.TrimSpace(r.Name)
+	r.Description = strings.TrimSpace(r.Description)
+	r.Sound = strings.TrimSpace(r.Sound)
+	r.Chant = strings.TrimSpace(r.Chant)
+	if r.ChantMatch == "" {
+		r.ChantMatch = string(domainmagic.ChantFuzzy)
+	}
+	if r.Kind == "" {
+		r.Kind = string(domainmagic.KindAttack)
+	}
+	if r.Target == "" {
+		r.Target = string(domainmagic.Kind(r.Kind).DefaultTarget())
+	}
+}
+
+// updateMagicTypeRequest は ID（パスで指定する）以外の項目です
+type updateMagicTypeRequest struct {
+	Name        string `json:"name" validate:"required,max=100"`
+	Kind        string `json:"kind" validate:"omitempty,oneof=attack heal"`
+	Target      string `json:"target" validate:"omitempty,oneof=enemy self ally"`
+	MPCost      int    `json:"mp_cost" validate:"min=1,max=1000"`
+	Description string `json:"description" validate:"max=500"`
+	Damage      int    `json:"damage" validate:"min=0,max=1000"`
+	Heal        int    `json:"heal" validate:"min=0,max=1000"`
+	Sound       string `json:"sound" validate:"max=200"`
+	Chant       string `json:"chant" validate:"required,max=200"`
+	ChantMatch  string `json:"chant_match" validate:"omitempty,oneof=exact fuzzy keyword"`
+	CooldownMS  int    `json:"cooldown_ms" validate:"min=0,max=60000"`
+	CastTimeMS  int    `json:"cast_time_ms" validate:"min=0,max=10000"`
+	StunMS      int    `json:"stun_ms" validate:"min=0,max=10000"`
+}
+
+// Normalize は前後の空白を除き、省略された照合方法・種類・対象に既定値を入れます
+func (r *updateMagicTypeRequest) Normalize() {
+	r.Name = strings.TrimSpace(r.Name)
+	r.Description = strings.TrimSpace(r.Description)
+	r.Sound = strings.TrimSpace(r.Sound)
+	r.Chant = strings.TrimSpace(r.Chant)
+	if r.ChantMatch == "" {
+		r.ChantMatch = string(domainmagic.ChantFuzzy)
+	}
+	if r.Kind == "" {
+		r.Kind = string(domainmagic.KindAttack)
+	}
+	if r.Target == "" {
+		r.Target = string(domainmagic.Kind(r.Kind).DefaultTarget())
+	}
+}
+
+// listMagicTypes は GET /api/magic-types で魔法の一覧を返します
+func (h *Handler) listMagicTypes(w http.ResponseWriter, r *http.Request) {
+	if h.magicTypes == nil {
+		apierror.Write(w, r, apierror.New(apierror.CodeServiceUnavailable, "database client not ready"))
+		return
+	}
+
+	list, err := h.magicTypes.List(r.Context())
+	if err != nil {
+		apierror.Write(w, r, apierror.Wrap(err, apierror.CodeUpstream, "failed to load magic types"))
+		return
+	}
+
+	response := data.MagicTypeList{MagicTypes: make([]data.MagicType, 0, len(list))}
+	for _, magicType := range list {
+		response.MagicTypes = append(response.MagicTypes, toMagicTypeResponse(magicType))
+	}
+	respondJSON(w, http.StatusOK, response)
+}
+
+// createMagicType は POST /admin/magic-types で魔法を追加します
+func (h *Handler) createMagicType(w http.ResponseWriter, r *http.Request) {
+	if h.magicTypes == nil {
+		apierror.Write(w, r, apierror.New(apierror.CodeServiceUnavailable, "database client not ready"))
+		return
+	}
+
+	var req createMagicTypeRequest
+	if err := request.Decode(w, r, &req, request.DisallowUnknownFields()); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	magicType := &domainmagic.MagicType{
+		ID:          req.ID,
+		Name:        req.Name,
+		Kind:        domainmagic.Kind(req.Kind),
+		Target:      domainmagic.Target(req.Target),
+		MPCost:      req.MPCost,
+		Description: req.Description,
+		Damage:      req.Damage,
+		Heal:        req.Heal,
+		Sound:       req.Sound,
+		Chant:       req.Chant,
+		ChantMatch:  domainmagic.ChantMatch(req.ChantMatch),
+		Cooldown:    time.Duration(req.CooldownMS) * time.Millisecond,
+		CastTime:    time.Duration(req.CastTimeMS) * time.Millisecond,
+		Stun:        time.Duration(req.StunMS) * time.Millisecond,
+	}
+	if err := h.magicTypes.Create(r.Context(), magicType); err != nil {
+		if errors.Is(err, domainmagic.ErrMagicTypeExists) {
+			apierror.Write(w, r, apierror.New(apierror.CodeMagicTypeExists, "a magic type with this id already exists"))
+			return
+		}
+		if errors.Is(err, domainmagic.ErrInvalidMagicType) {
+			apierror.Write(w, r, invalidMagicTypeError(err))
+			return
+		}
+		apierror.Write(w, r, apierror.Wrap(err, apierror.CodeUpstream, "failed to create magic type"))
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, toMagicTypeResponse(*magicType))
+}
+
+// updateMagicType は PUT /admin/magic-types/{id} で魔法を置き換えます
+func (h *Handler) updateMagicType(w http.ResponseWriter, r *http.Request) {
+	if h.magicTypes == nil {
+		apierror.Write(w, r, apierror.New(apierror.CodeServiceUnavailable, "database client not ready"))
+		return
+	}
+
+	var req updateMagicTypeRequest
+	if err := request.Decode(w, r, &req, request.DisallowUnknownFields()); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	magicType := &domainmagic.MagicType{
+		ID:          r.PathValue("id"),
+		Name:        req.Name,
+		Kind:        domainmagic.Kind(req.Kind),
+		Target:      domainmagic.Target(req.Target),
+		MPCost:      req.MPCost,
+		Description: req.Description,
+		Damage:      req.Damage,
+		Heal:        req.Heal,
+		Sound:       req.Sound,
+		Chant:       req.Chant,
+		ChantMatch:  domainmagic.ChantMatch(req.ChantMatch),
+		Cooldown:    time.Duration(req.CooldownMS) * time.Millisecond,
+		CastTime:    time.Duration(req.CastTimeMS) * time.Millisecond,
+		Stun:        time.Duration(req.StunMS) * time.Millisecond,
+	}
+	if err := h.magicTypes.Update(r.Context(), magicType); err != nil {
+		if errors.Is(err, domainmagic.ErrMagicTypeNotFound) {
+			apierror.Write(w, r, apierror.New(apierror.CodeMagicTypeNotFound, "magic type not found"))
+			return
+		}
+		if errors.Is(err, domainmagic.ErrInvalidMagicType) {
+			apierror.Write(w, r, invalidMagicTypeError(err))
+			return
+		}
+		apierror.Write(w, r, apierror.Wrap(err, apierror.CodeUpstream, "failed to update magic type"))
+		return
+	}
+
+	respondJSON(w, http.StatusOK, toMagicTypeResponse(*magicType))
+}
+
+// deleteMagicType は DELETE /admin/magic-types/{id} で魔法を削除します
+func (h *Handler) deleteMagicType(w http.ResponseWriter, r *http.Request) {
+	if h.magicTypes == nil {
+		apierror.Write(w, r, apierror.New(apierror.CodeServiceUnavailable, "database client not ready"))
+		return
+	}
+
+	if err := h.magicTypes.Delete(r.Context(), r.PathValue("id")); err != nil {
+		if errors.Is(err, domainmagic.ErrMagicTypeNotFound) {
+			apierror.Write(w, r, apierror.New(apierror.CodeMagicTypeNotFound, "magic type not found"))
+			return
+		}
+		apierror.Write(w, r, apierror.Wrap(err, apierror.CodeUpstream, "failed to delete magic type"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// invalidMagicTypeError は魔法の検証エラーを、範囲外の項目をフィールドに持つ 400 にします
+func invalidMagicTypeError(err error) error {
+	var invalid *domainmagic.ValidationError
+	if !errors.As(err, &invalid) {
+		return apierror.New(apierror.CodeValidation, err.Error())
+	}
+	return &apierror.Error{
+		Status:  http.StatusBadRequest,
+		Code:    apierror.CodeValidation,
+		Message: invalid.Message,
+		Fields:  []apierror.FieldError{{Field: invalid.Field, Code: invalid.Rule, Message: invalid.Message}},
+	}
+}
+
+func toMagicTypeResponse(magicType domainmagic.MagicType) data.MagicType {
+	return data.MagicType{
+		ID:          magicType.ID,
+		Name:        magicType.Name,
+		Kind:        string(magicType.Kind),
+		Target:      string(magicType.Target),
+		MPCost:      magicType.MPCost,
+		Description: magicType.Description,
+		Damage:      magicType.Damage,
+		Heal:        magicType.Heal,
+		Sound:       magicType.Sound,
+		Chant:       magicType.Chant,
+		ChantMatch:  string(magicType.ChantMatch),
+		CooldownMS:  int(magicType.Cooldown.Milliseconds()),
+		CastTimeMS:  int(magicType.CastTime.Milliseconds()),
+		StunMS:      int(magicType.Stun.Milliseconds()),
+	}
+}
+
+// defaultMagicTypes は埋め込みの初期データをドメインの型にします
+func defaultMagicTypes() ([]domainmagic.MagicType, error) {
+	list, err := data.DefaultMagicTypes()
+	if err != nil {
+		return nil, err
+	}
+	magicTypes := make([]domainmagic.MagicType, 0, len(list.MagicTypes))
+	for _, magicType := range list.MagicTypes {
+		magicTypes = append(magicTypes, domainmagic.MagicType{
+			ID:          magicType.ID,
+			Name:        magicType.Name,
+			Kind:        domainmagic.Kind(magicType.Kind),
+			Target:      domainmagic.Target(magicType.Target),
+			MPCost:      magicType.MPCost,
+			Description: magicType.Description,
+			Damage:      magicType.Damage,
+			Heal:        magicType.Heal,
+			Sound:       magicType.Sound,
+			Chant:       magicType.Chant,
+			ChantMatch:  domainmagic.ChantMatch(magicType.ChantMatch),
+			Cooldown:    time.Duration(magicType.CooldownMS) * time.Millisecond,
+			CastTime:    time.Duration(magicType.CastTimeMS) * time.Millisecond,
+			Stun:        time.Duration(magicType.StunMS) * time.Millisecond,
+		})
+	}
+	return magicTypes, nil
+}
+
+// requireAdminToken は Authorization: Bearer <token> が ADMIN_TOKEN と一致する場合のみ next を呼びます
+func requireAdminToken(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+				apierror.Write(w, r, apierror.New(apierror.CodeUnauthorized, "Admin token required"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
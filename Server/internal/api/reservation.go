@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"server/internal/apierror"
+	appbattlestage "server/internal/application/battlestage"
+	"server/internal/auth"
+	domainbattlestage "server/internal/domain/battlestage"
+	"server/internal/request"
+
+	"github.com/google/uuid"
+)
+
+// StageReservationService はステージの空き状況と予約のユースケースのインターフェースです。
+type StageReservationService interface {
+	Availability(ctx context.Context, stageIDs []string) (map[string]domainbattlestage.Availability, error)
+	Reserve(ctx context.Context, stageID string, userID uuid.UUID, startsAt, endsAt time.Time) (*domainbattlestage.Reservation, error)
+	Cancel(ctx context.Context, reservationID, userID uuid.UUID) error
+}
+
+type createReservationRequest struct {
+	StageID  string    `json:"stageId" validate:"required,max=64"`
+	StartsAt time.Time `json:"startsAt" validate:"required"`
+	EndsAt   time.Time `json:"endsAt" validate:"required,gtfield=StartsAt"`
+}
+
+// Normalize は前後の空白を除きます
+func (r *createReservationRequest) Normalize() {
+	r.StageID = strings.TrimSpace(r.StageID)
+}
+
+type reservationResponse struct {
+	ID       string    `json:"id"`
+	StageID  string    `json:"stageId"`
+	UserID   string    `json:"userId,omitempty"`
+	StartsAt time.Time `json:"startsAt"`
+	EndsAt   time.Time `json:"endsAt"`
+}
+
+type timeSlotResponse struct {
+	StartsAt time.Time `json:"startsAt"`
+	EndsAt   time.Time `json:"endsAt"`
+}
+
+type stageAvailabilityResponse struct {
+	Available      bool                  `json:"available"`
+	ActiveSessions []string              `json:"activeSessions"`
+	Reservations   []reservationResponse `json:"reservations"`
+	NextFreeSlot   timeSlotResponse      `json:"nextFreeSlot"`
+}
+
+// createReservation は POST /api/reservations で予約を作成します
+func (h *Handler) createReservation(w http.ResponseWriter, r *http.Request) {
+	if h.reservationService == nil {
+		apierror.Write(w, r, apierror.New(apierror.CodeServiceUnavailable, "database client not ready"))
+		return
+	}
+
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, r, apierror.Internal(errors.New("user ID not found in context")))
+		return
+	}
+
+	var req createReservationRequest
+	if err := request.Decode(w, r, &req, request.DisallowUnknownFields()); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	reservation, err := h.reservationService.Reserve(r.Context(), req.StageID, userID, req.StartsAt, req.EndsAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, appbattlestage.ErrInvalidReservation):
+			apierror.Write(w, r, apierror.New(apierror.CodeInvalidReservation, err.Error()))
+		case errors.Is(err, domainbattlestage.ErrStageNotFound):
+			apierror.Write(w, r, apierror.New(apierror.CodeStageNotFound, "battle stage not found"))
+		case errors.Is(err, domainbattlestage.ErrReservationConflict):
+			apierror.Write(w, r, apierror.New(apierror.CodeReservationConflict, "the stage is already booked or in use for this time slot"))
+		default:
+			apierror.Write(w, r, apierror.Wrap(err, apierror.CodeUpstream, "failed to create reservation"))
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, toReservationResponse(*reservation))
+}
+
+// cancelReservation は自分の予約を取り消します
+func (h *Handler) cancelReservation(w http.ResponseWriter, r *http.Request) {
+	if h.reservationService == nil {
+		apierror.Write(w, r, apierror.New(apierror.CodeServiceUnavailable, "database client not ready"))
+		return
+	}
+
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, r, apierror.Internal(errors.New("user ID not found in context")))
+		return
+	}
+
+	reservationID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		apierror.Write(w, r, apierror.New(apierror.CodeValidation, "'id' must be a reservation id"))
+		return
+	}
+
+	if err := h.reservationService.Cancel(r.Context(), reservationID, userID); err != nil {
+		if errors.Is(err, domainbattlestage.ErrReservationNotFound) {
+			apierror.Write(w, r, apierror.New(apierror.CodeReservationNotFound, "reservation not found"))
+			return
+		}
+		apierror.Write(w, r, apierror.Wrap(err, apierror.CodeUpstream, "failed to cancel reservation"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func toReservationResponse(reservation domainbattlestage.Reservation) reservationResponse {
+	return reservationResponse{
+		ID:       reservation.ID.String(),
+		StageID:  reservation.StageID,
+		UserID:   reservation.UserID.String(),
+		StartsAt: reservation.StartsAt,
+		EndsAt:   reservation.EndsAt,
+	}
+}
+
+func toStageAvailabilityResponse(availability domainbattlestage.Availability) *stageAvailabilityResponse {
+	response := &stageAvailabilityResponse{
+		Available:      availability.Available,
+		ActiveSessions: make([]string, 0, len(availability.Occupants)),
+		Reservations:   make([]reservationResponse, 0, len(availability.Reservations)),
+		NextFreeSlot: timeSlotResponse{
+			StartsAt: availability.NextFreeSlot.StartsAt,
+			EndsAt:   availability.NextFreeSlot.EndsAt,
+		},
+	}
+
+	for _, occupant := range availability.Occupants {
+		response.ActiveSessions = append(response.ActiveSessions, occupant.SessionID)
+	}
+	for _, reservation := range availability.Reservations {
+		// 他のユーザーの予約者 ID は公開しない
+		item := toReservationResponse(reservation)
+		item.UserID = ""
+		response.Reservations = append(response.Reservations, item)
+	}
+
+	return response
+}
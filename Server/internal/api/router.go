@@ -2,166 +2,380 @@ package api
 
 import (
 	"context"
-	"database/sql"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
+	"server/internal/apierror"
 	appbattlestage "server/internal/application/battlestage"
+	appmagic "server/internal/application/magic"
 	"server/internal/auth"
 	"server/internal/config"
+	"server/internal/cors"
 	domainbattlestage "server/internal/domain/battlestage"
+	"server/internal/domain/entities"
+	domainmagic "server/internal/domain/magic"
+	"server/internal/game/battle"
 	"server/internal/game/hpmp"
+	"server/internal/health"
+	"server/internal/idempotency"
+	"server/internal/infrastructure/database"
+	"server/internal/infrastructure/memory"
 	"server/internal/infrastructure/repository"
-	"server/internal/supabase"
-	"server/internal/data"
+	"server/internal/logging"
+	"server/internal/metrics"
+	"server/internal/openapi"
+	"server/internal/ratelimit"
+	"server/internal/requestid"
+	"server/internal/tracing"
+	"server/migrations"
 )
 
 // BattleStageFinder はステージ検索ユースケースのインターフェースです。
 type BattleStageFinder interface {
-	Execute(ctx context.Context, location domainbattlestage.Location) ([]domainbattlestage.StageWithDistance, error)
-	SearchRadius() float64
+	Execute(ctx context.Context, req appbattlestage.SearchRequest) (*appbattlestage.SearchResult, error)
+}
+
+// DatabaseHealth はデータベースの疎通確認のインターフェースです。
+type DatabaseHealth interface {
+	Ready() bool
+	Health(ctx context.Context) error
 }
 
 // NewRouter はアプリケーションの HTTP ルーティングを初期化します。
-func NewRouter(supabaseClient supabase.Client, db *sql.DB, cfg *config.Config) http.Handler {
+// db が nil または未接続の場合、データベースを使うエンドポイントは無効になります。
+// 対戦セッションの定期処理などのバックグラウンド処理は ctx が終了すると停止します。
+// 初期データの投入に失敗した場合はエラーを返します。
+func NewRouter(ctx context.Context, db *database.DB, cfg *config.Config) (http.Handler, error) {
+	router, _, err := newRouter(ctx, db, cfg)
+	return router, err
+}
+
+// newRouter はルーターと登録したルートのパターンを返します
+func newRouter(ctx context.Context, db *database.DB, cfg *config.Config) (http.Handler, []string, error) {
 
 	// リポジトリを初期化
 	var userRepo auth.UserRepository
 	var sessionRepo auth.SessionRepository
 	var playerRepo hpmp.PlayerRepository
-	if db != nil {
+	var stageRepo domainbattlestage.Repository
+	var reservationRepo domainbattlestage.ReservationRepository
+	var unitOfWork auth.UnitOfWork
+	var idempotencyRepo idempotency.Repository
+	var magicTypeRepo domainmagic.Repository
+	var battleRecorder battle.EventRecorder
+	var battleResults battle.ResultSaver
+	if cfg.UsesMemoryStorage() {
+		store, err := memory.NewSeededStore(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to seed in-memory store: %w", err)
+		}
+		userRepo = store.Users()
+		sessionRepo = store.Sessions()
+		playerRepo = store.Players()
+		battleResults = store.Players()
+		stageRepo = store.BattleStages()
+		reservationRepo = store.StageReservations()
+		idempotencyRepo = store.IdempotencyKeys()
+		magicTypeRepo = store.MagicTypes()
+		unitOfWork = store
+		slog.Info("using in-memory storage backend")
+	} else if db.Ready() {
 		userRepo = repository.NewUserRepository(db)
 		sessionRepo = repository.NewSessionRepository(db)
-		playerRepo = repository.NewPlayerRepository(db)
+		players := repository.NewPlayerRepository(db)
+		playerRepo = players
+		battleResults = players
+		stageRepo = repository.NewBattleStageSupabaseRepository(db)
+		reservationRepo = repository.NewStageReservationSupabaseRepository(db)
+		idempotencyRepo = repository.NewIdempotencyRepository(db)
+		magicTypeRepo = repository.NewMagicTypeRepository(db)
+		battleRecorder = repository.NewGameEventRepository(db)
+		unitOfWork = db
+		metrics.RegisterDBPool("primary", db)
 	}
 
 	// 認証ハンドラーを初期化
-	authHandler := auth.NewAuthHandler(userRepo, playerRepo, sessionRepo, cfg.Auth.JWTSecret)
-
-	// HP/MPハンドラーを初期化
-	hpmpHandler := hpmp.NewHPMPHandler(playerRepo)
+	authHandler := auth.NewAuthHandler(userRepo, playerRepo, sessionRepo, unitOfWork, cfg.Auth.JWTSecret)
 
 	var authMiddleware *auth.AuthMiddleware
 	if sessionRepo != nil {
 		authMiddleware = auth.NewAuthMiddleware(cfg.Auth.JWTSecret, sessionRepo)
 	}
 
+	// CORS と WebSocket は同じオリジンの設定を使う
+	corsPolicy := cors.New(cors.Options{
+		AllowedOrigins:   cfg.CORS.AllowedOrigins,
+		AllowedMethods:   cfg.CORS.AllowedMethods,
+		AllowedHeaders:   cfg.CORS.AllowedHeaders,
+		ExposedHeaders:   []string{requestid.Header, "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After", idempotency.ReplayedHeader},
+		MaxAge:           cfg.CORS.MaxAge,
+		AllowCredentials: cfg.CORS.AllowCredentials,
+	})
+	wsUpgrader := websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool {
+			// Origin を送らないネイティブアプリは許可し、ブラウザからは許可したオリジンのみ受け付ける
+			origin := r.Header.Get("Origin")
+			return origin == "" || corsPolicy.OriginAllowed(origin)
+		},
+		Error: upgradeError,
+	}
+
 	// 基本ハンドラーを初期化
-	handler := &Handler{supabase: supabaseClient, magicTypesPath: "/home/nonroot/magic_types.json",wsUpgrader: websocket.Upgrader{
-        CheckOrigin: func(*http.Request) bool { return true },},}
-	if cfg != nil {
-		handler.allowedOrigins = cfg.CORS.AllowedOrigins
+	handler := &Handler{database: db, wsUpgrader: wsUpgrader}
+
+	// 魔法の定義（テーブルが空の場合は埋め込みの magic_types.json を投入する）
+	var battleMagicTypes battle.MagicTypeFinder
+	if magicTypeRepo != nil {
+		catalog := appmagic.NewCatalog(magicTypeRepo)
+		seed, err := defaultMagicTypes()
+		if err == nil {
+			err = catalog.Seed(ctx, seed)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to seed magic types: %w", err)
+		}
+		go catalog.Run(ctx, cfg.Magic.RefreshInterval)
+		handler.magicTypes = catalog
+		battleMagicTypes = catalog
+	}
+
+	// 対戦セッション（ジオフェンス判定と防御行動）を初期化。データベースがある場合は対戦の経過を game_events に記録する。
+	// 対戦を終えた参加者の HP / MP はプレイヤーに保存し、自然回復は対戦の終了時刻から数える
+	battleHub := battle.NewHub(battle.GeofenceRules{
+		Tolerance:   cfg.Battle.GeofenceTolerance,
+		GracePeriod: cfg.Battle.GeofenceGracePeriod,
+		PenaltyHP:   cfg.Battle.GeofencePenaltyHP,
+		MaxStrikes:  cfg.Battle.GeofenceMaxStrikes,
+	}, battle.DefenseRules{
+		GuardMPCost:    cfg.Battle.GuardMPCost,
+		GuardDuration:  cfg.Battle.GuardDuration,
+		GuardReduction: cfg.Battle.GuardReduction,
+		ShieldMPCost:   cfg.Battle.ShieldMPCost,
+		ShieldDuration: cfg.Battle.ShieldDuration,
+		ShieldAbsorb:   cfg.Battle.ShieldAbsorb,
+	}, battle.SessionRules{
+		JoinTimeout:       cfg.Battle.JoinTimeout,
+		DisconnectTimeout: cfg.Battle.DisconnectTimeout,
+		IdleTimeout:       cfg.Battle.IdleTimeout,
+		FinishedRetention: cfg.Battle.FinishedRetention,
+	}, battleRecorder, battleResults)
+	go battleHub.Run(ctx, time.Second)
+
+	// HP/MPハンドラーを初期化（対戦中のプレイヤーは自然回復しない）
+	hpRegen := entities.HPRegen{PerMinute: cfg.Player.HPRegenPerMinute, Delay: cfg.Player.HPRegenDelay}
+	hpmpHandler := hpmp.NewHPMPHandler(playerRepo, hpRegen, battleHub)
+
+	// ステージ検索と予約の「対戦中」は、どちらもこのプロセスの Hub のセッションから判定する
+	var battleReservations battle.ReservationChecker
+	if stageRepo != nil {
+		handler.stageFinder = appbattlestage.NewNearbyFinder(stageRepo, battleHub, appbattlestage.SearchOptions{
+			DefaultRadius: cfg.Stage.DefaultSearchRadius,
+			MaxRadius:     cfg.Stage.MaxSearchRadius,
+			DefaultLimit:  cfg.Stage.DefaultPageSize,
+			MaxLimit:      cfg.Stage.MaxPageSize,
+		})
+		reservationService := appbattlestage.NewReservationService(stageRepo, reservationRepo, battleHub, appbattlestage.ReservationOptions{
+			SlotDuration:      cfg.Stage.ReservationSlot,
+			MaxDuration:       cfg.Stage.ReservationMaxDuration,
+			MaxAdvance:        cfg.Stage.ReservationMaxAdvance,
+			OccupancyEstimate: cfg.Stage.OccupancyEstimate,
+		})
+		handler.reservationService = reservationService
+		battleReservations = reservationService
+	}
+
+	var battleStages battle.StageFinder
+	if stageRepo != nil {
+		battleStages = stageRepo
 	}
-	if len(handler.allowedOrigins) == 0 {
-		handler.allowedOrigins = []string{"*"}
+	var battlePlayers battle.PlayerRepository
+	if playerRepo != nil {
+		battlePlayers = playerRepo
 	}
+	battleHandler := battle.NewBattleHandler(battleHub, battleStages, battleReservations, battlePlayers, battleMagicTypes, hpRegen, wsUpgrader, cfg.Battle.DefaultArenaRadius)
 
-	if supabaseClient != nil && supabaseClient.Ready() {
-		repo := repository.NewBattleStageSupabaseRepository(supabaseClient)
-		handler.stageFinder = appbattlestage.NewNearbyFinder(repo, 1000.0)
+	readiness := newReadiness(db, cfg, battleHub)
+
+	mux := newRouteMux()
+
+	// ヘルスチェックなど運用向けのエンドポイント（プローブやスクレイプを妨げないようレート制限しない）
+	mux.HandleFunc(http.MethodGet, "/health", handler.health)
+	mux.Handle(http.MethodGet, "/readyz", health.Handler(readiness))
+	mux.HandleFunc(http.MethodGet, "/supabase/health", handler.supabaseHealth)
+	mux.Handle(http.MethodGet, "/openapi.json", openapi.Handler())
+
+	// メトリクス（METRICS_TOKEN を設定した場合のみメインのポートで公開）
+	if cfg.Metrics.Token != "" {
+		mux.Handle(http.MethodGet, "/metrics", metrics.RequireToken(cfg.Metrics.Token, metrics.Handler()))
 	}
 
-	mux := http.NewServeMux()
+	// レート制限のポリシー（認証が必要なルートではユーザー ID、それ以外は IP アドレスごと）
+	rateLimit := newRateLimit(ctx, db, cfg)
+	authLimit := rateLimit("auth", cfg.RateLimit.Auth)
+	writeLimit := rateLimit("write", cfg.RateLimit.Write)
+	defaultLimit := rateLimit("default", cfg.RateLimit.Default)
 
-	// ヘルスチェックエンドポイント
-	mux.HandleFunc("/health", handler.health)
-	mux.HandleFunc("/supabase/health", handler.supabaseHealth)
-	mux.HandleFunc("/ws", handler.websocket)
-	mux.HandleFunc("/game", handler.listBattleStages)
+	public := mux.Group(defaultLimit)
+	public.HandleFunc(http.MethodGet, "/ws", handler.websocket)
+	public.HandleFunc(http.MethodGet, "/game", handler.listBattleStages)
+	public.HandleFunc(http.MethodGet, "/api/magic-types", handler.listMagicTypes)
 
 	// 認証エンドポイント
-	mux.HandleFunc("/auth/signup", authHandler.HandleSignUp)
-	mux.HandleFunc("/auth/signin", authHandler.HandleSignIn)
-	mux.HandleFunc("/auth/refresh", authHandler.HandleRefresh)
+	signIn := mux.Group(authLimit)
+	signIn.HandleFunc(http.MethodPost, "/auth/signup", authHandler.HandleSignUp)
+	signIn.HandleFunc(http.MethodPost, "/auth/signin", authHandler.HandleSignIn)
+	signIn.HandleFunc(http.MethodPost, "/auth/refresh", authHandler.HandleRefresh)
+
+	// 認証必須のエンドポイント（ストレージが未設定の場合は 503）
+	requireAuth := unavailableMiddleware
 	if authMiddleware != nil {
-		mux.Handle("/auth/logout", authMiddleware.RequireAuth(http.HandlerFunc(authHandler.HandleLogout)))
-		mux.Handle("/protected", authMiddleware.RequireAuth(http.HandlerFunc(handler.protected)))
-	} else {
-		mux.HandleFunc("/auth/logout", authHandler.HandleLogout)
-		mux.HandleFunc("/protected", methodNotAllowedHandler)
+		requireAuth = authMiddleware.RequireAuth
+	}
+	authed := mux.Group(requireAuth, defaultLimit)
+	authed.HandleFunc(http.MethodPost, "/auth/logout", authHandler.HandleLogout)
+	authed.HandleFunc(http.MethodGet, "/protected", handler.protected)
+	authed.HandleFunc(http.MethodGet, "/api/hp", hpmpHandler.HandleGetHP)
+	authed.HandleFunc(http.MethodGet, "/api/mp", hpmpHandler.HandleGetMP)
+	authed.HandleFunc(http.MethodGet, "/ws/battle", battleHandler.HandleWebSocket)
+
+	// 書き込みを伴うエンドポイント（クライアントの不具合による連打からデータベースを守るため、より厳しく制限する）。
+	// POST / PUT は Idempotency-Key による再送に最初のレスポンスを返す
+	writeMiddleware := []func(http.Handler) http.Handler{requireAuth, writeLimit}
+	if idempotencyRepo != nil {
+		idempotent := idempotency.NewMiddleware(idempotencyRepo, cfg.Idempotency.TTL, cfg.Idempotency.LockTimeout)
+		go idempotent.Run(ctx, 10*time.Minute)
+		writeMiddleware = append(writeMiddleware, idempotent.Handler)
+	}
+	writes := mux.Group(writeMiddleware...)
+	writes.HandleFunc(http.MethodPut, "/api/hp/update", hpmpHandler.HandleUpdateHP)
+	writes.HandleFunc(http.MethodPut, "/api/mp/update", hpmpHandler.HandleUpdateMP)
+	writes.HandleFunc(http.MethodPost, "/api/battles", battleHandler.HandleCreate)
+	writes.HandleFunc(http.MethodPost, "/api/reservations", handler.createReservation)
+	writes.HandleFunc(http.MethodDelete, "/api/reservations/{id}", handler.cancelReservation)
+
+	// 管理 API（ADMIN_TOKEN を設定した場合のみ公開）
+	if cfg.Admin.Token != "" {
+		admin := mux.Group(requireAdminToken(cfg.Admin.Token), writeLimit)
+		admin.HandleFunc(http.MethodPost, "/admin/magic-types", handler.createMagicType)
+		admin.HandleFunc(http.MethodPut, "/admin/magic-types/{id}", handler.updateMagicType)
+		admin.HandleFunc(http.MethodDelete, "/admin/magic-types/{id}", handler.deleteMagicType)
 	}
 
-	if authMiddleware != nil {
-		// HP/MP関連のエンドポイント（認証必須）
-		mux.Handle("/api/hp", authMiddleware.RequireAuth(http.HandlerFunc(hpmpHandler.HandleGetHP)))
-		mux.Handle("/api/hp/update", authMiddleware.RequireAuth(http.HandlerFunc(hpmpHandler.HandleUpdateHP)))
-		mux.Handle("/api/mp", authMiddleware.RequireAuth(http.HandlerFunc(hpmpHandler.HandleGetMP)))
-		mux.Handle("/api/mp/update", authMiddleware.RequireAuth(http.HandlerFunc(hpmpHandler.HandleUpdateMP)))
-	} else {
-		mux.HandleFunc("/api/hp", methodNotAllowedHandler)
-		mux.HandleFunc("/api/hp/update", methodNotAllowedHandler)
-		mux.HandleFunc("/api/mp", methodNotAllowedHandler)
-		mux.HandleFunc("/api/mp/update", methodNotAllowedHandler)
-	}
-
-	return corsMiddleware(cfg.CORS.AllowedOrigins, loggingMiddleware(mux))
+	// 外側から: リクエスト ID → トレース → アクセスログ → CORS → メトリクス → ルーティング
+	var chain http.Handler = metrics.Middleware(mux)
+	chain = corsPolicy.Middleware(chain)
+	chain = logging.Middleware(slog.Default(), cfg.Logging.ProjectID, chain)
+	chain = tracing.Middleware(mux.ServeMux, chain)
+	return requestid.Middleware(chain), mux.patterns, nil
+}
+
+// newRateLimit はレート制限のバックエンドを初期化し、ポリシーごとのミドルウェアを作る関数を返します。
+// RATE_LIMIT_BACKEND=off の場合は何もしないミドルウェアを返します。
+func newRateLimit(ctx context.Context, db *database.DB, cfg *config.Config) func(name string, rule config.RateLimitRule) func(http.Handler) http.Handler {
+	var store ratelimit.Store
+	switch {
+	case cfg.RateLimit.Backend == config.RateLimitBackendOff:
+		return func(string, config.RateLimitRule) func(http.Handler) http.Handler {
+			return func(next http.Handler) http.Handler { return next }
+		}
+	case cfg.RateLimit.Backend == config.RateLimitBackendPostgres && db.Ready():
+		store = repository.NewRateLimitRepository(db)
+	default:
+		if cfg.RateLimit.Backend == config.RateLimitBackendPostgres {
+			slog.Warn("database is not ready; falling back to in-memory rate limiting")
+		}
+		store = ratelimit.NewMemoryStore()
+	}
+
+	// 満杯に戻ったバケットは削除してよいため、最も長い期間より長く使われていないものを定期的に削除する
+	idle := max(cfg.RateLimit.Auth.Period, cfg.RateLimit.Write.Period, cfg.RateLimit.Default.Period)
+	limiter := ratelimit.NewLimiter(store, cfg.RateLimit.TrustedProxies)
+	go limiter.Run(ctx, time.Minute, idle)
+
+	return func(name string, rule config.RateLimitRule) func(http.Handler) http.Handler {
+		return limiter.Middleware(ratelimit.Policy{Name: name, Limit: rule.Limit, Period: rule.Period})
+	}
+}
+
+// newReadiness は /readyz で確認する依存先を登録します。
+// データベースとスキーマは必須、対戦セッションの定期処理は任意（停止中も API は使える）とします。
+func newReadiness(db *database.DB, cfg *config.Config, battleHub *battle.Hub) *health.Registry {
+	registry := health.NewRegistry()
+
+	if !cfg.UsesMemoryStorage() {
+		// 未設定の場合も認証などが使えないため、必須の依存先として失敗させる
+		registry.Register(health.Check{
+			Name:     "database",
+			Checker:  health.CheckerFunc(db.Health),
+			Critical: true,
+		})
+	}
+	if db.Ready() {
+		migrator, err := database.NewMigrator(db, migrations.FS)
+		if err != nil {
+			slog.Error("failed to load migrations for readiness check", "error", err)
+		} else {
+			registry.Register(health.Check{
+				Name:     "schema",
+				Checker:  health.CheckerFunc(migrator.CheckCurrent),
+				Critical: true,
+			})
+		}
+	}
+	registry.Register(health.Check{
+		Name:    "battle_hub",
+		Checker: battleHub,
+		Timeout: time.Second,
+	})
+
+	return registry
 }
 
 // Handler は HTTP ハンドラ群をまとめます。
 type Handler struct {
-	supabase       supabase.Client
-	stageFinder    BattleStageFinder
-	allowedOrigins []string
-	magicTypesPath string
-	wsUpgrader     websocket.Upgrader
+	database           DatabaseHealth
+	stageFinder        BattleStageFinder
+	reservationService StageReservationService
+	magicTypes         MagicTypeCatalog
+	wsUpgrader         websocket.Upgrader
 }
 
 func (h *Handler) health(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		methodNotAllowed(w)
-		return
-	}
-
 	respondJSON(w, http.StatusOK, map[string]string{"status": "ok"})
 }
 
 func (h *Handler) supabaseHealth(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		methodNotAllowed(w)
-		return
-	}
-
-	if h.supabase == nil || !h.supabase.Ready() {
-		respondJSON(w, http.StatusServiceUnavailable, map[string]string{
-			"status":  "supabase_unconfigured",
-			"message": "Set SUPABASE_DB_URL to enable this check.",
-		})
+	if h.database == nil || !h.database.Ready() {
+		apierror.Write(w, r, apierror.New(apierror.CodeServiceUnavailable, "Set DATABASE_URL or SUPABASE_DB_URL to enable this check."))
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(r.Context(), 4*time.Second)
 	defer cancel()
 
-	payload, err := h.supabase.Health(ctx)
-	if err != nil {
-		respondJSON(w, http.StatusBadGateway, map[string]string{
-			"status":  "error",
-			"message": err.Error(),
-		})
+	if err := h.database.Health(ctx); err != nil {
+		apierror.Write(w, r, apierror.Wrap(err, apierror.CodeUpstream, "database health check failed"))
 		return
 	}
 
 	respondJSON(w, http.StatusOK, map[string]string{
-		"status": payload.Status,
+		"status": "ok",
 	})
 }
 
 func (h *Handler) listBattleStages(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		methodNotAllowed(w)
-		return
-	}
-
 	if h.stageFinder == nil {
-		respondJSON(w, http.StatusServiceUnavailable, map[string]string{
-			"status":  "supabase_unconfigured",
-			"message": "database client not ready",
-		})
+		apierror.Write(w, r, apierror.New(apierror.CodeServiceUnavailable, "database client not ready"))
 		return
 	}
 
@@ -170,148 +384,164 @@ func (h *Handler) listBattleStages(w http.ResponseWriter, r *http.Request) {
 	lngParam := query.Get("lng")
 
 	if latParam == "" || lngParam == "" {
-		respondJSON(w, http.StatusBadRequest, map[string]string{
-			"status":  "invalid_request",
-			"message": "query parameters 'lat' and 'lng' are required",
-		})
+		apierror.Write(w, r, apierror.New(apierror.CodeValidation, "query parameters 'lat' and 'lng' are required"))
 		return
 	}
 
 	latitude, err := strconv.ParseFloat(latParam, 64)
 	if err != nil {
-		respondJSON(w, http.StatusBadRequest, map[string]string{
-			"status":  "invalid_latitude",
-			"message": "unable to parse 'lat' as float",
-		})
+		apierror.Write(w, r, apierror.New(apierror.CodeInvalidLatitude, "unable to parse 'lat' as float"))
 		return
 	}
 
 	longitude, err := strconv.ParseFloat(lngParam, 64)
 	if err != nil {
-		respondJSON(w, http.StatusBadRequest, map[string]string{
-			"status":  "invalid_longitude",
-			"message": "unable to parse 'lng' as float",
-		})
+		apierror.Write(w, r, apierror.New(apierror.CodeInvalidLongitude, "unable to parse 'lng' as float"))
 		return
 	}
 
 	if latitude < -90 || latitude > 90 || longitude < -180 || longitude > 180 {
-		respondJSON(w, http.StatusBadRequest, map[string]string{
-			"status":  "invalid_coordinates",
-			"message": "latitude must be between -90 and 90, longitude between -180 and 180",
-		})
+		apierror.Write(w, r, apierror.New(apierror.CodeInvalidCoordinates, "latitude must be between -90 and 90, longitude between -180 and 180"))
 		return
 	}
 
+	searchReq := appbattlestage.SearchRequest{
+		Origin: domainbattlestage.Location{
+			Latitude:  latitude,
+			Longitude: longitude,
+		},
+		Cursor: query.Get("cursor"),
+	}
+
+	if radiusParam := query.Get("radius"); radiusParam != "" {
+		radius, err := strconv.ParseFloat(radiusParam, 64)
+		if err != nil || radius <= 0 || math.IsNaN(radius) || math.IsInf(radius, 0) {
+			apierror.Write(w, r, apierror.New(apierror.CodeInvalidRadius, "'radius' must be a positive number of meters"))
+			return
+		}
+		searchReq.RadiusMeters = radius
+	}
+
+	if limitParam := query.Get("limit"); limitParam != "" {
+		limit, err := strconv.Atoi(limitParam)
+		if err != nil || limit <= 0 {
+			apierror.Write(w, r, apierror.New(apierror.CodeInvalidLimit, "'limit' must be a positive integer"))
+			return
+		}
+		searchReq.Limit = limit
+	}
+
+	if inBattleParam := query.Get("inBattle"); inBattleParam != "" {
+		inBattle, err := strconv.ParseBool(inBattleParam)
+		if err != nil {
+			apierror.Write(w, r, apierror.New(apierror.CodeInvalidInBattle, "'inBattle' must be true or false"))
+			return
+		}
+		searchReq.InBattle = &inBattle
+	}
+
 	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
 	defer cancel()
 
-	results, err := h.stageFinder.Execute(ctx, domainbattlestage.Location{
-		Latitude:  latitude,
-		Longitude: longitude,
-	})
+	result, err := h.stageFinder.Execute(ctx, searchReq)
 	if err != nil {
-		respondJSON(w, http.StatusBadGateway, map[string]string{
-			"status":  "supabase_query_failed",
-			"message": err.Error(),
-		})
+		if errors.Is(err, appbattlestage.ErrInvalidCursor) {
+			apierror.Write(w, r, apierror.New(apierror.CodeInvalidCursor, "'cursor' is malformed"))
+			return
+		}
+		apierror.Write(w, r, apierror.Wrap(err, apierror.CodeUpstream, "failed to search battle stages"))
 		return
 	}
 
-	payload := make([]battleStageResponse, 0, len(results))
-	for _, result := range results {
-		payload = append(payload, toBattleStageResponse(result))
+	var availability map[string]domainbattlestage.Availability
+	if h.reservationService != nil && len(result.Stages) > 0 {
+		stageIDs := make([]string, 0, len(result.Stages))
+		for _, stage := range result.Stages {
+			stageIDs = append(stageIDs, stage.Stage.ID)
+		}
+		availability, err = h.reservationService.Availability(ctx, stageIDs)
+		if err != nil {
+			// 空き状況は付加情報のため、取得に失敗してもステージ一覧は返す
+			logging.FromContext(ctx).Warn("failed to load stage availability", "error", err)
+		}
+	}
+
+	payload := make([]battleStageResponse, 0, len(result.Stages))
+	for _, stage := range result.Stages {
+		item := toBattleStageResponse(stage)
+		if stageAvailability, ok := availability[stage.Stage.ID]; ok {
+			item.Availability = toStageAvailabilityResponse(stageAvailability)
+		}
+		payload = append(payload, item)
 	}
 
-	respondJSON(w, http.StatusOK, map[string]any{
+	response := map[string]any{
 		"battleStages": payload,
-		"radiusMeters": h.stageFinder.SearchRadius(),
-	})
-}
-
-func (h *Handler) websocket(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		methodNotAllowed(w)
-		return
+		"radiusMeters": result.RadiusMeters,
+		"limit":        result.Limit,
 	}
-
-	upgrader := websocket.Upgrader{
-		CheckOrigin: func(r *http.Request) bool {
-			origin := r.Header.Get("Origin")
-			return originAllowed(origin, h.allowedOrigins)
-		},
+	if result.NextCursor != "" {
+		response["nextCursor"] = result.NextCursor
 	}
 
-	conn, err := upgrader.Upgrade(w, r, nil)
+	respondJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) websocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.wsUpgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.Printf("websocket upgrade failed: %v", err)
-		http.Error(w, "failed to upgrade connection", http.StatusBadRequest)
+		// エラーレスポンスは upgradeError が書き込み済み
 		return
 	}
 	defer conn.Close()
+	defer metrics.WebSocketConnected("/ws")()
 
 	for {
 		msgType, payload, err := conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Printf("websocket read error: %v", err)
+				logging.FromContext(r.Context()).Warn("websocket read error", "error", err)
 			}
 			return
 		}
 
-		if err := conn.WriteMessage(msgType, payload); err != nil {
-			log.Printf("websocket write error: %v", err)
+		_, span := tracing.Start(r.Context(), "ws.echo",
+			trace.WithNewRoot(),
+			trace.WithLinks(trace.LinkFromContext(r.Context())),
+			trace.WithAttributes(attribute.Int("ws.message_bytes", len(payload))),
+		)
+		err = conn.WriteMessage(msgType, payload)
+		tracing.RecordError(span, err)
+		span.End()
+		if err != nil {
+			logging.FromContext(r.Context()).Warn("websocket write error", "error", err)
 			return
 		}
 	}
 }
 
-func originAllowed(origin string, allowedOrigins []string) bool {
-	if len(allowedOrigins) == 0 {
-		return false
-	}
-
-	for _, allowed := range allowedOrigins {
-		if allowed == "*" {
-			return true
-		}
-
-		if origin != "" && allowed == origin {
-			return true
-		}
-	}
-
-	return false
+// upgradeError は WebSocket のアップグレード失敗を共通のエラー形式で返します
+func upgradeError(w http.ResponseWriter, r *http.Request, status int, reason error) {
+	apierror.Write(w, r, &apierror.Error{
+		Status:  status,
+		Code:    apierror.CodeBadRequest,
+		Message: "failed to upgrade connection",
+		Err:     reason,
+	})
 }
 
-func loggingMiddleware(next http.Handler) http.Handler {
+// unavailableMiddleware はストレージが未設定で認証できない場合にリクエストを 503 で拒否します
+func unavailableMiddleware(http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-		next.ServeHTTP(w, r)
-		duration := time.Since(start)
-		log.Printf("%s %s (%s)", r.Method, r.URL.Path, duration.String())
+		apierror.Write(w, r, apierror.New(apierror.CodeServiceUnavailable, "database client not ready"))
 	})
 }
 
-func methodNotAllowed(w http.ResponseWriter) {
-	w.Header().Set("Allow", http.MethodGet)
-	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
-}
-
-func methodNotAllowedHandler(w http.ResponseWriter, r *http.Request) {
-	methodNotAllowed(w)
-}
-
 func (h *Handler) protected(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		methodNotAllowed(w)
-		return
-	}
-
 	// 認証ミドルウェアからユーザーIDを取得
 	userID, ok := auth.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusInternalServerError)
+		apierror.Write(w, r, apierror.Internal(errors.New("user ID not found in context")))
 		return
 	}
 
@@ -321,51 +551,11 @@ func (h *Handler) protected(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func (h *Handler) listMagicTypes(w http.ResponseWriter, r *http.Request) {
-    if r.Method != http.MethodGet {
-        methodNotAllowed(w)
-        return
-    }
-
-    list, err := data.LoadMagicTypes(h.magicTypesPath)
-    if err != nil {
-        log.Printf("failed to load magic types: %v", err)
-        http.Error(w, "failed to load magic types", http.StatusInternalServerError)
-        return
-    }
-
-    respondJSON(w, http.StatusOK, list)
-}
-
-
-func corsMiddleware(allowedOrigins []string, next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		origin := r.Header.Get("Origin")
-
-		// 許可されたオリジンをチェック
-		if originAllowed(origin, allowedOrigins) {
-			w.Header().Set("Access-Control-Allow-Origin", origin)
-		}
-
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		w.Header().Set("Access-Control-Allow-Credentials", "true")
-
-		// プリフライトリクエストの処理
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
-		next.ServeHTTP(w, r)
-	})
-}
-
 func respondJSON(w http.ResponseWriter, status int, payload any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(payload); err != nil {
-		log.Printf("failed to encode json response: %v", err)
+		slog.Error("failed to encode json response", "error", err)
 	}
 }
 
@@ -377,6 +567,9 @@ type battleStageResponse struct {
 	RadiusMeters   *float64 `json:"radiusMeters,omitempty"`
 	Description    *string  `json:"description,omitempty"`
 	DistanceMeters float64  `json:"distanceMeters"`
+	InBattle       bool     `json:"inBattle"`
+
+	Availability *stageAvailabilityResponse `json:"availability,omitempty"`
 }
 
 func toBattleStageResponse(stage domainbattlestage.StageWithDistance) battleStageResponse {
@@ -388,5 +581,6 @@ func toBattleStageResponse(stage domainbattlestage.StageWithDistance) battleStag
 		RadiusMeters:   stage.Stage.RadiusMeters,
 		Description:    stage.Stage.Description,
 		DistanceMeters: stage.DistanceMeters,
+		InBattle:       stage.Stage.InBattle,
 	}
 }
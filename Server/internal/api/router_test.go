@@ -0,0 +1,51 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	appbattlestage "server/internal/application/battlestage"
+)
+
+// stubStageFinder は呼び出されたかを記録するテスト用の BattleStageFinder です
+type stubStageFinder struct {
+	called bool
+}
+
+func (f *stubStageFinder) Execute(ctx context.Context, req appbattlestage.SearchRequest) (*appbattlestage.SearchResult, error) {
+	f.called = true
+	return &appbattlestage.SearchResult{RadiusMeters: req.RadiusMeters, Limit: req.Limit}, nil
+}
+
+func TestListBattleStages_RejectsInvalidRadius(t *testing.T) {
+	testCases := []struct {
+		name   string
+		radius string
+	}{
+		{"not a number", "abc"},
+		{"zero", "0"},
+		{"negative", "-1"},
+		{"NaN", "NaN"},
+		{"infinity", "Inf"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			finder := &stubStageFinder{}
+			handler := &Handler{stageFinder: finder}
+
+			req := httptest.NewRequest(http.MethodGet, "/game?lat=35.6595&lng=139.7005&radius="+tc.radius, nil)
+			w := httptest.NewRecorder()
+			handler.listBattleStages(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("expected status %d, got %d: %s", http.StatusBadRequest, w.Code, w.Body.String())
+			}
+			if finder.called {
+				t.Fatal("stage finder must not be called with an invalid radius")
+			}
+		})
+	}
+}
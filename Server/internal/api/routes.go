@@ -0,0 +1,72 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+
+	"server/internal/apierror"
+)
+
+// routeMux はメソッドとパスのパターン（Go 1.22 の ServeMux の書式）でルーティングします。
+// パスごとに登録したメソッドを記録し、それ以外のメソッドには正しい Allow ヘッダー付きの 405 を返します。
+type routeMux struct {
+	*http.ServeMux
+	allowed  map[string][]string
+	patterns []string // 登録順のパス（OpenAPI の契約テストで使う）
+}
+
+func newRouteMux() *routeMux {
+	return &routeMux{ServeMux: http.NewServeMux(), allowed: map[string][]string{}}
+}
+
+// Handle は method と path（"/api/reservations/{id}" のようなパスパラメーターを含められる）にハンドラーを登録します
+func (m *routeMux) Handle(method, path string, handler http.Handler) {
+	if _, ok := m.allowed[path]; !ok {
+		m.patterns = append(m.patterns, path)
+		// メソッドなしのパターンはメソッド付きのパターンより優先度が低いため、未登録のメソッドだけがここに来る
+		m.ServeMux.Handle(path, m.methodNotAllowed(path))
+	}
+	m.allowed[path] = append(m.allowed[path], method)
+	if method == http.MethodGet {
+		// GET のパターンは HEAD にも一致する
+		m.allowed[path] = append(m.allowed[path], http.MethodHead)
+	}
+	m.ServeMux.Handle(method+" "+path, handler)
+}
+
+// HandleFunc は関数をハンドラーとして登録します
+func (m *routeMux) HandleFunc(method, path string, handler func(http.ResponseWriter, *http.Request)) {
+	m.Handle(method, path, http.HandlerFunc(handler))
+}
+
+// Group は登録するすべてのハンドラーに middleware を適用するグループを返します（先に指定したものが外側）
+func (m *routeMux) Group(middleware ...func(http.Handler) http.Handler) *routeGroup {
+	return &routeGroup{mux: m, middleware: middleware}
+}
+
+func (m *routeMux) methodNotAllowed(path string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed := slices.Clone(m.allowed[path])
+		slices.Sort(allowed)
+		apierror.WriteMethodNotAllowed(w, r, slices.Compact(allowed)...)
+	})
+}
+
+// routeGroup は認証などのミドルウェアを共有するルートのまとまりです
+type routeGroup struct {
+	mux        *routeMux
+	middleware []func(http.Handler) http.Handler
+}
+
+// Handle はミドルウェアを適用してハンドラーを登録します
+func (g *routeGroup) Handle(method, path string, handler http.Handler) {
+	for i := len(g.middleware) - 1; i >= 0; i-- {
+		handler = g.middleware[i](handler)
+	}
+	g.mux.Handle(method, path, handler)
+}
+
+// HandleFunc は関数をハンドラーとして登録します
+func (g *routeGroup) HandleFunc(method, path string, handler func(http.ResponseWriter, *http.Request)) {
+	g.Handle(method, path, http.HandlerFunc(handler))
+}
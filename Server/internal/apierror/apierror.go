@@ -0,0 +1,234 @@
+// Package apierror は全ハンドラー共通のエラーレスポンスを提供します。
+//
+// レスポンスは次の形式です。message は利用者向けの文言で、内部エラーの詳細は含めません。
+//
+//	{"code": "invalid_radius", "message": "...", "requestId": "..."}
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"server/internal/logging"
+	"server/internal/requestid"
+)
+
+// Code は機械可読なエラーコードです。一度公開したコードは変更しません。
+type Code string
+
+// 汎用のエラーコード
+const (
+	CodeBadRequest         Code = "bad_request"
+	CodeInvalidBody        Code = "invalid_body"
+	CodeValidation         Code = "validation_failed"
+	CodePayloadTooLarge    Code = "payload_too_large"
+	CodeUnsupportedMedia   Code = "unsupported_media_type"
+	CodeUnauthorized       Code = "unauthorized"
+	CodeForbidden          Code = "forbidden"
+	CodeNotFound           Code = "not_found"
+	CodeMethodNotAllowed   Code = "method_not_allowed"
+	CodeConflict           Code = "conflict"
+	CodeRateLimited        Code = "rate_limited"
+	CodeUpstream           Code = "upstream_error"
+	CodeServiceUnavailable Code = "service_unavailable"
+	CodeInternal           Code = "internal_error"
+)
+
+// Idempotency-Key のエラーコード
+const (
+	CodeInvalidIdempotencyKey Code = "invalid_idempotency_key"
+	CodeIdempotencyInProgress Code = "idempotency_key_in_progress"
+	CodeIdempotencyKeyReused  Code = "idempotency_key_reused"
+)
+
+// 認証のエラーコード
+const (
+	CodeInvalidCredentials Code = "invalid_credentials"
+	CodeUserExists         Code = "user_exists"
+	CodeAuthRequired       Code = "auth_required"
+	CodeInvalidToken       Code = "invalid_token"
+	CodeSessionExpired     Code = "session_expired"
+)
+
+// ゲーム・ステージのエラーコード
+const (
+	CodePlayerNotFound        Code = "player_not_found"
+	CodeInvalidHP             Code = "invalid_hp"
+	CodeInvalidMP             Code = "invalid_mp"
+	CodeInvalidLatitude       Code = "invalid_latitude"
+	CodeInvalidLongitude      Code = "invalid_longitude"
+	CodeInvalidCoordinates    Code = "invalid_coordinates"
+	CodeInvalidRadius         Code = "invalid_radius"
+	CodeInvalidLimit          Code = "invalid_limit"
+	CodeInvalidInBattle       Code = "invalid_in_battle"
+	CodeInvalidCursor         Code = "invalid_cursor"
+	CodeStageNotFound         Code = "stage_not_found"
+	CodeInvalidReservation    Code = "invalid_reservation"
+	CodeReservationConflict   Code = "reservation_conflict"
+	CodeReservationNotFound   Code = "reservation_not_found"
+	CodeBattleSessionNotFound Code = "battle_session_not_found"
+	CodeBattleSessionFull     Code = "battle_session_full"
+	CodeBattleSessionFinished Code = "battle_session_finished"
+	CodeStageOccupied         Code = "stage_occupied"
+	CodeStageReserved         Code = "stage_reserved"
+	CodeAlreadyInBattle       Code = "already_in_battle"
+	CodeMagicTypeNotFound     Code = "magic_type_not_found"
+	CodeMagicTypeExists       Code = "magic_type_exists"
+)
+
+// statusByCode はコードごとの既定の HTTP ステータスです
+var statusByCode = map[Code]int{
+	CodeBadRequest:         http.StatusBadRequest,
+	CodeInvalidBody:        http.StatusBadRequest,
+	CodeValidation:         http.StatusBadRequest,
+	CodePayloadTooLarge:    http.StatusRequestEntityTooLarge,
+	CodeUnsupportedMedia:   http.StatusUnsupportedMediaType,
+	CodeUnauthorized:       http.StatusUnauthorized,
+	CodeForbidden:          http.StatusForbidden,
+	CodeNotFound:           http.StatusNotFound,
+	CodeMethodNotAllowed:   http.StatusMethodNotAllowed,
+	CodeConflict:           http.StatusConflict,
+	CodeRateLimited:        http.StatusTooManyRequests,
+	CodeUpstream:           http.StatusBadGateway,
+	CodeServiceUnavailable: http.StatusServiceUnavailable,
+	CodeInternal:           http.StatusInternalServerError,
+
+	CodeInvalidIdempotencyKey: http.StatusBadRequest,
+	CodeIdempotencyInProgress: http.StatusConflict,
+	CodeIdempotencyKeyReused:  http.StatusUnprocessableEntity,
+
+	CodeInvalidCredentials: http.StatusUnauthorized,
+	CodeUserExists:         http.StatusConflict,
+	CodeAuthRequired:       http.StatusUnauthorized,
+	CodeInvalidToken:       http.StatusUnauthorized,
+	CodeSessionExpired:     http.StatusUnauthorized,
+
+	CodePlayerNotFound:        http.StatusNotFound,
+	CodeInvalidHP:             http.StatusBadRequest,
+	CodeInvalidMP:             http.StatusBadRequest,
+	CodeInvalidLatitude:       http.StatusBadRequest,
+	CodeInvalidLongitude:      http.StatusBadRequest,
+	CodeInvalidCoordinates:    http.StatusBadRequest,
+	CodeInvalidRadius:         http.StatusBadRequest,
+	CodeInvalidLimit:          http.StatusBadRequest,
+	CodeInvalidInBattle:       http.StatusBadRequest,
+	CodeInvalidCursor:         http.StatusBadRequest,
+	CodeStageNotFound:         http.StatusNotFound,
+	CodeInvalidReservation:    http.StatusBadRequest,
+	CodeReservationConflict:   http.StatusConflict,
+	CodeReservationNotFound:   http.StatusNotFound,
+	CodeBattleSessionNotFound: http.StatusNotFound,
+	CodeBattleSessionFull:     http.StatusConflict,
+	CodeBattleSessionFinished: http.StatusGone,
+	CodeStageOccupied:         http.StatusConflict,
+	CodeStageReserved:         http.StatusConflict,
+	CodeAlreadyInBattle:       http.StatusConflict,
+	CodeMagicTypeNotFound:     http.StatusNotFound,
+	CodeMagicTypeExists:       http.StatusConflict,
+}
+
+// Error は HTTP レスポンスに変換できるエラーです。Err はログにのみ出力されます。
+type Error struct {
+	Status  int
+	Code    Code
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+// FieldError はリクエストのフィールドごとの検証エラーです。
+// Field は JSON のパス（例: "hp", "user.email"）、Code は違反したルール（例: "required", "max"）です。
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Response はエラーレスポンスの JSON 形式です
+type Response struct {
+	Code      Code         `json:"code"`
+	Message   string       `json:"message"`
+	Fields    []FieldError `json:"fields,omitempty"`
+	RequestID string       `json:"requestId,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return string(e.Code) + ": " + e.Message + ": " + e.Err.Error()
+	}
+	return string(e.Code) + ": " + e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New はコードの既定ステータスでエラーを作成します
+func New(code Code, message string) *Error {
+	return &Error{Status: StatusFor(code), Code: code, Message: message}
+}
+
+// Wrap は内部エラー err を保持したエラーを作成します
+func Wrap(err error, code Code, message string) *Error {
+	return &Error{Status: StatusFor(code), Code: code, Message: message, Err: err}
+}
+
+// WithStatus はステータスを上書きしたエラーを作成します（汎用でないコード向け）
+func WithStatus(status int, code Code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+// Internal は内部エラーを 500 internal_error として包みます
+func Internal(err error) *Error {
+	return Wrap(err, CodeInternal, "Internal server error")
+}
+
+// MethodNotAllowed は Allow ヘッダーとともに返す 405 エラーです
+func MethodNotAllowed() *Error {
+	return New(CodeMethodNotAllowed, http.StatusText(http.StatusMethodNotAllowed))
+}
+
+// StatusFor はコードに対応する HTTP ステータスを返します。未登録のコードは 400 です。
+func StatusFor(code Code) int {
+	if status, ok := statusByCode[code]; ok {
+		return status
+	}
+	return http.StatusBadRequest
+}
+
+// Write は err をエラーレスポンスとして書き込みます。
+// *Error 以外のエラーは 500 internal_error として扱い、内容はログにのみ出力します。
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		apiErr = Internal(err)
+	}
+
+	id := requestid.FromContext(r.Context())
+	if apiErr.Status >= http.StatusInternalServerError || apiErr.Err != nil {
+		level := slog.LevelWarn
+		if apiErr.Status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		logging.FromContext(r.Context()).Log(r.Context(), level, "request failed",
+			"status", apiErr.Status, "code", apiErr.Code, "error", apiErr.Err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(apiErr.Status)
+	if encodeErr := json.NewEncoder(w).Encode(Response{Code: apiErr.Code, Message: apiErr.Message, Fields: apiErr.Fields, RequestID: id}); encodeErr != nil {
+		logging.FromContext(r.Context()).Error("failed to encode error response", "error", encodeErr)
+	}
+}
+
+// WriteMethodNotAllowed は Allow ヘッダーを設定して 405 を書き込みます
+func WriteMethodNotAllowed(w http.ResponseWriter, r *http.Request, allowed ...string) {
+	if len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+	}
+	Write(w, r, MethodNotAllowed())
+}
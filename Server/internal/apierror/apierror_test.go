@@ -0,0 +1,68 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"server/internal/requestid"
+)
+
+func TestWrite_HidesInternalErrors(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/game", nil)
+	req = req.WithContext(requestid.WithID(req.Context(), "req-1"))
+	rec := httptest.NewRecorder()
+
+	Write(rec, req, errors.New("pq: password authentication failed"))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "password") {
+		t.Fatalf("internal error leaked: %s", rec.Body.String())
+	}
+
+	var body Response
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body.Code != CodeInternal || body.RequestID != "req-1" {
+		t.Fatalf("unexpected body: %+v", body)
+	}
+}
+
+func TestWrite_UsesCodeStatus(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/reservations", nil)
+	rec := httptest.NewRecorder()
+
+	Write(rec, req, New(CodeReservationConflict, "already booked"))
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want 409", rec.Code)
+	}
+
+	var body Response
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body.Code != CodeReservationConflict || body.Message != "already booked" {
+		t.Fatalf("unexpected body: %+v", body)
+	}
+}
+
+func TestWriteMethodNotAllowed_SetsAllow(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPatch, "/api/reservations", nil)
+	rec := httptest.NewRecorder()
+
+	WriteMethodNotAllowed(rec, req, http.MethodPost, http.MethodDelete)
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("status = %d, want 405", rec.Code)
+	}
+	if got := rec.Header().Get("Allow"); got != "POST, DELETE" {
+		t.Fatalf("Allow = %q", got)
+	}
+}
@@ -2,27 +2,184 @@ package battlestage
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 
 	domain "server/internal/domain/battlestage"
 )
 
+// ErrInvalidCursor はページングカーソルが解釈できない場合のエラーです。
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// SearchOptions は検索半径と件数の既定値・上限です。
+type SearchOptions struct {
+	DefaultRadius float64
+	MaxRadius     float64
+	DefaultLimit  int
+	MaxLimit      int
+}
+
+// SearchRequest はユースケースへの入力です。
+// RadiusMeters と Limit は 0 の場合に既定値を使用し、上限を超える場合は上限に丸めます。
+type SearchRequest struct {
+	Origin       domain.Location
+	RadiusMeters float64
+	Limit        int
+	Cursor       string
+	InBattle     *bool
+}
+
+// SearchResult は 1 ページ分の検索結果です。
+type SearchResult struct {
+	Stages       []domain.StageWithDistance
+	RadiusMeters float64
+	Limit        int
+	NextCursor   string // 続きがない場合は空文字
+}
+
 // NearbyFinder は指定地点周辺のステージ取得ユースケースを表現します。
 type NearbyFinder struct {
-	repo         domain.Repository
-	searchRadius float64
+	repo      domain.Repository
+	occupancy OccupancyProvider
+	options   SearchOptions
 }
 
 // NewNearbyFinder はユースケースを生成します。
-func NewNearbyFinder(repo domain.Repository, searchRadius float64) *NearbyFinder {
-	return &NearbyFinder{repo: repo, searchRadius: searchRadius}
+// 対戦中かどうか（Stage.InBattle と SearchRequest.InBattle の絞り込み）は occupancy の終了していないセッションで判定し、nil の場合は常に対戦中ではないものとします。
+func NewNearbyFinder(repo domain.Repository, occupancy OccupancyProvider, options SearchOptions) *NearbyFinder {
+	if options.MaxRadius < options.DefaultRadius {
+		options.MaxRadius = options.DefaultRadius
+	}
+	if options.DefaultLimit <= 0 {
+		options.DefaultLimit = 1
+	}
+	if options.MaxLimit < options.DefaultLimit {
+		options.MaxLimit = options.DefaultLimit
+	}
+	return &NearbyFinder{repo: repo, occupancy: occupancy, options: options}
+}
+
+// Execute は指定地点から検索半径以内のステージを距離順に 1 ページ分取得します。
+// InBattle で絞り込む場合は、1 ページ分がそろうか候補がなくなるまでリポジトリから続きを取得します。
+func (f *NearbyFinder) Execute(ctx context.Context, req SearchRequest) (*SearchResult, error) {
+	after, err := DecodeCursor(req.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	radius := f.ClampRadius(req.RadiusMeters)
+	limit := f.ClampLimit(req.Limit)
+
+	// 1 件多く取得して次ページの有無を判定する
+	var stages []domain.StageWithDistance
+	for {
+		batch, err := f.repo.FindNearby(ctx, domain.NearbyQuery{
+			Origin:       req.Origin,
+			RadiusMeters: radius,
+			Limit:        limit + 1,
+			After:        after,
+		})
+		if err != nil {
+			return nil, err
+		}
+		f.markInBattle(batch)
+		for _, stage := range batch {
+			if req.InBattle == nil || stage.Stage.InBattle == *req.InBattle {
+				stages = append(stages, stage)
+			}
+		}
+		if len(stages) > limit || len(batch) <= limit {
+			break
+		}
+		last := batch[len(batch)-1]
+		after = &domain.Cursor{DistanceMeters: last.DistanceMeters, StageID: last.Stage.ID}
+	}
+
+	result := &SearchResult{RadiusMeters: radius, Limit: limit}
+	if len(stages) > limit {
+		stages = stages[:limit]
+		last := stages[len(stages)-1]
+		result.NextCursor = EncodeCursor(domain.Cursor{
+			DistanceMeters: last.DistanceMeters,
+			StageID:        last.Stage.ID,
+		})
+	}
+	result.Stages = stages
+
+	return result, nil
 }
 
-// Execute は指定地点から searchRadius 以内のステージ一覧を取得します。
-func (f *NearbyFinder) Execute(ctx context.Context, location domain.Location) ([]domain.StageWithDistance, error) {
-	return f.repo.FindNearby(ctx, location, f.searchRadius)
+// markInBattle は終了していないセッションがあるステージの InBattle を設定します。
+func (f *NearbyFinder) markInBattle(stages []domain.StageWithDistance) {
+	if f.occupancy == nil || len(stages) == 0 {
+		return
+	}
+	stageIDs := make([]string, 0, len(stages))
+	for _, stage := range stages {
+		stageIDs = append(stageIDs, stage.Stage.ID)
+	}
+	occupancy := f.occupancy.StageOccupancy(stageIDs)
+	for i := range stages {
+		stages[i].Stage.InBattle = len(occupancy[stages[i].Stage.ID]) > 0
+	}
 }
 
-// SearchRadius はユースケースが利用する検索半径を返します。
-func (f *NearbyFinder) SearchRadius() float64 {
-	return f.searchRadius
+// DefaultRadius は radius 未指定時の検索半径を返します。
+func (f *NearbyFinder) DefaultRadius() float64 {
+	return f.options.DefaultRadius
+}
+
+// ClampRadius は要求された半径を既定値・上限に合わせて補正します。
+func (f *NearbyFinder) ClampRadius(radius float64) float64 {
+	if radius <= 0 || math.IsNaN(radius) {
+		return f.options.DefaultRadius
+	}
+	return math.Min(radius, f.options.MaxRadius)
+}
+
+// ClampLimit は要求された件数を既定値・上限に合わせて補正します。
+func (f *NearbyFinder) ClampLimit(limit int) int {
+	if limit <= 0 {
+		return f.options.DefaultLimit
+	}
+	if limit > f.options.MaxLimit {
+		return f.options.MaxLimit
+	}
+	return limit
+}
+
+type cursorPayload struct {
+	Distance float64 `json:"d"`
+	ID       string  `json:"id"`
+}
+
+// EncodeCursor はカーソルを URL セーフな不透明文字列に変換します。
+func EncodeCursor(cursor domain.Cursor) string {
+	raw, _ := json.Marshal(cursorPayload{Distance: cursor.DistanceMeters, ID: cursor.StageID})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor は EncodeCursor の逆変換です。空文字の場合は nil を返します。
+func DecodeCursor(value string) (*domain.Cursor, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	var payload cursorPayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	if payload.ID == "" || payload.Distance < 0 || math.IsNaN(payload.Distance) {
+		return nil, ErrInvalidCursor
+	}
+
+	return &domain.Cursor{DistanceMeters: payload.Distance, StageID: payload.ID}, nil
 }
@@ -0,0 +1,184 @@
+package battlestage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	domain "server/internal/domain/battlestage"
+)
+
+// stubRepository は距離順に並んだステージを返すテスト用リポジトリです
+type stubRepository struct {
+	stages    []domain.StageWithDistance
+	lastQuery domain.NearbyQuery
+}
+
+func (s *stubRepository) FindNearby(ctx context.Context, query domain.NearbyQuery) ([]domain.StageWithDistance, error) {
+	s.lastQuery = query
+
+	results := make([]domain.StageWithDistance, 0)
+	for _, stage := range s.stages {
+		if stage.DistanceMeters > query.RadiusMeters {
+			continue
+		}
+		if query.After != nil {
+			if stage.DistanceMeters < query.After.DistanceMeters ||
+				(stage.DistanceMeters == query.After.DistanceMeters && stage.Stage.ID <= query.After.StageID) {
+				continue
+			}
+		}
+		results = append(results, stage)
+		if len(results) == query.Limit {
+			break
+		}
+	}
+	return results, nil
+}
+
+func (s *stubRepository) FindByID(ctx context.Context, id string) (*domain.Stage, error) {
+	for _, stage := range s.stages {
+		if stage.Stage.ID == id {
+			found := stage.Stage
+			return &found, nil
+		}
+	}
+	return nil, domain.ErrStageNotFound
+}
+
+func newTestFinder(repo domain.Repository, occupancy OccupancyProvider) *NearbyFinder {
+	return NewNearbyFinder(repo, occupancy, SearchOptions{
+		DefaultRadius: 1000,
+		MaxRadius:     5000,
+		DefaultLimit:  2,
+		MaxLimit:      3,
+	})
+}
+
+func TestNearbyFinder_ClampsRadiusAndLimit(t *testing.T) {
+	repo := &stubRepository{}
+	finder := newTestFinder(repo, nil)
+
+	testCases := []struct {
+		name           string
+		radius         float64
+		limit          int
+		expectedRadius float64
+		expectedLimit  int
+	}{
+		{"defaults", 0, 0, 1000, 2},
+		{"within bounds", 2500, 3, 2500, 3},
+		{"above max", 20000, 50, 5000, 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := finder.Execute(context.Background(), SearchRequest{RadiusMeters: tc.radius, Limit: tc.limit})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.RadiusMeters != tc.expectedRadius {
+				t.Errorf("expected radius %.0f, got %.0f", tc.expectedRadius, result.RadiusMeters)
+			}
+			if result.Limit != tc.expectedLimit {
+				t.Errorf("expected limit %d, got %d", tc.expectedLimit, result.Limit)
+			}
+			if repo.lastQuery.Limit != tc.expectedLimit+1 {
+				t.Errorf("expected repository limit %d, got %d", tc.expectedLimit+1, repo.lastQuery.Limit)
+			}
+		})
+	}
+}
+
+func TestNearbyFinder_Pagination(t *testing.T) {
+	repo := &stubRepository{}
+	for i := 0; i < 5; i++ {
+		repo.stages = append(repo.stages, domain.StageWithDistance{
+			Stage:          domain.Stage{ID: fmt.Sprintf("stage-%d", i)},
+			DistanceMeters: float64(100 * (i / 2)),
+		})
+	}
+	finder := newTestFinder(repo, nil)
+
+	var (
+		seen   []string
+		cursor string
+	)
+	for page := 0; page < 5; page++ {
+		result, err := finder.Execute(context.Background(), SearchRequest{Cursor: cursor})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, stage := range result.Stages {
+			seen = append(seen, stage.Stage.ID)
+		}
+		if result.NextCursor == "" {
+			break
+		}
+		cursor = result.NextCursor
+	}
+
+	if len(seen) != 5 {
+		t.Fatalf("expected 5 stages across pages, got %v", seen)
+	}
+	for i, id := range seen {
+		if id != fmt.Sprintf("stage-%d", i) {
+			t.Errorf("expected stage-%d at position %d, got %s", i, i, id)
+		}
+	}
+}
+
+func TestNearbyFinder_InBattleFromOccupancy(t *testing.T) {
+	repo := &stubRepository{}
+	occupancy := stubOccupancy{}
+	for i := 0; i < 5; i++ {
+		id := fmt.Sprintf("stage-%d", i)
+		repo.stages = append(repo.stages, domain.StageWithDistance{Stage: domain.Stage{ID: id}, DistanceMeters: float64(100 * i)})
+		if i%2 == 0 {
+			occupancy[id] = []domain.Occupancy{{SessionID: "session-" + id, Status: "active"}}
+		}
+	}
+	finder := newTestFinder(repo, occupancy)
+
+	inBattle := false
+	result, err := finder.Execute(context.Background(), SearchRequest{Limit: 3, InBattle: &inBattle})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Stages) != 2 || result.NextCursor != "" || result.Stages[0].Stage.InBattle {
+		t.Errorf("expected 2 idle stages without next cursor, got %+v (cursor=%q)", result.Stages, result.NextCursor)
+	}
+
+	// 絞り込みで 1 ページに足りない場合は続きを取得し、ページの区切りも絞り込み後の結果に合わせる
+	inBattle = true
+	var seen []string
+	cursor := ""
+	for page := 0; page < 5; page++ {
+		result, err := finder.Execute(context.Background(), SearchRequest{Limit: 1, Cursor: cursor, InBattle: &inBattle})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, stage := range result.Stages {
+			if !stage.Stage.InBattle {
+				t.Errorf("%s is not marked in battle", stage.Stage.ID)
+			}
+			seen = append(seen, stage.Stage.ID)
+		}
+		if cursor = result.NextCursor; cursor == "" {
+			break
+		}
+	}
+	if fmt.Sprint(seen) != "[stage-0 stage-2 stage-4]" {
+		t.Errorf("in-battle pages = %v", seen)
+	}
+}
+
+func TestNearbyFinder_InvalidCursor(t *testing.T) {
+	finder := newTestFinder(&stubRepository{}, nil)
+
+	_, err := finder.Execute(context.Background(), SearchRequest{Cursor: "not-a-cursor!"})
+	if !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("expected ErrInvalidCursor, got %v", err)
+	}
+}
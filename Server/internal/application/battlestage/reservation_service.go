@@ -0,0 +1,174 @@
+package battlestage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	domain "server/internal/domain/battlestage"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidReservation は予約枠の指定が不正な場合のエラーです
+var ErrInvalidReservation = errors.New("invalid reservation window")
+
+// OccupancyProvider はステージ上で進行中の対戦セッションを返します（battle.Hub が実装）
+type OccupancyProvider interface {
+	StageOccupancy(stageIDs []string) map[string][]domain.Occupancy
+}
+
+// ReservationOptions は予約と空き状況計算の設定です
+type ReservationOptions struct {
+	SlotDuration      time.Duration // 空き枠として提示する長さ
+	MaxDuration       time.Duration // 1 回の予約の最大長
+	MaxAdvance        time.Duration // どれだけ先まで予約できるか
+	OccupancyEstimate time.Duration // 進行中の対戦がステージを占有すると見込む時間
+}
+
+// ReservationService はステージの空き状況と予約のユースケースです
+type ReservationService struct {
+	stages       domain.Repository
+	reservations domain.ReservationRepository
+	occupancy    OccupancyProvider
+	options      ReservationOptions
+	now          func() time.Time
+}
+
+// NewReservationService はユースケースを生成します。occupancy が nil の場合は予約のみで判定します。
+func NewReservationService(stages domain.Repository, reservations domain.ReservationRepository, occupancy OccupancyProvider, options ReservationOptions) *ReservationService {
+	return &ReservationService{
+		stages:       stages,
+		reservations: reservations,
+		occupancy:    occupancy,
+		options:      options,
+		now:          time.Now,
+	}
+}
+
+// Availability は各ステージの占有状況・予約・次の空き枠を返します
+func (s *ReservationService) Availability(ctx context.Context, stageIDs []string) (map[string]domain.Availability, error) {
+	result := make(map[string]domain.Availability, len(stageIDs))
+	if len(stageIDs) == 0 {
+		return result, nil
+	}
+
+	now := s.now()
+	reservations, err := s.reservations.ListReservations(ctx, stageIDs, now, now.Add(s.options.MaxAdvance))
+	if err != nil {
+		return nil, fmt.Errorf("list reservations: %w", err)
+	}
+
+	byStage := make(map[string][]domain.Reservation, len(stageIDs))
+	for _, reservation := range reservations {
+		byStage[reservation.StageID] = append(byStage[reservation.StageID], reservation)
+	}
+
+	var occupancy map[string][]domain.Occupancy
+	if s.occupancy != nil {
+		occupancy = s.occupancy.StageOccupancy(stageIDs)
+	}
+
+	for _, stageID := range stageIDs {
+		occupants := occupancy[stageID]
+		stageReservations := byStage[stageID]
+		busyUntil := s.busyUntil(occupants, now)
+
+		slot := domain.NextFreeSlot(stageReservations, now, busyUntil, s.options.SlotDuration)
+		result[stageID] = domain.Availability{
+			Occupants:    occupants,
+			Reservations: stageReservations,
+			Available:    len(occupants) == 0 && !slot.StartsAt.After(now),
+			NextFreeSlot: slot,
+		}
+	}
+
+	return result, nil
+}
+
+// Reserve はステージの時間枠を予約します。
+// 既存の予約や、進行中の対戦の見込み占有時間と重なる場合は ErrReservationConflict を返します。
+func (s *ReservationService) Reserve(ctx context.Context, stageID string, userID uuid.UUID, startsAt, endsAt time.Time) (*domain.Reservation, error) {
+	now := s.now()
+
+	switch {
+	case !endsAt.After(startsAt):
+		return nil, fmt.Errorf("%w: endsAt must be after startsAt", ErrInvalidReservation)
+	case endsAt.Before(now):
+		return nil, fmt.Errorf("%w: window is in the past", ErrInvalidReservation)
+	case s.options.MaxDuration > 0 && endsAt.Sub(startsAt) > s.options.MaxDuration:
+		return nil, fmt.Errorf("%w: window must not exceed %s", ErrInvalidReservation, s.options.MaxDuration)
+	case s.options.MaxAdvance > 0 && startsAt.After(now.Add(s.options.MaxAdvance)):
+		return nil, fmt.Errorf("%w: window starts too far in the future", ErrInvalidReservation)
+	}
+
+	stage, err := s.stages.FindByID(ctx, stageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.occupancy != nil {
+		occupants := s.occupancy.StageOccupancy([]string{stage.ID})[stage.ID]
+		if startsAt.Before(s.busyUntil(occupants, now)) {
+			return nil, domain.ErrReservationConflict
+		}
+	}
+
+	reservation := &domain.Reservation{
+		ID:        uuid.New(),
+		StageID:   stage.ID,
+		UserID:    userID,
+		StartsAt:  startsAt,
+		EndsAt:    endsAt,
+		CreatedAt: now,
+	}
+
+	// 重複チェックはリポジトリ側（排他制約）で原子的に行う
+	if err := s.reservations.CreateReservation(ctx, reservation); err != nil {
+		return nil, err
+	}
+
+	return reservation, nil
+}
+
+// CheckReserved は、いま stageID で対戦を始めた場合の見込み占有時間（OccupancyEstimate）に
+// userID 以外の予約が重なる場合に ErrStageReserved を返します
+func (s *ReservationService) CheckReserved(ctx context.Context, stageID string, userID uuid.UUID) error {
+	now := s.now()
+	reservations, err := s.reservations.ListReservations(ctx, []string{stageID}, now, now.Add(s.options.OccupancyEstimate))
+	if err != nil {
+		return fmt.Errorf("list reservations: %w", err)
+	}
+	for _, reservation := range reservations {
+		if reservation.UserID != userID {
+			return domain.ErrStageReserved
+		}
+	}
+	return nil
+}
+
+// Cancel は自分の予約を取り消します
+func (s *ReservationService) Cancel(ctx context.Context, reservationID, userID uuid.UUID) error {
+	return s.reservations.DeleteReservation(ctx, reservationID, userID)
+}
+
+// busyUntil は進行中の対戦がステージを占有すると見込まれる時刻を返します
+func (s *ReservationService) busyUntil(occupants []domain.Occupancy, now time.Time) time.Time {
+	busyUntil := now
+	for _, occupant := range occupants {
+		since := occupant.CreatedAt
+		if occupant.StartedAt != nil {
+			since = *occupant.StartedAt
+		}
+		end := since.Add(s.options.OccupancyEstimate)
+		if end.Before(now) {
+			// 見込みを過ぎても終わっていない対戦は、少なくとも 1 枠分は占有が続くものとみなす
+			end = now.Add(s.options.SlotDuration)
+		}
+		if end.After(busyUntil) {
+			busyUntil = end
+		}
+	}
+	return busyUntil
+}
@@ -0,0 +1,180 @@
+package battlestage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	domain "server/internal/domain/battlestage"
+
+	"github.com/google/uuid"
+)
+
+// stubReservationRepository は重複を検出するテスト用の予約リポジトリです
+type stubReservationRepository struct {
+	reservations []domain.Reservation
+}
+
+func (s *stubReservationRepository) CreateReservation(ctx context.Context, reservation *domain.Reservation) error {
+	for _, existing := range s.reservations {
+		if existing.StageID == reservation.StageID && existing.Overlaps(reservation.StartsAt, reservation.EndsAt) {
+			return domain.ErrReservationConflict
+		}
+	}
+	s.reservations = append(s.reservations, *reservation)
+	return nil
+}
+
+func (s *stubReservationRepository) ListReservations(ctx context.Context, stageIDs []string, from, to time.Time) ([]domain.Reservation, error) {
+	var results []domain.Reservation
+	for _, reservation := range s.reservations {
+		for _, id := range stageIDs {
+			if reservation.StageID == id && reservation.Overlaps(from, to) {
+				results = append(results, reservation)
+			}
+		}
+	}
+	return results, nil
+}
+
+func (s *stubReservationRepository) DeleteReservation(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+	for i, reservation := range s.reservations {
+		if reservation.ID == id && reservation.UserID == userID {
+			s.reservations = append(s.reservations[:i], s.reservations[i+1:]...)
+			return nil
+		}
+	}
+	return domain.ErrReservationNotFound
+}
+
+// stubOccupancy は固定の占有状況を返します
+type stubOccupancy map[string][]domain.Occupancy
+
+func (s stubOccupancy) StageOccupancy(stageIDs []string) map[string][]domain.Occupancy {
+	return s
+}
+
+func newTestReservationService(now time.Time, occupancy OccupancyProvider) (*ReservationService, *stubReservationRepository) {
+	stages := &stubRepository{stages: []domain.StageWithDistance{
+		{Stage: domain.Stage{ID: "park"}},
+		{Stage: domain.Stage{ID: "beach"}},
+	}}
+	reservations := &stubReservationRepository{}
+	service := NewReservationService(stages, reservations, occupancy, ReservationOptions{
+		SlotDuration:      30 * time.Minute,
+		MaxDuration:       2 * time.Hour,
+		MaxAdvance:        24 * time.Hour,
+		OccupancyEstimate: 15 * time.Minute,
+	})
+	service.now = func() time.Time { return now }
+	return service, reservations
+}
+
+func TestReservationService_ReserveConflicts(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	service, _ := newTestReservationService(now, nil)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	if _, err := service.Reserve(ctx, "park", userID, now.Add(time.Hour), now.Add(2*time.Hour)); err != nil {
+		t.Fatalf("expected reservation to succeed, got %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		stageID  string
+		startsAt time.Time
+		endsAt   time.Time
+		expected error
+	}{
+		{"overlapping window", "park", now.Add(90 * time.Minute), now.Add(150 * time.Minute), domain.ErrReservationConflict},
+		{"adjacent window", "park", now.Add(2 * time.Hour), now.Add(3 * time.Hour), nil},
+		{"other stage", "beach", now.Add(time.Hour), now.Add(2 * time.Hour), nil},
+		{"unknown stage", "mountain", now.Add(time.Hour), now.Add(2 * time.Hour), domain.ErrStageNotFound},
+		{"reversed window", "beach", now.Add(3 * time.Hour), now.Add(2 * time.Hour), ErrInvalidReservation},
+		{"too long", "beach", now.Add(4 * time.Hour), now.Add(7 * time.Hour), ErrInvalidReservation},
+		{"too far ahead", "beach", now.Add(48 * time.Hour), now.Add(49 * time.Hour), ErrInvalidReservation},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := service.Reserve(ctx, tc.stageID, userID, tc.startsAt, tc.endsAt)
+			if !errors.Is(err, tc.expected) {
+				t.Fatalf("expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestReservationService_Availability(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	startedAt := now.Add(-5 * time.Minute)
+	occupancy := stubOccupancy{
+		"beach": {{SessionID: "session-1", Status: "active", StartedAt: &startedAt, CreatedAt: startedAt}},
+	}
+	service, _ := newTestReservationService(now, occupancy)
+	ctx := context.Background()
+
+	// park: 12:10-12:30 と 12:30-13:00 が予約済み → 次の 30 分枠は 13:00 から
+	if _, err := service.Reserve(ctx, "park", uuid.New(), now.Add(10*time.Minute), now.Add(30*time.Minute)); err != nil {
+		t.Fatalf("failed to reserve: %v", err)
+	}
+	if _, err := service.Reserve(ctx, "park", uuid.New(), now.Add(30*time.Minute), now.Add(time.Hour)); err != nil {
+		t.Fatalf("failed to reserve: %v", err)
+	}
+
+	// beach は対戦中のため、見込み終了時刻より前は予約できない
+	if _, err := service.Reserve(ctx, "beach", uuid.New(), now, now.Add(30*time.Minute)); !errors.Is(err, domain.ErrReservationConflict) {
+		t.Fatalf("expected conflict with active battle, got %v", err)
+	}
+
+	availability, err := service.Availability(ctx, []string{"park", "beach"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	park := availability["park"]
+	if park.Available {
+		t.Error("expected park to be unavailable because the next 30 minutes are booked")
+	}
+	if !park.NextFreeSlot.StartsAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("expected park to be free from 13:00, got %s", park.NextFreeSlot.StartsAt)
+	}
+	if len(park.Reservations) != 2 {
+		t.Errorf("expected 2 park reservations, got %d", len(park.Reservations))
+	}
+
+	beach := availability["beach"]
+	if beach.Available || len(beach.Occupants) != 1 {
+		t.Errorf("expected beach to be occupied, got %+v", beach)
+	}
+	if !beach.NextFreeSlot.StartsAt.Equal(startedAt.Add(15 * time.Minute)) {
+		t.Errorf("expected beach to be free after the estimated battle end, got %s", beach.NextFreeSlot.StartsAt)
+	}
+}
+
+func TestReservationService_CheckReserved(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	service, _ := newTestReservationService(now, nil)
+	ctx := context.Background()
+	owner := uuid.New()
+
+	// 対戦の見込み占有時間（15 分）と重なる予約だけが対戦の開始を妨げる
+	if _, err := service.Reserve(ctx, "park", owner, now.Add(10*time.Minute), now.Add(40*time.Minute)); err != nil {
+		t.Fatalf("failed to reserve: %v", err)
+	}
+	if _, err := service.Reserve(ctx, "beach", owner, now.Add(30*time.Minute), now.Add(time.Hour)); err != nil {
+		t.Fatalf("failed to reserve: %v", err)
+	}
+
+	if err := service.CheckReserved(ctx, "park", uuid.New()); !errors.Is(err, domain.ErrStageReserved) {
+		t.Fatalf("other user on reserved park: expected ErrStageReserved, got %v", err)
+	}
+	if err := service.CheckReserved(ctx, "park", owner); err != nil {
+		t.Fatalf("owner on reserved park: %v", err)
+	}
+	if err := service.CheckReserved(ctx, "beach", uuid.New()); err != nil {
+		t.Fatalf("beach reserved after the estimated battle: %v", err)
+	}
+}
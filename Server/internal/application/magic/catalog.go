@@ -0,0 +1,165 @@
+package magic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	domain "server/internal/domain/magic"
+	"server/internal/logging"
+)
+
+// Catalog は魔法の定義のユースケースです。
+// 一覧はプロセス内にキャッシュし、自分の変更では即座に、他のインスタンスの変更は Run の間隔で読み直します。
+type Catalog struct {
+	repo domain.Repository
+
+	mu      sync.RWMutex
+	cached  []domain.MagicType // nil の場合は未読み込み
+	version uint64             // 変更のたびに増やし、変更前に読み始めた一覧でキャッシュを上書きしないようにする
+}
+
+// NewCatalog はユースケースを生成します
+func NewCatalog(repo domain.Repository) *Catalog {
+	return &Catalog{repo: repo}
+}
+
+// List はすべての魔法を ID 順に返します。返したスライスは変更しないでください。
+func (c *Catalog) List(ctx context.Context) ([]domain.MagicType, error) {
+	c.mu.RLock()
+	cached := c.cached
+	c.mu.RUnlock()
+	if cached != nil {
+		return cached, nil
+	}
+	return c.Reload(ctx)
+}
+
+// Get は ID の魔法を返します。存在しなければ ErrMagicTypeNotFound を返します。
+func (c *Catalog) Get(ctx context.Context, id string) (*domain.MagicType, error) {
+	list, err := c.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		if list[i].ID == id {
+			magicType := list[i]
+			return &magicType, nil
+		}
+	}
+	return nil, domain.ErrMagicTypeNotFound
+}
+
+// Create は魔法を追加します
+func (c *Catalog) Create(ctx context.Context, magicType *domain.MagicType) error {
+	if err := magicType.Validate(); err != nil {
+		return err
+	}
+	if err := c.repo.Create(ctx, magicType); err != nil {
+		return err
+	}
+	c.invalidate()
+	return nil
+}
+
+// Update は魔法を更新します
+func (c *Catalog) Update(ctx context.Context, magicType *domain.MagicType) error {
+	if err := magicType.Validate(); err != nil {
+		return err
+	}
+	if err := c.repo.Update(ctx, magicType); err != nil {
+		return err
+	}
+	c.invalidate()
+	return nil
+}
+
+// Delete は魔法を削除します
+func (c *Catalog) Delete(ctx context.Context, id string) error {
+	if err := c.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	c.invalidate()
+	return nil
+}
+
+// Reload はリポジトリから一覧を読み直してキャッシュします。
+// 読み直している間に変更があった場合、読んだ一覧は古い可能性があるためキャッシュせず、次の List で読み直します。
+func (c *Catalog) Reload(ctx context.Context) ([]domain.MagicType, error) {
+	c.mu.RLock()
+	version := c.version
+	c.mu.RUnlock()
+
+	list, err := c.repo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list magic types: %w", err)
+	}
+	if list == nil {
+		list = []domain.MagicType{}
+	}
+
+	c.mu.Lock()
+	if c.version == version {
+		c.cached = list
+	}
+	c.mu.Unlock()
+	return list, nil
+}
+
+// Seed は魔法が 1 件もない場合に seed を投入します。ID の重複や範囲外の値を含む seed はエラーにします。
+// 管理 API で削除した魔法を起動のたびに戻さないよう、既にデータがあれば何もしません。
+func (c *Catalog) Seed(ctx context.Context, seed []domain.MagicType) error {
+	seen := make(map[string]bool, len(seed))
+	for _, magicType := range seed {
+		if err := magicType.Validate(); err != nil {
+			return err
+		}
+		if seen[magicType.ID] {
+			return fmt.Errorf("%w: duplicate id %q", domain.ErrInvalidMagicType, magicType.ID)
+		}
+		seen[magicType.ID] = true
+	}
+
+	list, err := c.repo.List(ctx)
+	if err != nil {
+		return fmt.Errorf("list magic types: %w", err)
+	}
+	if len(list) > 0 {
+		return nil
+	}
+
+	for i := range seed {
+		// 複数のインスタンスが同時に起動した場合は先に投入したものを残す
+		if err := c.repo.Create(ctx, &seed[i]); err != nil && !errors.Is(err, domain.ErrMagicTypeExists) {
+			return fmt.Errorf("seed magic type %q: %w", seed[i].ID, err)
+		}
+	}
+	c.invalidate()
+	return nil
+}
+
+// Run は interval ごとに一覧を読み直します。ctx がキャンセルされると戻ります。
+func (c *Catalog) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := c.Reload(ctx); err != nil {
+				logging.FromContext(ctx).Warn("failed to reload magic types", "error", err)
+			}
+		}
+	}
+}
+
+func (c *Catalog) invalidate() {
+	c.mu.Lock()
+	c.cached = nil
+	c.version++
+	c.mu.Unlock()
+}
@@ -0,0 +1,164 @@
+package magic
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+
+	domain "server/internal/domain/magic"
+)
+
+// stubRepository は List の呼び出し回数を数えるテスト用のリポジトリです
+type stubRepository struct {
+	magicTypes map[string]domain.MagicType
+	lists      int
+	onList     func() // 一覧を読んだ後、返す前に呼ぶ（読み直しと変更の競合の再現に使う）
+}
+
+func newStubRepository(magicTypes ...domain.MagicType) *stubRepository {
+	repo := &stubRepository{magicTypes: map[string]domain.MagicType{}}
+	for _, magicType := range magicTypes {
+		repo.magicTypes[magicType.ID] = magicType
+	}
+	return repo
+}
+
+func (s *stubRepository) List(ctx context.Context) ([]domain.MagicType, error) {
+	s.lists++
+	list := make([]domain.MagicType, 0, len(s.magicTypes))
+	for _, magicType := range s.magicTypes {
+		list = append(list, magicType)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	if s.onList != nil {
+		s.onList()
+	}
+	return list, nil
+}
+
+func (s *stubRepository) Create(ctx context.Context, magicType *domain.MagicType) error {
+	if _, exists := s.magicTypes[magicType.ID]; exists {
+		return domain.ErrMagicTypeExists
+	}
+	s.magicTypes[magicType.ID] = *magicType
+	return nil
+}
+
+func (s *stubRepository) Update(ctx context.Context, magicType *domain.MagicType) error {
+	if _, exists := s.magicTypes[magicType.ID]; !exists {
+		return domain.ErrMagicTypeNotFound
+	}
+	s.magicTypes[magicType.ID] = *magicType
+	return nil
+}
+
+func (s *stubRepository) Delete(ctx context.Context, id string) error {
+	if _, exists := s.magicTypes[id]; !exists {
+		return domain.ErrMagicTypeNotFound
+	}
+	delete(s.magicTypes, id)
+	return nil
+}
+
+var fireball = domain.MagicType{ID: "fireball", Name: "ファイアボール", Kind: domain.KindAttack, Target: domain.TargetEnemy, MPCost: 40, Damage: 30, Chant: "炎よ", ChantMatch: domain.ChantFuzzy}
+
+func TestCatalog_CachesUntilChanged(t *testing.T) {
+	ctx := context.Background()
+	repo := newStubRepository(fireball)
+	catalog := NewCatalog(repo)
+
+	for i := 0; i < 3; i++ {
+		if list, err := catalog.List(ctx); err != nil || len(list) != 1 {
+			t.Fatalf("list: %v, %v", list, err)
+		}
+	}
+	if repo.lists != 1 {
+		t.Fatalf("repository listed %d times, want 1 (cached)", repo.lists)
+	}
+
+	// 自分の変更は次の List に反映される
+	updated := fireball
+	updated.Damage = 45
+	if err := catalog.Update(ctx, &updated); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if got, err := catalog.Get(ctx, "fireball"); err != nil || got.Damage != 45 {
+		t.Fatalf("after update: %+v, %v", got, err)
+	}
+
+	// 他のインスタンスの変更は Reload で反映される
+	delete(repo.magicTypes, "fireball")
+	if _, err := catalog.Reload(ctx); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if _, err := catalog.Get(ctx, "fireball"); !errors.Is(err, domain.ErrMagicTypeNotFound) {
+		t.Fatalf("after reload: err = %v, want ErrMagicTypeNotFound", err)
+	}
+}
+
+func TestCatalog_ReloadDoesNotOverwriteNewerChanges(t *testing.T) {
+	ctx := context.Background()
+	repo := newStubRepository(fireball)
+	catalog := NewCatalog(repo)
+
+	// 読み直しの途中（古い一覧を読んだ後）に変更が入る
+	updated := fireball
+	updated.Damage = 45
+	repo.onList = func() {
+		repo.onList = nil
+		if err := catalog.Update(ctx, &updated); err != nil {
+			t.Fatalf("update: %v", err)
+		}
+	}
+	if _, err := catalog.Reload(ctx); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+
+	if got, err := catalog.Get(ctx, "fireball"); err != nil || got.Damage != 45 {
+		t.Fatalf("after racing reload: %+v, %v (stale list was cached)", got, err)
+	}
+}
+
+func TestCatalog_ValidatesValues(t *testing.T) {
+	catalog := NewCatalog(newStubRepository())
+	for _, tc := range []struct {
+		magicType domain.MagicType
+		field     string
+	}{
+		{domain.MagicType{ID: "free", Name: "無料", Kind: domain.KindAttack, Target: domain.TargetEnemy, MPCost: 0, Damage: 10, Chant: "無料", ChantMatch: domain.ChantExact}, "mp_cost"},
+		{domain.MagicType{ID: "huge", Name: "過大", Kind: domain.KindAttack, Target: domain.TargetEnemy, MPCost: 10, Damage: domain.MaxDamage + 1, Chant: "過大", ChantMatch: domain.ChantExact}, "damage"},
+		{domain.MagicType{ID: "silent", Name: "無詠唱", Kind: domain.KindAttack, Target: domain.TargetEnemy, MPCost: 10, Damage: 10, Chant: "、。", ChantMatch: domain.ChantExact}, "chant"},
+		{domain.MagicType{ID: "loose", Name: "曖昧", Kind: domain.KindAttack, Target: domain.TargetEnemy, MPCost: 10, Damage: 10, Chant: "曖昧", ChantMatch: "loose"}, "chant_match"},
+		{domain.MagicType{ID: "kindless", Name: "種類なし", MPCost: 10, Damage: 10, Chant: "種類なし", ChantMatch: domain.ChantExact}, "kind"},
+		{domain.MagicType{ID: "drain", Name: "吸収", Kind: domain.KindHeal, Target: domain.TargetEnemy, MPCost: 10, Heal: 10, Chant: "吸収", ChantMatch: domain.ChantExact}, "target"},
+		{domain.MagicType{ID: "harmful_heal", Name: "痛い回復", Kind: domain.KindHeal, Target: domain.TargetSelf, MPCost: 10, Damage: 5, Heal: 10, Chant: "痛い回復", ChantMatch: domain.ChantExact}, "damage"},
+		{domain.MagicType{ID: "empty_heal", Name: "空の回復", Kind: domain.KindHeal, Target: domain.TargetAlly, MPCost: 10, Chant: "空の回復", ChantMatch: domain.ChantExact}, "heal"},
+	} {
+		err := catalog.Create(context.Background(), &tc.magicType)
+		var invalid *domain.ValidationError
+		if !errors.Is(err, domain.ErrInvalidMagicType) || !errors.As(err, &invalid) || invalid.Field != tc.field {
+			t.Errorf("create %s: err = %v, want ErrInvalidMagicType on %s", tc.magicType.ID, err, tc.field)
+		}
+	}
+}
+
+func TestCatalog_SeedsOnlyEmptyRepository(t *testing.T) {
+	ctx := context.Background()
+	thunderbolt := domain.MagicType{ID: "thunderbolt", Name: "サンダーボルト", Kind: domain.KindAttack, Target: domain.TargetEnemy, MPCost: 40, Damage: 30, Chant: "雷よ", ChantMatch: domain.ChantKeyword}
+
+	repo := newStubRepository()
+	if err := NewCatalog(repo).Seed(ctx, []domain.MagicType{fireball, thunderbolt}); err != nil || len(repo.magicTypes) != 2 {
+		t.Fatalf("seed empty: %d magic types, err = %v", len(repo.magicTypes), err)
+	}
+
+	// 管理 API で削除した魔法は再起動しても戻さない
+	repo = newStubRepository(thunderbolt)
+	if err := NewCatalog(repo).Seed(ctx, []domain.MagicType{fireball, thunderbolt}); err != nil || len(repo.magicTypes) != 1 {
+		t.Fatalf("seed non-empty: %d magic types, err = %v", len(repo.magicTypes), err)
+	}
+
+	if err := NewCatalog(newStubRepository()).Seed(ctx, []domain.MagicType{fireball, fireball}); !errors.Is(err, domain.ErrInvalidMagicType) {
+		t.Fatalf("duplicate ids: err = %v, want ErrInvalidMagicType", err)
+	}
+}
@@ -3,13 +3,17 @@ package auth
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"strings"
 	"time"
 
+	"server/internal/apierror"
 	"server/internal/domain/entities"
+	"server/internal/logging"
+	"server/internal/metrics"
+	"server/internal/request"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
@@ -27,9 +31,33 @@ type AuthHandler struct {
 	userRepo    UserRepository
 	playerRepo  PlayerRepository
 	sessionRepo SessionRepository
+	unitOfWork  UnitOfWork
 	jwtSecret   string
 }
 
+// UnitOfWork は複数のリポジトリ操作を 1 つのトランザクションにまとめます。
+// fn に渡された context を使ったリポジトリ呼び出しはトランザクションに参加し、
+// fn がエラーを返した場合はすべて取り消されます。
+type UnitOfWork interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// noTransaction はトランザクションを持たないストア向けの UnitOfWork です
+type noTransaction struct{}
+
+func (noTransaction) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// signUpStepError はサインアップのどの手順で失敗したかを保持します
+type signUpStepError struct {
+	message string
+	err     error
+}
+
+func (e *signUpStepError) Error() string { return e.message + ": " + e.err.Error() }
+func (e *signUpStepError) Unwrap() error { return e.err }
+
 // UserRepository はユーザーリポジトリのインターフェースです
 type UserRepository interface {
 	CreateUser(ctx context.Context, user *entities.User) error
@@ -46,27 +74,43 @@ type SessionRepository interface {
 	DeleteExpiredSessions(ctx context.Context) error
 }
 
-// NewAuthHandler は新しい認証ハンドラーを作成します
-func NewAuthHandler(userRepo UserRepository, playerRepo PlayerRepository, sessionRepo SessionRepository, jwtSecret string) *AuthHandler {
+// NewAuthHandler は新しい認証ハンドラーを作成します。
+// unitOfWork が nil の場合、サインアップの各操作はトランザクションなしで実行されます。
+func NewAuthHandler(userRepo UserRepository, playerRepo PlayerRepository, sessionRepo SessionRepository, unitOfWork UnitOfWork, jwtSecret string) *AuthHandler {
+	if unitOfWork == nil {
+		unitOfWork = noTransaction{}
+	}
 	return &AuthHandler{
 		userRepo:    userRepo,
 		playerRepo:  playerRepo,
 		sessionRepo: sessionRepo,
+		unitOfWork:  unitOfWork,
 		jwtSecret:   jwtSecret,
 	}
 }
 
 // SignUpRequest はユーザー登録リクエストです
 type SignUpRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
-	FullName string `json:"full_name"`
+	Email    string `json:"email" validate:"required,email,max=254"`
+	Password string `json:"password" validate:"required,min=8,max=72"` // bcrypt は 72 バイトを超えるパスワードを扱えない
+	FullName string `json:"full_name" validate:"max=100"`
+}
+
+// Normalize はメールアドレスを小文字にし、前後の空白を除きます
+func (r *SignUpRequest) Normalize() {
+	r.Email = normalizeEmail(r.Email)
+	r.FullName = strings.TrimSpace(r.FullName)
 }
 
 // SignInRequest はサインインリクエストです
 type SignInRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+// Normalize はメールアドレスを小文字にし、前後の空白を除きます
+func (r *SignInRequest) Normalize() {
+	r.Email = normalizeEmail(r.Email)
 }
 
 // SignInResponse はサインインレスポンスです
@@ -85,63 +129,70 @@ type UserInfo struct {
 
 // HandleSignUp はユーザー登録を処理します
 func (h *AuthHandler) HandleSignUp(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		h.respondError(w, r, http.StatusMethodNotAllowed, "Method not allowed", nil)
-		return
-	}
-
 	if !h.ensureDependencies(w, r) {
 		return
 	}
 
 	var req SignUpRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.respondError(w, r, http.StatusBadRequest, "Invalid request body", err)
-		return
-	}
-
-	email := normalizeEmail(req.Email)
-	if email == "" || len(req.Password) < 8 {
-		h.respondError(w, r, http.StatusBadRequest, "Invalid email or password", fmt.Errorf("email=%q length=%d", email, len(req.Password)))
+	if err := request.Decode(w, r, &req); err != nil {
+		apierror.Write(w, r, err)
 		return
 	}
 
 	ctx := r.Context()
 
-	if _, err := h.userRepo.GetUserByEmail(ctx, email); err == nil {
-		h.respondError(w, r, http.StatusConflict, "User already exists", fmt.Errorf("email=%s", email))
+	// 同時に登録された場合はこの確認をすり抜けるため、CreateUser の ErrUserExists でも 409 を返す
+	if _, err := h.userRepo.GetUserByEmail(ctx, req.Email); err == nil {
+		apierror.Write(w, r, apierror.Wrap(fmt.Errorf("email=%s", req.Email), apierror.CodeUserExists, "User already exists"))
 		return
 	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
-		h.respondError(w, r, http.StatusInternalServerError, "Failed to process password", err)
+		apierror.Write(w, r, apierror.Wrap(err, apierror.CodeInternal, "Failed to process password"))
 		return
 	}
 
-	user := entities.NewUser(email, string(hashedPassword), strings.TrimSpace(req.FullName))
-	if err := h.userRepo.CreateUser(ctx, user); err != nil {
-		h.respondError(w, r, http.StatusInternalServerError, "Failed to create user", err)
+	user := entities.NewUser(req.Email, string(hashedPassword), req.FullName)
+	accessToken, expiresAt, err := h.generateAccessToken(user.ID)
+	if err != nil {
+		apierror.Write(w, r, apierror.Wrap(err, apierror.CodeInternal, "Failed to generate access token"))
 		return
 	}
 
-	player := entities.NewPlayer(&user.ID, user.FullName)
-	if err := h.playerRepo.CreatePlayer(ctx, player); err != nil {
-		h.respondError(w, r, http.StatusInternalServerError, "Failed to create player", err)
+	// ユーザー・プレイヤー・セッションの作成はすべて成功するか、すべて取り消される
+	err = h.unitOfWork.WithTx(ctx, func(ctx context.Context) error {
+		if err := h.userRepo.CreateUser(ctx, user); err != nil {
+			return &signUpStepError{message: "Failed to create user", err: err}
+		}
+
+		player := entities.NewPlayer(&user.ID, user.FullName)
+		if err := h.playerRepo.CreatePlayer(ctx, player); err != nil {
+			return &signUpStepError{message: "Failed to create player", err: err}
+		}
+
+		session := entities.NewSession(user.ID, accessToken, expiresAt)
+		if err := h.sessionRepo.CreateSession(ctx, session); err != nil {
+			return &signUpStepError{message: "Failed to create session", err: err}
+		}
+		return nil
+	})
+	if errors.Is(err, entities.ErrUserExists) {
+		apierror.Write(w, r, apierror.Wrap(fmt.Errorf("email=%s: %w", req.Email, err), apierror.CodeUserExists, "User already exists"))
 		return
 	}
-
-	accessToken, expiresAt, err := h.generateAccessToken(user.ID)
 	if err != nil {
-		h.respondError(w, r, http.StatusInternalServerError, "Failed to generate access token", err)
+		message := "Failed to create user"
+		var stepErr *signUpStepError
+		if errors.As(err, &stepErr) {
+			message = stepErr.message
+		}
+		apierror.Write(w, r, apierror.Wrap(err, apierror.CodeInternal, message))
 		return
 	}
 
-	session := entities.NewSession(user.ID, accessToken, expiresAt)
-	if err := h.sessionRepo.CreateSession(ctx, session); err != nil {
-		h.respondError(w, r, http.StatusInternalServerError, "Failed to create session", err)
-		return
-	}
+	ctx = logging.With(ctx, "user_id", user.ID.String())
+	logging.FromContext(ctx).Info("auth: user signed up")
 
 	response := SignInResponse{
 		AccessToken: accessToken,
@@ -160,52 +211,47 @@ func (h *AuthHandler) HandleSignUp(w http.ResponseWriter, r *http.Request) {
 
 // HandleSignIn はメール・パスワードによるサインインを処理します
 func (h *AuthHandler) HandleSignIn(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		h.respondError(w, r, http.StatusMethodNotAllowed, "Method not allowed", nil)
-		return
-	}
-
 	if !h.ensureDependencies(w, r) {
 		return
 	}
 
 	var req SignInRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.respondError(w, r, http.StatusBadRequest, "Invalid request body", err)
-		return
-	}
-
-	email := normalizeEmail(req.Email)
-	if email == "" || req.Password == "" {
-		h.respondError(w, r, http.StatusBadRequest, "Invalid email or password", fmt.Errorf("email empty? %t password length=%d", email == "", len(req.Password)))
+	if err := request.Decode(w, r, &req); err != nil {
+		apierror.Write(w, r, err)
 		return
 	}
 
 	ctx := r.Context()
 
-	user, err := h.userRepo.GetUserByEmail(ctx, email)
+	user, err := h.userRepo.GetUserByEmail(ctx, req.Email)
 	if err != nil {
-		h.respondError(w, r, http.StatusUnauthorized, "Invalid email or password", err)
+		metrics.SignIn(false)
+		apierror.Write(w, r, apierror.Wrap(err, apierror.CodeInvalidCredentials, "Invalid email or password"))
 		return
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
-		h.respondError(w, r, http.StatusUnauthorized, "Invalid email or password", err)
+		metrics.SignIn(false)
+		apierror.Write(w, r, apierror.Wrap(err, apierror.CodeInvalidCredentials, "Invalid email or password"))
 		return
 	}
 
 	accessToken, expiresAt, err := h.generateAccessToken(user.ID)
 	if err != nil {
-		h.respondError(w, r, http.StatusInternalServerError, "Failed to generate access token", err)
+		apierror.Write(w, r, apierror.Wrap(err, apierror.CodeInternal, "Failed to generate access token"))
 		return
 	}
 
 	session := entities.NewSession(user.ID, accessToken, expiresAt)
 	if err := h.sessionRepo.CreateSession(ctx, session); err != nil {
-		h.respondError(w, r, http.StatusInternalServerError, "Failed to create session", err)
+		apierror.Write(w, r, apierror.Wrap(err, apierror.CodeInternal, "Failed to create session"))
 		return
 	}
 
+	ctx = logging.With(ctx, "user_id", user.ID.String())
+	logging.FromContext(ctx).Info("auth: user signed in")
+	metrics.SignIn(true)
+
 	response := SignInResponse{
 		AccessToken: accessToken,
 		User: &UserInfo{
@@ -222,24 +268,19 @@ func (h *AuthHandler) HandleSignIn(w http.ResponseWriter, r *http.Request) {
 
 // HandleRefresh トークンリフレッシュを処理します
 func (h *AuthHandler) HandleRefresh(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		h.respondError(w, r, http.StatusMethodNotAllowed, "Method not allowed", nil)
-		return
-	}
-
 	if !h.ensureDependencies(w, r) {
 		return
 	}
 
 	authHeader := r.Header.Get("Authorization")
 	if authHeader == "" {
-		h.respondError(w, r, http.StatusUnauthorized, "Authorization header required", nil)
+		apierror.Write(w, r, apierror.New(apierror.CodeAuthRequired, "Authorization header required"))
 		return
 	}
 
 	token := strings.TrimPrefix(authHeader, "Bearer ")
 	if token == authHeader {
-		h.respondError(w, r, http.StatusUnauthorized, "Invalid authorization header format", fmt.Errorf("auth header=%s", authHeader))
+		apierror.Write(w, r, apierror.New(apierror.CodeInvalidToken, "Invalid authorization header format"))
 		return
 	}
 
@@ -247,24 +288,24 @@ func (h *AuthHandler) HandleRefresh(w http.ResponseWriter, r *http.Request) {
 
 	session, err := h.sessionRepo.GetSessionByToken(ctx, token)
 	if err != nil {
-		h.respondError(w, r, http.StatusUnauthorized, "Invalid session", err)
+		apierror.Write(w, r, apierror.Wrap(err, apierror.CodeInvalidToken, "Invalid session"))
 		return
 	}
 
 	if session.IsExpired() {
-		h.respondError(w, r, http.StatusUnauthorized, "Session expired", fmt.Errorf("token=%s", token))
+		apierror.Write(w, r, apierror.New(apierror.CodeSessionExpired, "Session expired"))
 		return
 	}
 
 	user, err := h.userRepo.GetUserByID(ctx, session.UserID)
 	if err != nil {
-		h.respondError(w, r, http.StatusUnauthorized, "User not found", err)
+		apierror.Write(w, r, apierror.Wrap(err, apierror.CodeInvalidToken, "User not found"))
 		return
 	}
 
 	newAccessToken, newExpiresAt, err := h.generateAccessToken(user.ID)
 	if err != nil {
-		h.respondError(w, r, http.StatusInternalServerError, "Failed to generate new access token", err)
+		apierror.Write(w, r, apierror.Wrap(err, apierror.CodeInternal, "Failed to generate new access token"))
 		return
 	}
 
@@ -274,7 +315,7 @@ func (h *AuthHandler) HandleRefresh(w http.ResponseWriter, r *http.Request) {
 
 	newSession := entities.NewSession(user.ID, newAccessToken, newExpiresAt)
 	if err := h.sessionRepo.CreateSession(ctx, newSession); err != nil {
-		h.respondError(w, r, http.StatusInternalServerError, "Failed to create new session", err)
+		apierror.Write(w, r, apierror.Wrap(err, apierror.CodeInternal, "Failed to create new session"))
 		return
 	}
 
@@ -294,31 +335,26 @@ func (h *AuthHandler) HandleRefresh(w http.ResponseWriter, r *http.Request) {
 
 // HandleLogout ログアウトを処理します
 func (h *AuthHandler) HandleLogout(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		h.respondError(w, r, http.StatusMethodNotAllowed, "Method not allowed", nil)
-		return
-	}
-
 	if !h.ensureDependencies(w, r) {
 		return
 	}
 
 	authHeader := r.Header.Get("Authorization")
 	if authHeader == "" {
-		h.respondError(w, r, http.StatusUnauthorized, "Authorization header required", nil)
+		apierror.Write(w, r, apierror.New(apierror.CodeAuthRequired, "Authorization header required"))
 		return
 	}
 
 	token := strings.TrimPrefix(authHeader, "Bearer ")
 	if token == authHeader {
-		h.respondError(w, r, http.StatusUnauthorized, "Invalid authorization header format", fmt.Errorf("auth header=%s", authHeader))
+		apierror.Write(w, r, apierror.New(apierror.CodeInvalidToken, "Invalid authorization header format"))
 		return
 	}
 
 	ctx := r.Context()
 
 	if err := h.sessionRepo.DeleteSession(ctx, token); err != nil {
-		log.Printf("auth: failed to delete session token=%s: %v", token, err)
+		logging.FromContext(ctx).Warn("auth: failed to delete session", "error", err)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -333,6 +369,7 @@ func (h *AuthHandler) generateAccessToken(userID uuid.UUID) (string, time.Time,
 		"user_id":    userID.String(),
 		"exp":        expiresAt.Unix(),
 		"iat":        time.Now().Unix(),
+		"jti":        uuid.NewString(), // 同じ秒に発行しても別のトークン（セッション）になるようにする
 		"token_type": "access",
 	}
 
@@ -351,25 +388,8 @@ func normalizeEmail(email string) string {
 
 func (h *AuthHandler) ensureDependencies(w http.ResponseWriter, r *http.Request) bool {
 	if h.userRepo == nil || h.playerRepo == nil || h.sessionRepo == nil {
-		h.respondError(w, r, http.StatusServiceUnavailable, "Authentication service unavailable", fmt.Errorf("userRepo nil=%t playerRepo nil=%t sessionRepo nil=%t", h.userRepo == nil, h.playerRepo == nil, h.sessionRepo == nil))
+		apierror.Write(w, r, apierror.Wrap(fmt.Errorf("userRepo nil=%t playerRepo nil=%t sessionRepo nil=%t", h.userRepo == nil, h.playerRepo == nil, h.sessionRepo == nil), apierror.CodeServiceUnavailable, "Authentication service unavailable"))
 		return false
 	}
 	return true
 }
-
-func (h *AuthHandler) respondError(w http.ResponseWriter, r *http.Request, status int, clientMessage string, err error) {
-	log.Printf("auth: %s %s -> status=%d message=%s error=%v", r.Method, r.URL.Path, status, clientMessage, err)
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-
-	response := map[string]string{
-		"message": clientMessage,
-	}
-	if err != nil {
-		response["error"] = err.Error()
-	}
-
-	if encodeErr := json.NewEncoder(w).Encode(response); encodeErr != nil {
-		log.Printf("auth: failed to encode error response: %v", encodeErr)
-	}
-}
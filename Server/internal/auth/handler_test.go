@@ -54,11 +54,20 @@ func (m *MockSessionRepository) DeleteExpiredSessions(ctx context.Context) error
 	return nil
 }
 
+func (m *MockSessionRepository) snapshot() func() {
+	saved := make(map[string]*entities.Session, len(m.sessions))
+	for token, session := range m.sessions {
+		copied := *session
+		saved[token] = &copied
+	}
+	return func() { m.sessions = saved }
+}
+
 func TestAuthHandler_HandleSignUp_Success(t *testing.T) {
 	userRepo := NewMockUserRepository()
 	sessionRepo := NewMockSessionRepository()
 	playerRepo := NewMockPlayerRepository()
-	handler := NewAuthHandler(userRepo, playerRepo, sessionRepo, "test-secret")
+	handler := NewAuthHandler(userRepo, playerRepo, sessionRepo, nil, "test-secret")
 
 	reqBody := SignUpRequest{
 		Email:    "NewUser@example.com",
@@ -100,11 +109,92 @@ func TestAuthHandler_HandleSignUp_Success(t *testing.T) {
 	}
 }
 
+func TestAuthHandler_HandleSignUp_RollsBackOnPlayerFailure(t *testing.T) {
+	userRepo := NewMockUserRepository()
+	sessionRepo := NewMockSessionRepository()
+	playerRepo := NewMockPlayerRepository()
+	unitOfWork := NewMockUnitOfWork(userRepo, playerRepo, sessionRepo)
+	handler := NewAuthHandler(userRepo, playerRepo, sessionRepo, unitOfWork, "test-secret")
+
+	signUp := func() *httptest.ResponseRecorder {
+		body, _ := json.Marshal(SignUpRequest{Email: "retry@example.com", Password: "Passw0rd!", FullName: "Retry"})
+		req := httptest.NewRequest(http.MethodPost, "/auth/signup", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.HandleSignUp(w, req)
+		return w
+	}
+
+	playerRepo.CreateErr = fmt.Errorf("players table unavailable")
+	if w := signUp(); w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+	if unitOfWork.Rollbacks != 1 {
+		t.Fatalf("expected 1 rollback, got %d", unitOfWork.Rollbacks)
+	}
+	if _, err := userRepo.GetUserByEmail(context.Background(), "retry@example.com"); err == nil {
+		t.Fatal("expected user creation to be rolled back")
+	}
+	if len(sessionRepo.sessions) != 0 {
+		t.Fatalf("expected no sessions, got %d", len(sessionRepo.sessions))
+	}
+
+	// 失敗後の再試行は "User already exists" にならず成功する
+	playerRepo.CreateErr = nil
+	if w := signUp(); w.Code != http.StatusCreated {
+		t.Fatalf("expected retry status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	user, err := userRepo.GetUserByEmail(context.Background(), "retry@example.com")
+	if err != nil {
+		t.Fatalf("expected user stored after retry: %v", err)
+	}
+	if _, err := playerRepo.GetPlayerByUserID(context.Background(), user.ID); err != nil {
+		t.Fatalf("expected player stored after retry: %v", err)
+	}
+	if unitOfWork.Commits != 1 {
+		t.Fatalf("expected 1 commit, got %d", unitOfWork.Commits)
+	}
+}
+
+// racingUserRepository は事前の確認の後に同じメールアドレスのユーザーが登録された状態を再現します
+type racingUserRepository struct {
+	*MockUserRepository
+}
+
+func (racingUserRepository) GetUserByEmail(ctx context.Context, email string) (*entities.User, error) {
+	return nil, fmt.Errorf("user not found")
+}
+
+func TestAuthHandler_HandleSignUp_ConcurrentDuplicateIsConflict(t *testing.T) {
+	userRepo := NewMockUserRepository()
+	userRepo.CreateUser(context.Background(), entities.NewUser("race@example.com", "hash", "First"))
+	sessionRepo := NewMockSessionRepository()
+	playerRepo := NewMockPlayerRepository()
+	unitOfWork := NewMockUnitOfWork(userRepo, playerRepo, sessionRepo)
+	handler := NewAuthHandler(racingUserRepository{userRepo}, playerRepo, sessionRepo, unitOfWork, "test-secret")
+
+	body, _ := json.Marshal(SignUpRequest{Email: "race@example.com", Password: "Passw0rd!", FullName: "Second"})
+	req := httptest.NewRequest(http.MethodPost, "/auth/signup", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.HandleSignUp(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusConflict, w.Code, w.Body.String())
+	}
+	if unitOfWork.Rollbacks != 1 {
+		t.Fatalf("expected 1 rollback, got %d", unitOfWork.Rollbacks)
+	}
+	if len(playerRepo.players) != 0 || len(sessionRepo.sessions) != 0 {
+		t.Fatalf("expected no players or sessions, got %d players and %d sessions", len(playerRepo.players), len(sessionRepo.sessions))
+	}
+}
+
 func TestAuthHandler_HandleSignIn(t *testing.T) {
 	userRepo := NewMockUserRepository()
 	sessionRepo := NewMockSessionRepository()
 	playerRepo := NewMockPlayerRepository()
-	handler := NewAuthHandler(userRepo, playerRepo, sessionRepo, "test-secret")
+	handler := NewAuthHandler(userRepo, playerRepo, sessionRepo, nil, "test-secret")
 
 	hashed, err := bcrypt.GenerateFromPassword([]byte("Passw0rd!"), bcrypt.DefaultCost)
 	if err != nil {
@@ -158,7 +248,7 @@ func TestAuthHandler_HandleLogout(t *testing.T) {
 	userRepo := NewMockUserRepository()
 	sessionRepo := NewMockSessionRepository()
 	playerRepo := NewMockPlayerRepository()
-	handler := NewAuthHandler(userRepo, playerRepo, sessionRepo, "test-secret")
+	handler := NewAuthHandler(userRepo, playerRepo, sessionRepo, nil, "test-secret")
 
 	tests := []struct {
 		name           string
@@ -215,3 +305,24 @@ func TestAuthHandler_GenerateAccessToken(t *testing.T) {
 		t.Error("expected token to be valid for at least 23 hours")
 	}
 }
+
+func TestAuthHandler_GenerateAccessToken_UniqueWithinSameSecond(t *testing.T) {
+	handler := &AuthHandler{
+		jwtSecret: "test-secret",
+	}
+
+	// iat と exp は秒単位のため、同じユーザーに続けて発行すると同じ秒になる
+	userID := uuid.New()
+	first, _, err := handler.generateAccessToken(userID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	second, _, err := handler.generateAccessToken(userID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if first == second {
+		t.Error("expected tokens issued in the same second to differ")
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"net/http"
 	"strings"
 
+	"server/internal/apierror"
 	"server/internal/domain/entities"
+	"server/internal/logging"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
@@ -39,44 +41,46 @@ func (m *AuthMiddleware) RequireAuth(next http.Handler) http.Handler {
 		// Authorizationヘッダーからトークンを取得
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
-			http.Error(w, "Authorization header required", http.StatusUnauthorized)
+			apierror.Write(w, r, apierror.New(apierror.CodeAuthRequired, "Authorization header required"))
 			return
 		}
 
 		token := strings.TrimPrefix(authHeader, "Bearer ")
 		if token == authHeader {
-			http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
+			apierror.Write(w, r, apierror.New(apierror.CodeInvalidToken, "Invalid authorization header format"))
 			return
 		}
 
 		// JWTトークンを検証
 		claims, err := m.validateToken(token)
 		if err != nil {
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			apierror.Write(w, r, apierror.New(apierror.CodeInvalidToken, "Invalid token"))
 			return
 		}
 
 		// セッションの存在確認
 		session, err := m.sessionRepo.GetSessionByToken(r.Context(), token)
 		if err != nil {
-			http.Error(w, "Session not found", http.StatusUnauthorized)
+			apierror.Write(w, r, apierror.New(apierror.CodeInvalidToken, "Session not found"))
 			return
 		}
 
 		if session.IsExpired() {
-			http.Error(w, "Session expired", http.StatusUnauthorized)
+			apierror.Write(w, r, apierror.New(apierror.CodeSessionExpired, "Session expired"))
 			return
 		}
 
 		// ユーザーIDをコンテキストに追加
-		userID, err := uuid.Parse(claims["user_id"].(string))
+		rawUserID, _ := claims["user_id"].(string)
+		userID, err := uuid.Parse(rawUserID)
 		if err != nil {
-			http.Error(w, "Invalid user ID in token", http.StatusUnauthorized)
+			apierror.Write(w, r, apierror.New(apierror.CodeInvalidToken, "Invalid user ID in token"))
 			return
 		}
 
 		ctx := context.WithValue(r.Context(), UserIDKey, userID)
 		ctx = context.WithValue(ctx, sessionKey, session)
+		ctx = logging.With(ctx, "user_id", userID.String())
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
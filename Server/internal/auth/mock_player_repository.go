@@ -13,6 +13,9 @@ import (
 // MockPlayerRepository はテスト用のプレイヤーリポジトリです
 type MockPlayerRepository struct {
 	players map[uuid.UUID]*entities.Player
+
+	// CreateErr が設定されている場合、CreatePlayer はこのエラーを返します
+	CreateErr error
 }
 
 // NewMockPlayerRepository は新しいモックプレイヤーリポジトリを作成します
@@ -23,6 +26,9 @@ func NewMockPlayerRepository() *MockPlayerRepository {
 }
 
 func (m *MockPlayerRepository) CreatePlayer(ctx context.Context, player *entities.Player) error {
+	if m.CreateErr != nil {
+		return m.CreateErr
+	}
 	m.players[player.ID] = player
 	return nil
 }
@@ -57,8 +63,7 @@ func (m *MockPlayerRepository) UpdatePlayerHP(ctx context.Context, playerID uuid
 	if !exists {
 		return fmt.Errorf("player not found")
 	}
-	player.HP = hp
-	player.UpdatedAt = time.Now()
+	player.UpdateHP(hp)
 	return nil
 }
 
@@ -71,3 +76,12 @@ func (m *MockPlayerRepository) UpdatePlayerMP(ctx context.Context, playerID uuid
 	player.UpdatedAt = time.Now()
 	return nil
 }
+
+func (m *MockPlayerRepository) snapshot() func() {
+	saved := make(map[uuid.UUID]*entities.Player, len(m.players))
+	for id, player := range m.players {
+		copied := *player
+		saved[id] = &copied
+	}
+	return func() { m.players = saved }
+}
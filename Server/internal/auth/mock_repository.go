@@ -24,6 +24,9 @@ func NewMockUserRepository() *MockUserRepository {
 
 func (m *MockUserRepository) CreateUser(ctx context.Context, user *entities.User) error {
 	email := strings.ToLower(user.Email)
+	if _, exists := m.users[email]; exists {
+		return entities.ErrUserExists
+	}
 	m.users[email] = user
 	return nil
 }
@@ -49,3 +52,12 @@ func (m *MockUserRepository) UpdateUser(ctx context.Context, user *entities.User
 	m.users[strings.ToLower(user.Email)] = user
 	return nil
 }
+
+func (m *MockUserRepository) snapshot() func() {
+	saved := make(map[string]*entities.User, len(m.users))
+	for email, user := range m.users {
+		copied := *user
+		saved[email] = &copied
+	}
+	return func() { m.users = saved }
+}
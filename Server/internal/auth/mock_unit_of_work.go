@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"context"
+)
+
+// mockTxParticipant はトランザクションのロールバックに対応するモックリポジトリです
+type mockTxParticipant interface {
+	snapshot() (restore func())
+}
+
+// MockUnitOfWork はテスト用の UnitOfWork です。
+// fn がエラーを返した場合、登録されたモックリポジトリを開始時点の状態に戻します。
+type MockUnitOfWork struct {
+	participants []mockTxParticipant
+	Commits      int
+	Rollbacks    int
+}
+
+// NewMockUnitOfWork は participants の状態を巻き戻せるモック UnitOfWork を作成します
+func NewMockUnitOfWork(participants ...mockTxParticipant) *MockUnitOfWork {
+	return &MockUnitOfWork{participants: participants}
+}
+
+func (m *MockUnitOfWork) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	restores := make([]func(), 0, len(m.participants))
+	for _, participant := range m.participants {
+		restores = append(restores, participant.snapshot())
+	}
+
+	if err := fn(ctx); err != nil {
+		for _, restore := range restores {
+			restore()
+		}
+		m.Rollbacks++
+		return err
+	}
+
+	m.Commits++
+	return nil
+}
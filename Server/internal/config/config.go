@@ -3,25 +3,53 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Config はアプリケーションの設定を管理します
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Auth     AuthConfig
-	CORS     CORSConfig
+	Server      ServerConfig
+	Database    DatabaseConfig
+	Auth        AuthConfig
+	CORS        CORSConfig
+	Stage       StageConfig
+	Battle      BattleConfig
+	Player      PlayerConfig
+	Storage     StorageConfig
+	Logging     LoggingConfig
+	Metrics     MetricsConfig
+	Tracing     TracingConfig
+	RateLimit   RateLimitConfig
+	Idempotency IdempotencyConfig
+	Admin       AdminConfig
+	Magic       MagicConfig
 }
 
+// 実行環境の種類
+const (
+	EnvironmentProduction  = "production"  // 本番・ステージング（既定）
+	EnvironmentDevelopment = "development" // ローカル開発。既知のパスワードのユーザーを投入するインメモリストアを使える
+)
+
 // ServerConfig はサーバー設定です
 type ServerConfig struct {
-	Port string
+	Port        string
+	Environment string
 }
 
 // DatabaseConfig はデータベース設定です
 type DatabaseConfig struct {
-	URL string
+	URL              string
+	MaxConns         int           // 接続プールの最大接続数
+	MinConns         int           // 常に確保しておく接続数
+	MaxConnLifetime  time.Duration // 接続を作り直すまでの時間
+	MaxConnIdleTime  time.Duration // アイドル接続を閉じるまでの時間
+	StatementTimeout time.Duration // 1 文あたりのタイムアウト（0 で無制限）
+	ConnectTimeout   time.Duration // 起動時の接続確認のタイムアウト
+	AutoMigrate      bool          // 起動時に未適用のマイグレーションを適用するか
+	SlowQuery        time.Duration // この時間を超えたクエリを警告ログに出す（0 で無効）
 }
 
 // AuthConfig は認証設定です
@@ -29,30 +57,218 @@ type AuthConfig struct {
 	JWTSecret string
 }
 
-// CORSConfig はCORS設定です
+// CORSConfig はCORS設定です。
+// AllowedOrigins には "https://app.example.com" のほか "https://*.example.com"（サブドメイン）や "*" を指定できます。
 type CORSConfig struct {
-	AllowedOrigins []string
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	MaxAge           time.Duration // プリフライトの結果をブラウザがキャッシュする時間
+	AllowCredentials bool          // Cookie などの資格情報付きのリクエストを許可するか（"*" とは併用できない）
+}
+
+func (c CORSConfig) validate() error {
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			if c.AllowCredentials {
+				return fmt.Errorf("CORS_ALLOW_CREDENTIALS cannot be used with CORS_ALLOWED_ORIGINS=*")
+			}
+			continue
+		}
+		if !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+			return fmt.Errorf("CORS_ALLOWED_ORIGINS entries must start with http:// or https:// (got %q)", origin)
+		}
+	}
+	if c.MaxAge < 0 {
+		return fmt.Errorf("CORS_MAX_AGE must not be negative")
+	}
+	return nil
+}
+
+// ストレージバックエンドの種類
+const (
+	StorageBackendPostgres = "postgres" // PostgreSQL / Supabase を使う（既定）
+	StorageBackendMemory   = "memory"   // インメモリ（オフライン開発用、再起動で消える）
+)
+
+// StorageConfig はリポジトリの保存先の設定です
+type StorageConfig struct {
+	Backend string
+}
+
+// LoggingConfig はログ出力の設定です
+type LoggingConfig struct {
+	Level     string // debug, info, warn, error
+	Format    string // json（Cloud Run 向け）または text
+	ProjectID string // ログを Cloud Trace と紐付ける GCP プロジェクト
+}
+
+// MetricsConfig は /metrics の公開設定です。
+// Addr を設定すると管理用ポートで公開し、Token を設定するとメインのポートでも Bearer トークン付きで公開します。
+type MetricsConfig struct {
+	Addr  string // 管理用ポートのアドレス（例: :9090）
+	Token string // /metrics に必要な Bearer トークン
+}
+
+// Enabled は /metrics をどこかで公開する設定かどうかを返します
+func (m MetricsConfig) Enabled() bool {
+	return m.Addr != "" || m.Token != ""
+}
+
+// AdminConfig は管理 API（/admin/...）の設定です。Token が空の場合は管理 API を公開しません。
+type AdminConfig struct {
+	Token string // 管理 API に必要な Bearer トークン
+}
+
+// MagicConfig は魔法の定義の設定です
+type MagicConfig struct {
+	RefreshInterval time.Duration // 他のインスタンスの変更を反映するためにキャッシュを読み直す間隔
+}
+
+// TracingConfig は OpenTelemetry のトレース設定です。
+// OTLP の送信先やサンプリングは OTEL_EXPORTER_OTLP_ENDPOINT などの標準の環境変数で設定します。
+type TracingConfig struct {
+	Exporter    string // none, otlp, stdout
+	ServiceName string
+}
+
+// レート制限のバックエンドの種類
+const (
+	RateLimitBackendMemory   = "memory"   // インスタンスごとに制限する（既定）
+	RateLimitBackendPostgres = "postgres" // rate_limit_buckets テーブルで全インスタンス共通に制限する
+	RateLimitBackendOff      = "off"      // 制限しない
+)
+
+// RateLimitConfig はレート制限の設定です。認証済みのリクエストはユーザー ID、それ以外は IP アドレスごとに数えます。
+type RateLimitConfig struct {
+	Backend        string
+	TrustedProxies int           // X-Forwarded-For を追加する信頼できるプロキシの段数（0 の場合は接続元のアドレスを使う）
+	Auth           RateLimitRule // サインアップ・サインイン・トークンリフレッシュ
+	Write          RateLimitRule // HP/MP の更新、対戦セッション・予約の作成と取り消し
+	Default        RateLimitRule // その他の API
+}
+
+// RateLimitRule は Period ごとに Limit 回までリクエストを受け付ける制限です
+type RateLimitRule struct {
+	Limit  int
+	Period time.Duration
+}
+
+// IdempotencyConfig は Idempotency-Key の設定です
+type IdempotencyConfig struct {
+	TTL         time.Duration // 最初のレスポンスを保存して再送に返す期間
+	LockTimeout time.Duration // 処理中のまま完了しないリクエストを中断されたとみなすまでの時間
+}
+
+// StageConfig はバトルステージ検索の設定です
+type StageConfig struct {
+	DefaultSearchRadius float64 // radius 未指定時の検索半径（メートル）
+	MaxSearchRadius     float64 // radius の上限（メートル）
+	DefaultPageSize     int     // limit 未指定時の件数
+	MaxPageSize         int     // limit の上限
+
+	ReservationSlot        time.Duration // 空き枠として提示する長さ
+	ReservationMaxDuration time.Duration // 1 回の予約の最大長
+	ReservationMaxAdvance  time.Duration // どれだけ先まで予約できるか
+	OccupancyEstimate      time.Duration // 進行中の対戦がステージを占有すると見込む時間
+}
+
+// BattleConfig は対戦中のジオフェンス判定と防御行動の設定です
+type BattleConfig struct {
+	DefaultArenaRadius  float64       // radius_m が未設定のステージで使うアリーナ半径（メートル）
+	GeofenceTolerance   float64       // GPS 誤差として許容する距離（メートル）
+	GeofenceGracePeriod time.Duration // 場外に出てから違反とみなすまでの猶予
+	GeofencePenaltyHP   int           // 違反 1 回あたりの HP ペナルティ
+	GeofenceMaxStrikes  int           // この回数の違反で失格（0 の場合は失格なし）
+
+	GuardMPCost    int           // ガードの MP コスト
+	GuardDuration  time.Duration // ガードが続く時間
+	GuardReduction float64       // ガード中に受けるダメージを減らす割合（0〜1）
+	ShieldMPCost   int           // シールドの MP コスト
+	ShieldDuration time.Duration // シールドが続く時間
+	ShieldAbsorb   int           // シールドが吸収できるダメージの合計
+
+	JoinTimeout       time.Duration // 相手が参加しないまま待機できる時間
+	DisconnectTimeout time.Duration // 接続がない参加者を失格にするまでの時間
+	IdleTimeout       time.Duration // メッセージを送らない参加者を失格にするまでの時間
+	FinishedRetention time.Duration // 終了したセッションをメモリに残す時間
+}
+
+// PlayerConfig はプレイヤーの HP の設定です
+type PlayerConfig struct {
+	HPRegenPerMinute int           // 戦闘外で 1 分あたりに自然回復する HP（0 の場合は回復しない）
+	HPRegenDelay     time.Duration // HP が変わってから自然回復が始まるまでの時間
 }
 
 // Load は環境変数から設定を読み込みます
 func Load() (*Config, error) {
 	config := &Config{
 		Server: ServerConfig{
-			Port: getEnv("PORT", "8080"),
-		},
-		Database: DatabaseConfig{
-			URL: func() string {
-				if url := getEnv("DATABASE_URL", ""); url != "" {
-					return url
-				}
-				return getEnv("SUPABASE_DB_URL", "")
-			}(),
+			Port:        getEnv("PORT", "8080"),
+			Environment: strings.ToLower(strings.TrimSpace(getEnv("APP_ENV", EnvironmentProduction))),
 		},
+		Database: loadDatabaseConfig(),
 		Auth: AuthConfig{
 			JWTSecret: getEnv("JWT_SECRET", ""),
 		},
 		CORS: CORSConfig{
-			AllowedOrigins: getEnvSlice("CORS_ALLOWED_ORIGINS", []string{"*"}),
+			AllowedOrigins:   getEnvSlice("CORS_ALLOWED_ORIGINS", []string{"*"}),
+			AllowedMethods:   getEnvSlice("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "DELETE"}),
+			AllowedHeaders:   getEnvSlice("CORS_ALLOWED_HEADERS", []string{"Content-Type", "Authorization", "X-Request-ID", "Idempotency-Key"}),
+			MaxAge:           getEnvDuration("CORS_MAX_AGE", 10*time.Minute),
+			AllowCredentials: getEnvBool("CORS_ALLOW_CREDENTIALS", false),
+		},
+		RateLimit: RateLimitConfig{
+			Backend:        strings.ToLower(getEnv("RATE_LIMIT_BACKEND", RateLimitBackendMemory)),
+			TrustedProxies: getEnvInt("RATE_LIMIT_TRUSTED_PROXIES", 0),
+			Auth:           getEnvRateLimit("RATE_LIMIT_AUTH", RateLimitRule{Limit: 10, Period: time.Minute}),
+			Write:          getEnvRateLimit("RATE_LIMIT_WRITE", RateLimitRule{Limit: 30, Period: time.Minute}),
+			Default:        getEnvRateLimit("RATE_LIMIT_DEFAULT", RateLimitRule{Limit: 120, Period: time.Minute}),
+		},
+		Idempotency: IdempotencyConfig{
+			TTL:         getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+			LockTimeout: getEnvDuration("IDEMPOTENCY_LOCK_TIMEOUT", time.Minute),
+		},
+		Stage: StageConfig{
+			DefaultSearchRadius: getEnvFloat("STAGE_SEARCH_RADIUS_M", 1000),
+			MaxSearchRadius:     getEnvFloat("STAGE_SEARCH_MAX_RADIUS_M", 10000),
+			DefaultPageSize:     getEnvInt("STAGE_SEARCH_PAGE_SIZE", 20),
+			MaxPageSize:         getEnvInt("STAGE_SEARCH_MAX_PAGE_SIZE", 100),
+
+			ReservationSlot:        getEnvDuration("STAGE_RESERVATION_SLOT", 30*time.Minute),
+			ReservationMaxDuration: getEnvDuration("STAGE_RESERVATION_MAX_DURATION", 2*time.Hour),
+			ReservationMaxAdvance:  getEnvDuration("STAGE_RESERVATION_MAX_ADVANCE", 7*24*time.Hour),
+			OccupancyEstimate:      getEnvDuration("STAGE_OCCUPANCY_ESTIMATE", 15*time.Minute),
+		},
+		Battle: loadBattleConfig(),
+		Player: PlayerConfig{
+			HPRegenPerMinute: getEnvInt("HP_REGEN_PER_MINUTE", 6),
+			HPRegenDelay:     getEnvDuration("HP_REGEN_DELAY", 30*time.Second),
+		},
+		Storage: StorageConfig{
+			Backend: strings.ToLower(strings.TrimSpace(getEnv("STORAGE_BACKEND", StorageBackendPostgres))),
+		},
+		Logging: loadLoggingConfig(),
+		Metrics: MetricsConfig{
+			Addr:  getEnv("METRICS_ADDR", ""),
+			Token: getEnv("METRICS_TOKEN", ""),
+		},
+		Admin: AdminConfig{
+			Token: getEnv("ADMIN_TOKEN", ""),
+		},
+		Magic: MagicConfig{
+			RefreshInterval: getEnvDuration("MAGIC_TYPES_REFRESH_INTERVAL", 30*time.Second),
+		},
+		Tracing: TracingConfig{
+			Exporter: func() string {
+				exporter := strings.ToLower(strings.TrimSpace(getEnv("OTEL_TRACES_EXPORTER", "none")))
+				if exporter == "console" {
+					// OpenTelemetry の仕様上の名前も受け付ける
+					return "stdout"
+				}
+				return exporter
+			}(),
+			ServiceName: getEnv("OTEL_SERVICE_NAME", "os2516-server"),
 		},
 	}
 
@@ -64,15 +280,223 @@ func Load() (*Config, error) {
 	return config, nil
 }
 
+// LoadDatabase はデータベース設定のみを読み込みます（マイグレーションなど JWT_SECRET が不要な用途向け）
+func LoadDatabase() (*DatabaseConfig, error) {
+	database := loadDatabaseConfig()
+	if err := database.validate(); err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
+	return &database, nil
+}
+
+// LoadLogging はログ設定のみを読み込みます（他の設定より先にロガーを初期化するため）
+func LoadLogging() (*LoggingConfig, error) {
+	logging := loadLoggingConfig()
+	if err := logging.validate(); err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
+	return &logging, nil
+}
+
+// LoadBattle は対戦の設定のみを読み込みます（シミュレーターでサーバーと同じルールを使うため）
+func LoadBattle() (*BattleConfig, error) {
+	battle := loadBattleConfig()
+	if err := battle.validate(); err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
+	return &battle, nil
+}
+
+func loadBattleConfig() BattleConfig {
+	return BattleConfig{
+		DefaultArenaRadius:  getEnvFloat("BATTLE_DEFAULT_ARENA_RADIUS_M", 50),
+		GeofenceTolerance:   getEnvFloat("BATTLE_GEOFENCE_TOLERANCE_M", 10),
+		GeofenceGracePeriod: getEnvDuration("BATTLE_GEOFENCE_GRACE_PERIOD", 10*time.Second),
+		GeofencePenaltyHP:   getEnvInt("BATTLE_GEOFENCE_PENALTY_HP", 10),
+		GeofenceMaxStrikes:  getEnvInt("BATTLE_GEOFENCE_MAX_STRIKES", 3),
+
+		GuardMPCost:    getEnvInt("BATTLE_GUARD_MP_COST", 20),
+		GuardDuration:  getEnvDuration("BATTLE_GUARD_DURATION", 3*time.Second),
+		GuardReduction: getEnvFloat("BATTLE_GUARD_REDUCTION", 0.5),
+		ShieldMPCost:   getEnvInt("BATTLE_SHIELD_MP_COST", 30),
+		ShieldDuration: getEnvDuration("BATTLE_SHIELD_DURATION", 5*time.Second),
+		ShieldAbsorb:   getEnvInt("BATTLE_SHIELD_ABSORB", 40),
+
+		JoinTimeout:       getEnvDuration("BATTLE_JOIN_TIMEOUT", 5*time.Minute),
+		DisconnectTimeout: getEnvDuration("BATTLE_DISCONNECT_TIMEOUT", 30*time.Second),
+		IdleTimeout:       getEnvDuration("BATTLE_IDLE_TIMEOUT", 2*time.Minute),
+		FinishedRetention: getEnvDuration("BATTLE_FINISHED_RETENTION", time.Minute),
+	}
+}
+
+func (b BattleConfig) validate() error {
+	if b.DefaultArenaRadius <= 0 {
+		return fmt.Errorf("BATTLE_DEFAULT_ARENA_RADIUS_M must be positive")
+	}
+	if b.GeofenceTolerance < 0 || b.GeofenceGracePeriod < 0 ||
+		b.GeofencePenaltyHP < 0 || b.GeofenceMaxStrikes < 0 {
+		return fmt.Errorf("battle geofence settings must not be negative")
+	}
+	if b.GuardMPCost < 0 || b.ShieldMPCost < 0 || b.ShieldAbsorb < 0 {
+		return fmt.Errorf("battle guard and shield settings must not be negative")
+	}
+	if b.GuardDuration <= 0 || b.ShieldDuration <= 0 {
+		return fmt.Errorf("BATTLE_GUARD_DURATION and BATTLE_SHIELD_DURATION must be positive")
+	}
+	if b.GuardReduction < 0 || b.GuardReduction > 1 {
+		return fmt.Errorf("BATTLE_GUARD_REDUCTION must be between 0 and 1")
+	}
+	if b.JoinTimeout <= 0 || b.DisconnectTimeout <= 0 || b.IdleTimeout <= 0 || b.FinishedRetention < 0 {
+		return fmt.Errorf("BATTLE_JOIN_TIMEOUT, BATTLE_DISCONNECT_TIMEOUT and BATTLE_IDLE_TIMEOUT must be positive, BATTLE_FINISHED_RETENTION must not be negative")
+	}
+	return nil
+}
+
+func loadLoggingConfig() LoggingConfig {
+	return LoggingConfig{
+		Level:     strings.ToLower(strings.TrimSpace(getEnv("LOG_LEVEL", "info"))),
+		Format:    strings.ToLower(strings.TrimSpace(getEnv("LOG_FORMAT", "json"))),
+		ProjectID: getEnv("GOOGLE_CLOUD_PROJECT", ""),
+	}
+}
+
+func (l LoggingConfig) validate() error {
+	switch l.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		return fmt.Errorf("LOG_LEVEL must be one of debug, info, warn, error")
+	}
+	switch l.Format {
+	case "json", "text":
+	default:
+		return fmt.Errorf("LOG_FORMAT must be %q or %q", "json", "text")
+	}
+	return nil
+}
+
+func loadDatabaseConfig() DatabaseConfig {
+	return DatabaseConfig{
+		URL: func() string {
+			if url := getEnv("DATABASE_URL", ""); url != "" {
+				return url
+			}
+			return getEnv("SUPABASE_DB_URL", "")
+		}(),
+		MaxConns:         getEnvInt("DB_MAX_CONNS", 10),
+		MinConns:         getEnvInt("DB_MIN_CONNS", 0),
+		MaxConnLifetime:  getEnvDuration("DB_MAX_CONN_LIFETIME", 30*time.Minute),
+		MaxConnIdleTime:  getEnvDuration("DB_MAX_CONN_IDLE_TIME", 5*time.Minute),
+		StatementTimeout: getEnvDuration("DB_STATEMENT_TIMEOUT", 5*time.Second),
+		ConnectTimeout:   getEnvDuration("DB_CONNECT_TIMEOUT", 5*time.Second),
+		AutoMigrate:      getEnvBool("DB_AUTO_MIGRATE", false),
+		SlowQuery:        getEnvDuration("DB_SLOW_QUERY_THRESHOLD", 500*time.Millisecond),
+	}
+}
+
+func (d DatabaseConfig) validate() error {
+	if d.MaxConns <= 0 || d.MinConns < 0 || d.MinConns > d.MaxConns {
+		return fmt.Errorf("DB_MIN_CONNS must be between 0 and DB_MAX_CONNS")
+	}
+	if d.StatementTimeout < 0 || d.ConnectTimeout < 0 || d.SlowQuery < 0 ||
+		d.MaxConnLifetime < 0 || d.MaxConnIdleTime < 0 {
+		return fmt.Errorf("database timeouts must not be negative")
+	}
+	return nil
+}
+
 // Validate は設定の妥当性を検証します
 func (c *Config) Validate() error {
 	if c.Auth.JWTSecret == "" {
 		return fmt.Errorf("JWT_SECRET is required")
 	}
 
+	if err := c.Database.validate(); err != nil {
+		return err
+	}
+	if err := c.Logging.validate(); err != nil {
+		return err
+	}
+	if err := c.CORS.validate(); err != nil {
+		return err
+	}
+	switch c.Tracing.Exporter {
+	case "none", "otlp", "stdout":
+	default:
+		return fmt.Errorf("OTEL_TRACES_EXPORTER must be one of none, otlp, stdout")
+	}
+
+	if c.Stage.DefaultSearchRadius <= 0 || c.Stage.MaxSearchRadius <= 0 {
+		return fmt.Errorf("stage search radius must be positive")
+	}
+	if c.Stage.DefaultSearchRadius > c.Stage.MaxSearchRadius {
+		return fmt.Errorf("STAGE_SEARCH_RADIUS_M must not exceed STAGE_SEARCH_MAX_RADIUS_M")
+	}
+	if c.Stage.DefaultPageSize <= 0 || c.Stage.DefaultPageSize > c.Stage.MaxPageSize {
+		return fmt.Errorf("STAGE_SEARCH_PAGE_SIZE must be between 1 and STAGE_SEARCH_MAX_PAGE_SIZE")
+	}
+
+	if c.Stage.ReservationSlot <= 0 || c.Stage.ReservationMaxDuration <= 0 ||
+		c.Stage.ReservationMaxAdvance <= 0 || c.Stage.OccupancyEstimate <= 0 {
+		return fmt.Errorf("stage reservation durations must be positive")
+	}
+
+	if err := c.Battle.validate(); err != nil {
+		return err
+	}
+
+	if c.Player.HPRegenPerMinute < 0 || c.Player.HPRegenDelay < 0 {
+		return fmt.Errorf("HP_REGEN_PER_MINUTE and HP_REGEN_DELAY must not be negative")
+	}
+
+	switch c.RateLimit.Backend {
+	case RateLimitBackendMemory, RateLimitBackendPostgres, RateLimitBackendOff:
+	default:
+		return fmt.Errorf("RATE_LIMIT_BACKEND must be one of %s, %s, %s", RateLimitBackendMemory, RateLimitBackendPostgres, RateLimitBackendOff)
+	}
+	if c.RateLimit.TrustedProxies < 0 {
+		return fmt.Errorf("RATE_LIMIT_TRUSTED_PROXIES must not be negative")
+	}
+	if c.RateLimit.Backend == RateLimitBackendPostgres && c.UsesMemoryStorage() {
+		return fmt.Errorf("RATE_LIMIT_BACKEND=postgres cannot be used with STORAGE_BACKEND=memory")
+	}
+
+	if c.Idempotency.TTL <= 0 || c.Idempotency.LockTimeout <= 0 {
+		return fmt.Errorf("IDEMPOTENCY_TTL and IDEMPOTENCY_LOCK_TIMEOUT must be positive")
+	}
+
+	if c.Magic.RefreshInterval <= 0 {
+		return fmt.Errorf("MAGIC_TYPES_REFRESH_INTERVAL must be positive")
+	}
+
+	switch c.Storage.Backend {
+	case StorageBackendPostgres, StorageBackendMemory:
+	default:
+		return fmt.Errorf("STORAGE_BACKEND must be %q or %q", StorageBackendPostgres, StorageBackendMemory)
+	}
+
+	switch c.Server.Environment {
+	case EnvironmentProduction, EnvironmentDevelopment:
+	default:
+		return fmt.Errorf("APP_ENV must be %q or %q", EnvironmentProduction, EnvironmentDevelopment)
+	}
+	// インメモリストアには既知のパスワードのシードユーザーが入るため、開発環境以外では使わせない
+	if c.UsesMemoryStorage() && !c.IsDevelopment() {
+		return fmt.Errorf("STORAGE_BACKEND=memory requires APP_ENV=development")
+	}
+
 	return nil
 }
 
+// IsDevelopment はローカル開発環境かどうかを返します
+func (c *Config) IsDevelopment() bool {
+	return c.Server.Environment == EnvironmentDevelopment
+}
+
+// UsesMemoryStorage はインメモリのリポジトリを使う設定かどうかを返します
+func (c *Config) UsesMemoryStorage() bool {
+	return c.Storage.Backend == StorageBackendMemory
+}
+
 // getEnv は環境変数を取得し、デフォルト値を返します
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -81,12 +505,82 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
-// getEnvSlice は環境変数をカンマ区切りのスライスとして取得します
+// getEnvSlice は環境変数をカンマ区切りのスライスとして取得します（各要素の前後の空白と空の要素は除く）
 func getEnvSlice(key string, defaultValue []string) []string {
-	if value := os.Getenv(key); value != "" {
-		return strings.Split(value, ",")
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
 	}
-	return defaultValue
+	if len(values) == 0 {
+		return defaultValue
+	}
+	return values
+}
+
+// getEnvRateLimit は "回数/期間"（例: 30/1m）形式の環境変数を取得します
+func getEnvRateLimit(key string, defaultValue RateLimitRule) RateLimitRule {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return defaultValue
+	}
+
+	limitPart, periodPart, ok := strings.Cut(value, "/")
+	if !ok {
+		return defaultValue
+	}
+	limit, err := strconv.Atoi(strings.TrimSpace(limitPart))
+	if err != nil || limit <= 0 {
+		return defaultValue
+	}
+	period, err := time.ParseDuration(strings.TrimSpace(periodPart))
+	if err != nil || period <= 0 {
+		return defaultValue
+	}
+	return RateLimitRule{Limit: limit, Period: period}
+}
+
+// getEnvFloat は環境変数を float64 として取得します
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return defaultValue
+	}
+	return parsed
+}
+
+// getEnvInt は環境変数を int として取得します
+func getEnvInt(key string, defaultValue int) int {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return defaultValue
+	}
+	return parsed
+}
+
+// getEnvDuration は環境変数を time.Duration（例: 10s, 1m）として取得します
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return defaultValue
+	}
+	return parsed
 }
 
 // getEnvBool は環境変数を bool として取得します
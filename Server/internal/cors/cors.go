@@ -0,0 +1,180 @@
+// Package cors はブラウザからのクロスオリジンリクエストを許可するミドルウェアです。
+//
+// 許可したオリジンのリクエストにのみ Access-Control-* ヘッダーを付け、
+// プリフライト（OPTIONS）はオリジン・メソッド・ヘッダーがすべて許可されている場合だけ 204 で応答します。
+package cors
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"server/internal/apierror"
+)
+
+// Options は CORS の設定です
+type Options struct {
+	AllowedOrigins   []string // "https://app.example.com"、"https://*.example.com"（サブドメイン）、"*"
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	MaxAge           time.Duration
+	AllowCredentials bool // "*" で許可したオリジンには付けない
+}
+
+// CORS は設定を解析済みのミドルウェアです
+type CORS struct {
+	anyOrigin        bool
+	origins          map[string]bool
+	wildcards        []wildcardOrigin
+	methods          []string
+	headers          map[string]bool
+	allowMethods     string
+	allowHeaders     string
+	exposeHeaders    string
+	maxAge           string
+	allowCredentials bool
+}
+
+// wildcardOrigin は "https://*.example.com" のようなサブドメインのパターンです
+type wildcardOrigin struct {
+	scheme string
+	suffix string // ".example.com" またはポート付きの ".example.com:8443"
+}
+
+// New は CORS を作成します。解釈できないオリジンのパターンはどのオリジンにも一致しません。
+func New(opts Options) *CORS {
+	c := &CORS{
+		origins:          map[string]bool{},
+		headers:          map[string]bool{},
+		allowCredentials: opts.AllowCredentials,
+	}
+
+	for _, origin := range opts.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+		switch {
+		case origin == "*":
+			c.anyOrigin = true
+		case strings.Contains(origin, "://*."):
+			scheme, host, _ := strings.Cut(origin, "://*.")
+			c.wildcards = append(c.wildcards, wildcardOrigin{scheme: scheme, suffix: "." + strings.TrimSuffix(host, "/")})
+		case origin != "":
+			c.origins[strings.TrimSuffix(origin, "/")] = true
+		}
+	}
+
+	for _, method := range opts.AllowedMethods {
+		c.methods = append(c.methods, strings.ToUpper(strings.TrimSpace(method)))
+	}
+	for _, header := range opts.AllowedHeaders {
+		c.headers[http.CanonicalHeaderKey(strings.TrimSpace(header))] = true
+	}
+
+	c.allowMethods = strings.Join(c.methods, ", ")
+	c.allowHeaders = strings.Join(opts.AllowedHeaders, ", ")
+	c.exposeHeaders = strings.Join(opts.ExposedHeaders, ", ")
+	if opts.MaxAge > 0 {
+		c.maxAge = strconv.Itoa(int(opts.MaxAge.Seconds()))
+	}
+	return c
+}
+
+// OriginAllowed は origin が許可されたオリジンかどうかを返します
+func (c *CORS) OriginAllowed(origin string) bool {
+	if origin == "" {
+		return false
+	}
+	if c.anyOrigin {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+	if c.origins[origin] {
+		return true
+	}
+	if len(c.wildcards) == 0 {
+		return false
+	}
+
+	parsed, err := url.Parse(origin)
+	if err != nil || parsed.Host == "" {
+		return false
+	}
+	for _, wildcard := range c.wildcards {
+		// "https://*.example.com" は "https://a.example.com" に一致し、"https://example.com" や "https://evil-example.com" には一致しない
+		if parsed.Scheme == wildcard.scheme && strings.HasSuffix(parsed.Host, wildcard.suffix) && len(parsed.Host) > len(wildcard.suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Middleware は CORS のヘッダーを付け、プリフライトリクエストに応答します
+func (c *CORS) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		header := w.Header()
+		// レスポンスはオリジンによって変わるため、共有キャッシュが別のオリジンに使い回さないようにする
+		header.Add("Vary", "Origin")
+
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if preflight {
+			header.Add("Vary", "Access-Control-Request-Method")
+			header.Add("Vary", "Access-Control-Request-Headers")
+			c.preflight(w, r, origin)
+			return
+		}
+
+		if c.OriginAllowed(origin) {
+			c.setAllowOrigin(header, origin)
+			if c.exposeHeaders != "" {
+				header.Set("Access-Control-Expose-Headers", c.exposeHeaders)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (c *CORS) preflight(w http.ResponseWriter, r *http.Request, origin string) {
+	if !c.OriginAllowed(origin) {
+		apierror.Write(w, r, apierror.New(apierror.CodeForbidden, "origin is not allowed"))
+		return
+	}
+	if method := r.Header.Get("Access-Control-Request-Method"); !slices.Contains(c.methods, strings.ToUpper(method)) {
+		apierror.Write(w, r, apierror.New(apierror.CodeForbidden, "method is not allowed for cross-origin requests"))
+		return
+	}
+	for _, requested := range strings.Split(r.Header.Get("Access-Control-Request-Headers"), ",") {
+		requested = strings.TrimSpace(requested)
+		if requested != "" && !c.headers[http.CanonicalHeaderKey(requested)] {
+			apierror.Write(w, r, apierror.New(apierror.CodeForbidden, "header "+requested+" is not allowed for cross-origin requests"))
+			return
+		}
+	}
+
+	header := w.Header()
+	c.setAllowOrigin(header, origin)
+	header.Set("Access-Control-Allow-Methods", c.allowMethods)
+	if c.allowHeaders != "" {
+		header.Set("Access-Control-Allow-Headers", c.allowHeaders)
+	}
+	if c.maxAge != "" {
+		header.Set("Access-Control-Max-Age", c.maxAge)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *CORS) setAllowOrigin(header http.Header, origin string) {
+	if c.anyOrigin {
+		// "*" で許可した場合は資格情報を許可しない（任意のサイトからログイン中のユーザーとしてリクエストできてしまうため）
+		header.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	header.Set("Access-Control-Allow-Origin", origin)
+	if c.allowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
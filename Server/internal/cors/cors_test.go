@@ -0,0 +1,133 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+func newPolicy(origins ...string) *CORS {
+	return New(Options{
+		AllowedOrigins:   origins,
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization", "X-Request-ID"},
+		MaxAge:           10 * time.Minute,
+		AllowCredentials: true,
+	})
+}
+
+func TestOriginAllowed(t *testing.T) {
+	policy := newPolicy("https://app.example.com", "https://*.example.org", "http://*.localhost:3000")
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://app.example.com", true},
+		{"https://APP.example.com", true},
+		{"http://app.example.com", false},
+		{"https://evil.example.com", false},
+		{"https://a.example.org", true},
+		{"https://a.b.example.org", true},
+		{"https://example.org", false},
+		{"https://evil-example.org", false},
+		{"https://a.example.org.evil.com", false},
+		{"http://a.example.org", false},
+		{"http://web.localhost:3000", true},
+		{"http://web.localhost:4000", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := policy.OriginAllowed(tt.origin); got != tt.want {
+			t.Errorf("OriginAllowed(%q) = %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+}
+
+func TestMiddleware_SimpleRequest(t *testing.T) {
+	handler := newPolicy("https://app.example.com").Middleware(okHandler)
+
+	req := httptest.NewRequest(http.MethodGet, "/game", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Fatalf("Allow-Origin = %q", got)
+	}
+	if rec.Header().Get("Access-Control-Allow-Credentials") != "true" {
+		t.Fatal("credentials should be allowed for a listed origin")
+	}
+	if rec.Header().Get("Vary") != "Origin" {
+		t.Fatalf("Vary = %q, want Origin", rec.Header().Get("Vary"))
+	}
+
+	req.Header.Set("Origin", "https://evil.example.com")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("disallowed origin: status = %d, Allow-Origin = %q", rec.Code, rec.Header().Get("Access-Control-Allow-Origin"))
+	}
+}
+
+func TestMiddleware_AnyOriginNeverAllowsCredentials(t *testing.T) {
+	handler := New(Options{AllowedOrigins: []string{"*"}, AllowCredentials: true}).Middleware(okHandler)
+
+	req := httptest.NewRequest(http.MethodGet, "/game", nil)
+	req.Header.Set("Origin", "https://anywhere.example")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Fatalf("Allow-Origin = %q, want *", rec.Header().Get("Access-Control-Allow-Origin"))
+	}
+	if rec.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Fatal("credentials must not be allowed with *")
+	}
+}
+
+func TestMiddleware_Preflight(t *testing.T) {
+	handler := newPolicy("https://*.example.com").Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("preflight must not reach the next handler")
+	}))
+
+	tests := []struct {
+		name       string
+		origin     string
+		method     string
+		headers    string
+		wantStatus int
+	}{
+		{"allowed", "https://app.example.com", "POST", "content-type, authorization", http.StatusNoContent},
+		{"origin", "https://app.example.net", "POST", "", http.StatusForbidden},
+		{"method", "https://app.example.com", "DELETE", "", http.StatusForbidden},
+		{"header", "https://app.example.com", "POST", "X-Debug", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodOptions, "/api/battles", nil)
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Access-Control-Request-Method", tt.method)
+			if tt.headers != "" {
+				req.Header.Set("Access-Control-Request-Headers", tt.headers)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			allowOrigin := rec.Header().Get("Access-Control-Allow-Origin")
+			if tt.wantStatus == http.StatusNoContent {
+				if allowOrigin != tt.origin || rec.Header().Get("Access-Control-Max-Age") != "600" {
+					t.Fatalf("Allow-Origin = %q, Max-Age = %q", allowOrigin, rec.Header().Get("Access-Control-Max-Age"))
+				}
+			} else if allowOrigin != "" {
+				t.Fatalf("rejected preflight must not set Allow-Origin (got %q)", allowOrigin)
+			}
+		})
+	}
+}
@@ -1,33 +1,45 @@
+// Package data はバイナリに埋め込む初期データです。
 package data
 
 import (
-    "encoding/json"
-    "os"
+	_ "embed"
+	"encoding/json"
+	"fmt"
 )
 
+// magicTypesJSON は magic_types テーブルの初期データです（ローカルと本番で同じ定義を使う）
+//
+//go:embed magic_types.json
+var magicTypesJSON []byte
+
+// MagicType は初期データの魔法です
 type MagicType struct {
-    ID          string `json:"id"`
-    Name        string `json:"name"`
-    MPCost      int    `json:"mp_cost"`
-    Description string `json:"description"`
-    Damage      int    `json:"damage"`
-    Sound       string `json:"sound"`
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Kind        string `json:"kind"`
+	Target      string `json:"target"`
+	MPCost      int    `json:"mp_cost"`
+	Description string `json:"description"`
+	Damage      int    `json:"damage"`
+	Heal        int    `json:"heal"`
+	Sound       string `json:"sound"`
+	Chant       string `json:"chant"`
+	ChantMatch  string `json:"chant_match"`
+	CooldownMS  int    `json:"cooldown_ms"`
+	CastTimeMS  int    `json:"cast_time_ms"`
+	StunMS      int    `json:"stun_ms"`
 }
 
+// MagicTypeList は magic_types.json の形式です
 type MagicTypeList struct {
-    MagicTypes []MagicType `json:"magic_types"`
+	MagicTypes []MagicType `json:"magic_types"`
 }
 
-func LoadMagicTypes(path string) (*MagicTypeList, error) {
-    file, err := os.Open(path)
-    if err != nil {
-        return nil, err
-    }
-    defer file.Close()
-
-    var list MagicTypeList
-    if err := json.NewDecoder(file).Decode(&list); err != nil {
-        return nil, err
-    }
-    return &list, nil
+// DefaultMagicTypes は埋め込みの魔法の初期データを返します
+func DefaultMagicTypes() (*MagicTypeList, error) {
+	var list MagicTypeList
+	if err := json.Unmarshal(magicTypesJSON, &list); err != nil {
+		return nil, fmt.Errorf("failed to decode magic types: %w", err)
+	}
+	return &list, nil
 }
@@ -0,0 +1,93 @@
+package battlestage
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrReservationConflict は予約枠が既存の予約や進行中の対戦と重なる場合のエラーです
+	ErrReservationConflict = errors.New("reservation conflicts with an existing booking")
+	// ErrReservationNotFound は指定した予約が存在しない場合のエラーです
+	ErrReservationNotFound = errors.New("reservation not found")
+	// ErrStageReserved は他のユーザーが予約している時間にステージで対戦を始めようとした場合のエラーです
+	ErrStageReserved = errors.New("stage is reserved by another user")
+)
+
+// Reservation はステージの時間枠予約です。枠は [StartsAt, EndsAt) の半開区間です。
+type Reservation struct {
+	ID        uuid.UUID
+	StageID   string
+	UserID    uuid.UUID
+	StartsAt  time.Time
+	EndsAt    time.Time
+	CreatedAt time.Time
+}
+
+// Overlaps は [start, end) と予約枠が重なるかを返します
+func (r Reservation) Overlaps(start, end time.Time) bool {
+	return r.StartsAt.Before(end) && start.Before(r.EndsAt)
+}
+
+// Occupancy はステージ上で進行中（待機中を含む）の対戦セッションです
+type Occupancy struct {
+	SessionID string
+	Status    string
+	StartedAt *time.Time
+	CreatedAt time.Time
+}
+
+// TimeSlot は時間枠です
+type TimeSlot struct {
+	StartsAt time.Time
+	EndsAt   time.Time
+}
+
+// Availability はステージの現在の空き状況です
+type Availability struct {
+	Occupants    []Occupancy
+	Reservations []Reservation // 検索範囲内の予約（開始時刻順）
+	Available    bool          // 現在対戦も予約もなく、すぐに使えるか
+	NextFreeSlot TimeSlot
+}
+
+// ReservationRepository はステージ予約の永続化を抽象化します
+type ReservationRepository interface {
+	// CreateReservation は予約を作成します。同じステージで枠が重なる場合は ErrReservationConflict を返します。
+	CreateReservation(ctx context.Context, reservation *Reservation) error
+	// ListReservations は指定ステージの [from, to) と重なる予約を開始時刻順に返します
+	ListReservations(ctx context.Context, stageIDs []string, from, to time.Time) ([]Reservation, error)
+	// DeleteReservation は userID が所有する予約を削除します
+	DeleteReservation(ctx context.Context, id uuid.UUID, userID uuid.UUID) error
+}
+
+// NextFreeSlot は from 以降で長さ duration の枠が予約と重ならない最初の時間枠を返します。
+// busyUntil より前は埋まっているものとして扱います。
+func NextFreeSlot(reservations []Reservation, from, busyUntil time.Time, duration time.Duration) TimeSlot {
+	sorted := make([]Reservation, len(reservations))
+	copy(sorted, reservations)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].StartsAt.Before(sorted[j].StartsAt)
+	})
+
+	start := from
+	if busyUntil.After(start) {
+		start = busyUntil
+	}
+
+	for _, reservation := range sorted {
+		if !reservation.Overlaps(start, start.Add(duration)) {
+			if !reservation.StartsAt.Before(start) {
+				break
+			}
+			continue
+		}
+		start = reservation.EndsAt
+	}
+
+	return TimeSlot{StartsAt: start, EndsAt: start.Add(duration)}
+}
@@ -1,6 +1,10 @@
 package battlestage
 
-import "context"
+import (
+	"context"
+	"errors"
+	"math"
+)
 
 // Location は地理座標を表現します。
 type Location struct {
@@ -15,6 +19,7 @@ type Stage struct {
 	Location     Location
 	RadiusMeters *float64
 	Description  *string
+	InBattle     bool // 終了していない対戦セッションがあるか（リポジトリは設定せず、検索のユースケースが対戦 Hub から設定する）
 }
 
 // StageWithDistance は検索地点からの距離を付与したステージ情報です。
@@ -23,7 +28,41 @@ type StageWithDistance struct {
 	DistanceMeters float64
 }
 
+// Cursor は距離順ページングの再開位置です。
+// (DistanceMeters, StageID) の組より後ろの行から取得を再開します。
+type Cursor struct {
+	DistanceMeters float64
+	StageID        string
+}
+
+// NearbyQuery は周辺ステージ検索の条件です。
+type NearbyQuery struct {
+	Origin       Location
+	RadiusMeters float64
+	Limit        int
+	After        *Cursor // nil の場合は先頭から取得
+}
+
 // Repository はステージ情報の取得を抽象化します。
 type Repository interface {
-	FindNearby(ctx context.Context, origin Location, radiusMeters float64) ([]StageWithDistance, error)
+	FindNearby(ctx context.Context, query NearbyQuery) ([]StageWithDistance, error)
+	FindByID(ctx context.Context, id string) (*Stage, error)
+}
+
+// ErrStageNotFound は指定 ID のステージが存在しない場合のエラーです。
+var ErrStageNotFound = errors.New("battle stage not found")
+
+// EarthRadiusMeters はハーサイン式で使用する地球半径です（検索 SQL と同じ値）。
+const EarthRadiusMeters = 6371000.0
+
+// DistanceMeters は 2 地点間の大円距離をハーサイン式で求めます。
+func DistanceMeters(a, b Location) float64 {
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	dLat := lat2 - lat1
+	dLng := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * EarthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(h)))
 }
@@ -0,0 +1,24 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdempotencyRecord は Idempotency-Key ごとに保存する最初のリクエストとそのレスポンスです
+type IdempotencyRecord struct {
+	UserID      uuid.UUID
+	Key         string
+	Fingerprint string              // メソッド・パス・ボディのハッシュ（同じキーで別のリクエストが来たことの検出に使う）
+	StatusCode  int                 // 0 の場合は最初のリクエストを処理中
+	Header      map[string][]string // 再送時に返すレスポンスヘッダー
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// Completed はレスポンスが保存済みかどうかを返します
+func (r *IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
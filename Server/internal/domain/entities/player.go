@@ -6,30 +6,60 @@ import (
 	"github.com/google/uuid"
 )
 
+// HP の範囲
+const (
+	DefaultMaxHP = 100  // 新規プレイヤーの最大HP
+	MaxHPLimit   = 1000 // 最大HPの上限
+)
+
 // Player はゲーム内のプレイヤーを表すエンティティです
 type Player struct {
 	ID          uuid.UUID  `json:"id" db:"id"`
-	UserID      *uuid.UUID `json:"user_id" db:"user_id"`           // ユーザーID（ゲストはNULL可）
-	DisplayName string     `json:"display_name" db:"display_name"` // 表示名
-	HP          int        `json:"hp" db:"hp"`                     // ヒットポイント
-	MP          int        `json:"mp" db:"mp"`                     // マジックポイント
-	Rank        int        `json:"rank" db:"rank"`                 // レーティング
-	AvatarURL   *string    `json:"avatar_url" db:"avatar_url"`     // アバター画像URL
+	UserID      *uuid.UUID `json:"user_id" db:"user_id"`             // ユーザーID（ゲストはNULL可）
+	DisplayName string     `json:"display_name" db:"display_name"`   // 表示名
+	HP          int        `json:"hp" db:"hp"`                       // ヒットポイント
+	MaxHP       int        `json:"max_hp" db:"max_hp"`               // 最大HP
+	HPUpdatedAt time.Time  `json:"hp_updated_at" db:"hp_updated_at"` // 最後にHPが変わった時刻（自然回復の起点）
+	MP          int        `json:"mp" db:"mp"`                       // マジックポイント
+	Rank        int        `json:"rank" db:"rank"`                   // レーティング
+	AvatarURL   *string    `json:"avatar_url" db:"avatar_url"`       // アバター画像URL
 	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
 }
 
+// HPRegen は戦闘外の HP 自然回復の設定です
+type HPRegen struct {
+	PerMinute int           // 1 分あたりの回復量（0 で回復しない）
+	Delay     time.Duration // HP が変わってから回復が始まるまでの時間
+}
+
+// CurrentHP は最後に HP が変わってから now までの自然回復を加えた HP を返します。最大HPは超えません。
+func (p *Player) CurrentHP(now time.Time, regen HPRegen) int {
+	if regen.PerMinute <= 0 || p.HP >= p.MaxHP {
+		return p.HP
+	}
+	elapsed := now.Sub(p.HPUpdatedAt) - regen.Delay
+	if elapsed <= 0 {
+		return p.HP
+	}
+	regenerated := int64(elapsed) * int64(regen.PerMinute) / int64(time.Minute)
+	return int(min(int64(p.MaxHP), int64(p.HP)+regenerated))
+}
+
 // NewPlayer は新しいプレイヤーを作成します
 func NewPlayer(userID *uuid.UUID, displayName string) *Player {
+	now := time.Now()
 	return &Player{
 		ID:          uuid.New(),
 		UserID:      userID,
 		DisplayName: displayName,
-		HP:          100, // デフォルトHP
+		HP:          DefaultMaxHP, // デフォルトHP
+		MaxHP:       DefaultMaxHP,
+		HPUpdatedAt: now,
 		MP:          100, // デフォルトMP
 		Rank:        0,   // デフォルトランク
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		CreatedAt:   now,
+		UpdatedAt:   now,
 	}
 }
 
@@ -39,10 +69,11 @@ func (p *Player) UpdateDisplayName(displayName string) {
 	p.UpdatedAt = time.Now()
 }
 
-// UpdateHP はHPを更新します
+// UpdateHP はHPを更新し、自然回復の起点をリセットします
 func (p *Player) UpdateHP(hp int) {
 	p.HP = hp
-	p.UpdatedAt = time.Now()
+	p.HPUpdatedAt = time.Now()
+	p.UpdatedAt = p.HPUpdatedAt
 }
 
 // UpdateMP はMPを更新します
@@ -1,11 +1,15 @@
 package entities
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// ErrUserExists は同じメールアドレスのユーザーがすでに存在する場合のエラーです
+var ErrUserExists = errors.New("user already exists")
+
 // User はアプリケーションのユーザーを表すエンティティです
 type User struct {
 	ID           uuid.UUID `json:"id" db:"id"`
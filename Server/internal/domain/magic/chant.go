@@ -0,0 +1,154 @@
+package magic
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ChantMatch は詠唱の照合方法です
+type ChantMatch string
+
+const (
+	ChantExact   ChantMatch = "exact"   // 正規化した詠唱が完全に一致した場合のみ成功
+	ChantFuzzy   ChantMatch = "fuzzy"   // 編集距離から正確さを求める（音声認識の揺れを許容）
+	ChantKeyword ChantMatch = "keyword" // 詠唱を句読点・空白で区切った語句がいくつ含まれるか
+)
+
+// Valid は定義済みの照合方法かを返します
+func (m ChantMatch) Valid() bool {
+	switch m {
+	case ChantExact, ChantFuzzy, ChantKeyword:
+		return true
+	}
+	return false
+}
+
+// 詠唱の正確さの閾値
+const (
+	FullChantAccuracy = 0.9 // これ以上は満額のダメージ / 回復量
+	MinChantAccuracy  = 0.5 // これ未満は不発
+	MaxChantLength    = 200 // 詠唱の最大文字数
+)
+
+// ChantOutcome は詠唱の判定結果です
+type ChantOutcome string
+
+const (
+	ChantPerfect ChantOutcome = "perfect" // 満額のダメージ / 回復量
+	ChantPartial ChantOutcome = "partial" // 正確さに応じてダメージ / 回復量を減らす
+	ChantFizzled ChantOutcome = "fizzled" // 不発（MP は消費する）
+)
+
+// ChantResult は詠唱を照合した結果です
+type ChantResult struct {
+	Accuracy float64 // 0〜1
+	Outcome  ChantOutcome
+}
+
+// Scale は判定結果に応じたダメージ / 回復量を返します。部分的な詠唱でも 1 以上になります。
+func (r ChantResult) Scale(base int) int {
+	switch r.Outcome {
+	case ChantPerfect:
+		return base
+	case ChantPartial:
+		return max(1, int(float64(base)*r.Accuracy))
+	}
+	return 0
+}
+
+// VerifyChant は文字起こしされた詠唱 text を魔法の詠唱と照合します
+func (m MagicType) VerifyChant(text string) ChantResult {
+	want, got := NormalizeChant(m.Chant), NormalizeChant(text)
+
+	var accuracy float64
+	switch m.ChantMatch {
+	case ChantFuzzy:
+		accuracy = similarity([]rune(want), []rune(got))
+	case ChantKeyword:
+		keywords := chantKeywords(m.Chant)
+		matched := 0
+		for _, keyword := range keywords {
+			if strings.Contains(got, keyword) {
+				matched++
+			}
+		}
+		if len(keywords) > 0 {
+			accuracy = float64(matched) / float64(len(keywords))
+		}
+	default:
+		if want == got {
+			accuracy = 1
+		}
+	}
+
+	result := ChantResult{Accuracy: accuracy, Outcome: ChantFizzled}
+	switch {
+	case accuracy >= FullChantAccuracy:
+		result.Outcome = ChantPerfect
+	case accuracy >= MinChantAccuracy && m.ChantMatch != ChantExact:
+		result.Outcome = ChantPartial
+	}
+	return result
+}
+
+// NormalizeChant は照合用に詠唱を正規化します。
+// 空白・句読点・記号を除き、全角英数字を半角に、カタカナをひらがなに、英字を小文字にそろえます。
+func NormalizeChant(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r >= '！' && r <= '～':
+			r -= '！' - '!'
+		case r >= 'ァ' && r <= 'ヶ':
+			r -= 'ァ' - 'ぁ'
+		}
+		if unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			continue
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// chantKeywords は詠唱を空白・句読点で区切り、正規化した語句を返します
+func chantKeywords(chant string) []string {
+	var keywords []string
+	for _, field := range strings.FieldsFunc(chant, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	}) {
+		if keyword := NormalizeChant(field); keyword != "" {
+			keywords = append(keywords, keyword)
+		}
+	}
+	return keywords
+}
+
+// similarity は編集距離を長い方の文字数で割り、1 から引いた値（0〜1）を返します
+func similarity(a, b []rune) float64 {
+	longest := max(len(a), len(b))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(a, b))/float64(longest)
+}
+
+// levenshtein は a と b の編集距離（挿入・削除・置換の回数）を返します
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,32 @@
+package magic
+
+import "testing"
+
+func TestVerifyChant(t *testing.T) {
+	tests := []struct {
+		name    string
+		match   ChantMatch
+		chant   string
+		text    string
+		outcome ChantOutcome
+		damage  int // 基礎ダメージ 100 のときのダメージ
+	}{
+		{"exact ignores punctuation and kana", ChantExact, "かぜよ、刃となれ", "カゼよ　刃となれ！", ChantPerfect, 100},
+		{"exact rejects one wrong character", ChantExact, "風よ刃となれ", "風よ刃となる", ChantFizzled, 0},
+		{"fuzzy tolerates a small error", ChantFuzzy, "紅蓮の炎よ我が敵を焼き尽くせ", "紅蓮の炎よ我が的を焼き尽くせ", ChantPerfect, 100},
+		{"fuzzy scales partial chants", ChantFuzzy, "紅蓮の炎よ我が敵を焼き尽くせ", "紅蓮の炎よ我が敵を", ChantPartial, 64},
+		{"fuzzy fizzles unrelated text", ChantFuzzy, "紅蓮の炎よ我が敵を焼き尽くせ", "こんにちは", ChantFizzled, 0},
+		{"keyword counts phrases", ChantKeyword, "天より降りし雷光、槍となりて貫け", "えっと天より降りし雷光", ChantPartial, 50},
+		{"keyword accepts all phrases in any order", ChantKeyword, "天より降りし雷光、槍となりて貫け", "槍となりて貫け 天より降りし雷光", ChantPerfect, 100},
+		{"english is case-insensitive", ChantFuzzy, "Fire Ball", "fireball", ChantPerfect, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := MagicType{Chant: tt.chant, ChantMatch: tt.match}.VerifyChant(tt.text)
+			if result.Outcome != tt.outcome || result.Scale(100) != tt.damage {
+				t.Fatalf("VerifyChant(%q) = %+v, damage %d; want %s, damage %d", tt.text, result, result.Scale(100), tt.outcome, tt.damage)
+			}
+		})
+	}
+}
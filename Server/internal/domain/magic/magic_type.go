@@ -0,0 +1,163 @@
+package magic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrMagicTypeNotFound は指定 ID の魔法が存在しない場合のエラーです
+	ErrMagicTypeNotFound = errors.New("magic type not found")
+	// ErrMagicTypeExists は同じ ID の魔法が既に存在する場合のエラーです
+	ErrMagicTypeExists = errors.New("magic type already exists")
+	// ErrInvalidMagicType は魔法の値が範囲外の場合のエラーです
+	ErrInvalidMagicType = errors.New("invalid magic type")
+)
+
+// 魔法の値の範囲（HP / MP の上限 1000 に合わせる）
+const (
+	MaxMPCost = 1000
+	MinDamage = 1
+	MaxDamage = 1000
+	MaxHeal   = 1000
+
+	MaxCooldown = time.Minute
+	MaxCastTime = 10 * time.Second
+	MaxStun     = 10 * time.Second
+)
+
+// Kind は魔法の種類です
+type Kind string
+
+const (
+	KindAttack Kind = "attack" // 相手にダメージを与える
+	KindHeal   Kind = "heal"   // 自分または味方の HP を回復する
+)
+
+// Target は魔法の対象です
+type Target string
+
+const (
+	TargetEnemy Target = "enemy" // 対戦相手
+	TargetSelf  Target = "self"  // 唱えた本人
+	TargetAlly  Target = "ally"  // 唱えた本人を含む味方
+)
+
+// DefaultTarget は種類ごとの既定の対象を返します
+func (k Kind) DefaultTarget() Target {
+	if k == KindHeal {
+		return TargetSelf
+	}
+	return TargetEnemy
+}
+
+// MagicType は魔法の定義です
+type MagicType struct {
+	ID          string
+	Name        string
+	Kind        Kind
+	Target      Target
+	MPCost      int
+	Description string
+	Damage      int // 攻撃魔法のダメージ（回復魔法は 0）
+	Heal        int // 回復魔法の回復量（攻撃魔法は 0）
+	Sound       string
+	Chant       string        // 唱える必要がある詠唱
+	ChantMatch  ChantMatch    // 詠唱の照合方法
+	Cooldown    time.Duration // 唱えてから同じ魔法を再び唱えられるまでの時間
+	CastTime    time.Duration // 唱え始めてから発動するまでの時間（この間に行動不能になると中断）
+	Stun        time.Duration // 命中した相手を行動不能にする時間
+}
+
+// ValidationError は魔法の値が範囲外の場合のエラーです。errors.Is で ErrInvalidMagicType と判定できます。
+type ValidationError struct {
+	ID      string
+	Field   string // 範囲外の項目（magic_types の列名。例: "mp_cost"）
+	Rule    string // 違反したルール（"required", "range", "oneof", "kind"）
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.ID == "" {
+		return fmt.Sprintf("%s: %s", ErrInvalidMagicType, e.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", ErrInvalidMagicType, e.ID, e.Message)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidMagicType
+}
+
+// invalid は field が rule に違反したことを表す ValidationError を作ります
+func (m MagicType) invalid(field, rule, format string, args ...any) error {
+	return &ValidationError{ID: m.ID, Field: field, Rule: rule, Message: fmt.Sprintf(format, args...)}
+}
+
+// Validate は ID・名前・詠唱が空でなく、MP コスト・ダメージ / 回復量・各時間が範囲内で、照合方法が定義済みであることを確認します。
+// 攻撃魔法は相手が対象でダメージを持ち、回復魔法は自分か味方が対象で回復量を持ちます（ダメージと行動不能はなし）。
+// 違反した場合は最初に見つかった項目の *ValidationError を返します。
+func (m MagicType) Validate() error {
+	switch {
+	case m.ID == "":
+		return m.invalid("id", "required", "id is required")
+	case m.Name == "":
+		return m.invalid("name", "required", "name is required")
+	case m.MPCost < 1 || m.MPCost > MaxMPCost:
+		return m.invalid("mp_cost", "range", "mp_cost must be between 1 and %d", MaxMPCost)
+	}
+
+	switch m.Kind {
+	case KindAttack:
+		switch {
+		case m.Target != TargetEnemy:
+			return m.invalid("target", "kind", "attack magic must target enemy")
+		case m.Damage < MinDamage || m.Damage > MaxDamage:
+			return m.invalid("damage", "range", "damage must be between %d and %d", MinDamage, MaxDamage)
+		case m.Heal != 0:
+			return m.invalid("heal", "kind", "attack magic must not heal")
+		}
+	case KindHeal:
+		switch {
+		case m.Target != TargetSelf && m.Target != TargetAlly:
+			return m.invalid("target", "kind", "heal magic must target self or ally")
+		case m.Heal < 1 || m.Heal > MaxHeal:
+			return m.invalid("heal", "range", "heal must be between 1 and %d", MaxHeal)
+		case m.Damage != 0:
+			return m.invalid("damage", "kind", "heal magic must not deal damage")
+		case m.Stun != 0:
+			return m.invalid("stun_ms", "kind", "heal magic must not stun")
+		}
+	default:
+		return m.invalid("kind", "oneof", "kind must be one of attack, heal")
+	}
+
+	switch {
+	case NormalizeChant(m.Chant) == "":
+		return m.invalid("chant", "required", "chant must contain letters")
+	case len([]rune(m.Chant)) > MaxChantLength:
+		return m.invalid("chant", "range", "chant must be at most %d characters", MaxChantLength)
+	case !m.ChantMatch.Valid():
+		return m.invalid("chant_match", "oneof", "chant_match must be one of exact, fuzzy, keyword")
+	case m.Cooldown < 0 || m.Cooldown > MaxCooldown:
+		return m.invalid("cooldown_ms", "range", "cooldown_ms must be between 0 and %d", MaxCooldown.Milliseconds())
+	case m.CastTime < 0 || m.CastTime > MaxCastTime:
+		return m.invalid("cast_time_ms", "range", "cast_time_ms must be between 0 and %d", MaxCastTime.Milliseconds())
+	case m.Stun < 0 || m.Stun > MaxStun:
+		return m.invalid("stun_ms", "range", "stun_ms must be between 0 and %d", MaxStun.Milliseconds())
+	}
+	return nil
+}
+
+// Repository は魔法の定義の永続化を抽象化します
+type Repository interface {
+	// List はすべての魔法を ID 順に返します
+	List(ctx context.Context) ([]MagicType, error)
+	// Create は魔法を追加します。同じ ID があれば ErrMagicTypeExists を返します。
+	Create(ctx context.Context, magicType *MagicType) error
+	// Update は魔法を更新します。存在しなければ ErrMagicTypeNotFound を返します。
+	Update(ctx context.Context, magicType *MagicType) error
+	// Delete は魔法を削除します。存在しなければ ErrMagicTypeNotFound を返します。
+	Delete(ctx context.Context, id string) error
+}
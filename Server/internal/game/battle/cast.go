@@ -0,0 +1,253 @@
+package battle
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	domainmagic "server/internal/domain/magic"
+
+	"github.com/google/uuid"
+)
+
+// PendingCast は詠唱時間の途中の魔法です
+type PendingCast struct {
+	MagicType  domainmagic.MagicType
+	TargetID   uuid.UUID
+	Chant      domainmagic.ChantResult
+	ResolvesAt time.Time
+}
+
+// CastError は唱えられなかった理由と、再び唱えられるようになるまでの時間です
+type CastError struct {
+	Err       error
+	Remaining time.Duration
+}
+
+func (e *CastError) Error() string {
+	return fmt.Sprintf("%v (retry in %s)", e.Err, e.Remaining)
+}
+
+func (e *CastError) Unwrap() error {
+	return e.Err
+}
+
+// Cast は参加者 casterID が詠唱 chantText で魔法を唱えます。
+// MP を消費して再使用までの時間を開始してから詠唱を照合し、不発でなければ正確さに応じたダメージを相手に与えるか、対象の HP を回復します。
+// targetID が uuid.Nil の場合は、攻撃魔法は相手、回復魔法は本人が対象です。
+// 詠唱時間のある魔法は spell_casting を返し、ResolveCasts で詠唱時間が過ぎてから発動します。
+// 相手の HP が 0 になった場合は失格にし、対戦を終了します。
+func (s *Session) Cast(casterID uuid.UUID, magicType domainmagic.MagicType, targetID uuid.UUID, chantText string, now time.Time) ([]Event, error) {
+	switch s.Status {
+	case StatusFinished:
+		return nil, ErrSessionFinished
+	case StatusWaiting:
+		return nil, ErrSessionNotActive
+	}
+
+	caster, ok := s.Participants[casterID]
+	if !ok || caster.Forfeited {
+		return nil, ErrNotParticipant
+	}
+	if s.opponent(caster) == nil {
+		return nil, ErrSessionNotActive
+	}
+	target, err := s.spellTarget(caster, magicType, targetID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case now.Before(caster.StunnedUntil):
+		return nil, &CastError{Err: ErrStunned, Remaining: caster.StunnedUntil.Sub(now)}
+	case caster.Casting != nil:
+		return nil, &CastError{Err: ErrAlreadyCasting, Remaining: caster.Casting.ResolvesAt.Sub(now)}
+	}
+	if remaining := caster.Cooldowns.Remaining(magicType.ID, now); remaining > 0 {
+		return nil, &CastError{Err: ErrOnCooldown, Remaining: remaining}
+	}
+	if caster.MP < magicType.MPCost {
+		return nil, ErrInsufficientMP
+	}
+
+	caster.MP -= magicType.MPCost
+	caster.Cooldowns.Start(magicType.ID, now, magicType.Cooldown)
+	chant := magicType.VerifyChant(chantText)
+
+	if chant.Outcome == domainmagic.ChantFizzled {
+		event := s.newSpellEvent(EventSpellFizzled, caster, magicType, chant, now)
+		return []Event{event}, nil
+	}
+
+	if magicType.CastTime > 0 {
+		resolvesAt := now.Add(magicType.CastTime)
+		caster.Casting = &PendingCast{MagicType: magicType, TargetID: target.UserID, Chant: chant, ResolvesAt: resolvesAt}
+		event := s.newSpellEvent(EventSpellCasting, caster, magicType, chant, now)
+		event.CastEndsAt = &resolvesAt
+		return []Event{event}, nil
+	}
+
+	return s.resolveCast(caster, target.UserID, magicType, chant, now), nil
+}
+
+// ResolveCasts は詠唱時間が過ぎた魔法を、発動する時刻の順に発動します
+func (s *Session) ResolveCasts(now time.Time) []Event {
+	if s.Status != StatusActive {
+		return nil
+	}
+
+	var due []*Participant
+	for _, p := range s.Participants {
+		if p.Casting != nil && !now.Before(p.Casting.ResolvesAt) {
+			due = append(due, p)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].Casting.ResolvesAt.Equal(due[j].Casting.ResolvesAt) {
+			return due[i].Casting.ResolvesAt.Before(due[j].Casting.ResolvesAt)
+		}
+		return due[i].UserID.String() < due[j].UserID.String()
+	})
+
+	var events []Event
+	for _, p := range due {
+		// 先に発動した魔法で対戦が終わったり、行動不能で中断されたりした場合は発動しない
+		if s.Status != StatusActive || p.Casting == nil {
+			continue
+		}
+		pending := p.Casting
+		p.Casting = nil
+		events = append(events, s.resolveCast(p, pending.TargetID, pending.MagicType, pending.Chant, pending.ResolvesAt)...)
+	}
+	return events
+}
+
+// resolveCast は魔法を発動し、対象 targetID にダメージと行動不能を与えます（回復魔法は resolveHeal で回復します）。
+// 詠唱の正確さでダメージを決め、相手のガード / シールドで減らしてから HP に反映します。
+// ダメージを受けた相手は行動不能になり、詠唱時間の途中であればその魔法を中断します。
+// 発動までに対象が失格していた場合は何も起きません。
+func (s *Session) resolveCast(caster *Participant, targetID uuid.UUID, magicType domainmagic.MagicType, chant domainmagic.ChantResult, now time.Time) []Event {
+	target, ok := s.Participants[targetID]
+	if !ok || target.Forfeited {
+		return nil
+	}
+	if magicType.Kind == domainmagic.KindHeal {
+		return s.resolveHeal(caster, target, magicType, chant, now)
+	}
+
+	mitigation := target.mitigate(chant.Scale(magicType.Damage), now)
+	damage := min(target.HP, mitigation.taken)
+	target.HP -= damage
+	hp := target.HP
+
+	event := s.newSpellEvent(EventSpellCast, caster, magicType, chant, now)
+	event.TargetID = &target.UserID
+	event.Damage = &damage
+	event.HP = &hp
+	if mitigation.defense != "" {
+		event.Defense = mitigation.defense
+		event.Mitigated = &mitigation.prevented
+		event.ShieldRemaining = mitigation.shield
+	}
+	if magicType.Stun > 0 && damage > 0 && target.HP > 0 {
+		if stunEndsAt := now.Add(magicType.Stun); stunEndsAt.After(target.StunnedUntil) {
+			target.StunnedUntil = stunEndsAt
+		}
+		stunEndsAt := target.StunnedUntil
+		event.StunEndsAt = &stunEndsAt
+	}
+	events := []Event{event}
+
+	if mitigation.broken {
+		events = append(events, s.newEvent(EventShieldBroken, &target.UserID, now))
+	}
+	if target.Casting != nil && now.Before(target.StunnedUntil) {
+		interrupted := s.newEvent(EventSpellInterrupted, &target.UserID, now)
+		interrupted.MagicTypeID = target.Casting.MagicType.ID
+		target.Casting = nil
+		events = append(events, interrupted)
+	}
+
+	if target.HP == 0 {
+		events = append(events, s.forfeit(target, now)...)
+	}
+	return events
+}
+
+// resolveHeal は回復魔法を発動し、詠唱の正確さに応じた量だけ対象の HP を回復します。最大HPは超えません。
+func (s *Session) resolveHeal(caster, target *Participant, magicType domainmagic.MagicType, chant domainmagic.ChantResult, now time.Time) []Event {
+	healed := max(0, min(chant.Scale(magicType.Heal), target.MaxHP-target.HP))
+	target.HP += healed
+	hp := target.HP
+
+	event := s.newSpellEvent(EventSpellCast, caster, magicType, chant, now)
+	event.TargetID = &target.UserID
+	event.Healed = &healed
+	event.HP = &hp
+	return []Event{event}
+}
+
+// spellTarget は魔法の対象を返します。targetID が uuid.Nil の場合は、攻撃魔法は相手、回復魔法は本人が対象です。
+// 味方を対象にできる回復魔法でも、1 対 1 の対戦では味方は本人だけのため、それ以外を指定すると ErrInvalidTarget を返します。
+func (s *Session) spellTarget(caster *Participant, magicType domainmagic.MagicType, targetID uuid.UUID) (*Participant, error) {
+	target := s.opponent(caster)
+	if magicType.Kind == domainmagic.KindHeal {
+		target = caster
+	}
+	if targetID != uuid.Nil && targetID != target.UserID {
+		return nil, ErrInvalidTarget
+	}
+	return target, nil
+}
+
+// newSpellEvent は唱えた参加者の残り MP と詠唱の判定結果を含むイベントを作成します
+func (s *Session) newSpellEvent(eventType EventType, caster *Participant, magicType domainmagic.MagicType, chant domainmagic.ChantResult, now time.Time) Event {
+	mp := caster.MP
+	accuracy := math.Round(chant.Accuracy*100) / 100
+
+	event := s.newEvent(eventType, &caster.UserID, now)
+	event.MagicTypeID = magicType.ID
+	event.MP = &mp
+	event.ChantAccuracy = &accuracy
+	event.ChantOutcome = chant.Outcome
+	return event
+}
+
+// opponent は p の対戦相手（失格していない他の参加者）を返します
+func (s *Session) opponent(p *Participant) *Participant {
+	for _, other := range s.Participants {
+		if other.UserID != p.UserID && !other.Forfeited {
+			return other
+		}
+	}
+	return nil
+}
+
+// rejectReason は Cast のエラーを唱えた参加者に通知する理由と、再び唱えられるまでの時間に変換します。
+// 通知の対象でないエラー（セッションに参加していないなど）は false を返します。
+func rejectReason(err error) (RejectReason, time.Duration, bool) {
+	var remaining time.Duration
+	var castErr *CastError
+	if errors.As(err, &castErr) {
+		remaining = castErr.Remaining
+	}
+
+	switch {
+	case errors.Is(err, ErrSessionNotActive):
+		return RejectSessionNotActive, 0, true
+	case errors.Is(err, ErrSessionFinished):
+		return RejectSessionFinished, 0, true
+	case errors.Is(err, ErrInsufficientMP):
+		return RejectInsufficientMP, 0, true
+	case errors.Is(err, ErrOnCooldown):
+		return RejectCooldown, remaining, true
+	case errors.Is(err, ErrAlreadyCasting):
+		return RejectCasting, remaining, true
+	case errors.Is(err, ErrStunned):
+		return RejectStunned, remaining, true
+	case errors.Is(err, ErrInvalidTarget):
+		return RejectInvalidTarget, 0, true
+	}
+	return "", 0, false
+}
@@ -0,0 +1,198 @@
+package battle
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	domainmagic "server/internal/domain/magic"
+
+	"github.com/google/uuid"
+)
+
+var testFireball = domainmagic.MagicType{
+	ID:         "fireball",
+	Name:       "ファイアボール",
+	Kind:       domainmagic.KindAttack,
+	Target:     domainmagic.TargetEnemy,
+	MPCost:     40,
+	Damage:     60,
+	Chant:      "紅蓮の炎よ、我が敵を焼き尽くせ",
+	ChantMatch: domainmagic.ChantFuzzy,
+}
+
+func TestSession_Cast(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	session, caster, target := newActiveSession(t, now)
+
+	// 正確な詠唱は満額のダメージ
+	events, err := session.Cast(caster, testFireball, uuid.Nil, "紅蓮の炎よ 我が敵を焼き尽くせ！", now)
+	if err != nil || !reflect.DeepEqual(eventTypes(events), []EventType{EventSpellCast}) {
+		t.Fatalf("perfect chant: %v (err=%v)", eventTypes(events), err)
+	}
+	if e := events[0]; *e.Damage != 60 || *e.HP != 40 || *e.MP != 60 || *e.TargetID != target || e.ChantOutcome != domainmagic.ChantPerfect {
+		t.Fatalf("perfect chant event = %+v", e)
+	}
+
+	// 不発でも MP は消費する
+	events, err = session.Cast(caster, testFireball, uuid.Nil, "えーと", now)
+	if err != nil || !reflect.DeepEqual(eventTypes(events), []EventType{EventSpellFizzled}) {
+		t.Fatalf("fizzled chant: %v (err=%v)", eventTypes(events), err)
+	}
+	if p := session.Participants[caster]; p.MP != 20 || session.Participants[target].HP != 40 {
+		t.Fatalf("after fizzle: caster mp = %d, target hp = %d", p.MP, session.Participants[target].HP)
+	}
+
+	if _, err := session.Cast(caster, testFireball, uuid.Nil, testFireball.Chant, now); err != ErrInsufficientMP {
+		t.Fatalf("expected ErrInsufficientMP, got %v", err)
+	}
+
+	// 相手の HP が 0 になると対戦が終わる
+	events, err = session.Cast(target, testFireball, uuid.Nil, testFireball.Chant, now)
+	if err != nil {
+		t.Fatalf("counter cast: %v", err)
+	}
+	events, err = session.Cast(target, testFireball, uuid.Nil, testFireball.Chant, now)
+	want := []EventType{EventSpellCast, EventForfeit, EventSessionFinished}
+	if err != nil || !reflect.DeepEqual(eventTypes(events), want) {
+		t.Fatalf("finishing cast: %v (err=%v), want %v", eventTypes(events), err, want)
+	}
+	if *events[0].Damage != 40 || session.WinnerID == nil || *session.WinnerID != target {
+		t.Fatalf("finishing cast: damage = %d, winner = %v", *events[0].Damage, session.WinnerID)
+	}
+
+	if _, err := session.Cast(target, testFireball, uuid.Nil, testFireball.Chant, now); err != ErrSessionFinished {
+		t.Fatalf("expected ErrSessionFinished, got %v", err)
+	}
+}
+
+func TestSession_Cast_Heal(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	session, caster, opponent := newActiveSession(t, now)
+	heal := domainmagic.MagicType{ID: "heal", Kind: domainmagic.KindHeal, Target: domainmagic.TargetAlly, MPCost: 10, Heal: 25, Chant: "癒やしよ", ChantMatch: domainmagic.ChantExact}
+
+	if _, err := session.Cast(opponent, testFireball, uuid.Nil, testFireball.Chant, now); err != nil {
+		t.Fatalf("attack: %v", err)
+	}
+
+	// 対象を省略すると本人を回復する
+	events, err := session.Cast(caster, heal, uuid.Nil, heal.Chant, now)
+	if err != nil || !reflect.DeepEqual(eventTypes(events), []EventType{EventSpellCast}) {
+		t.Fatalf("heal = %v, %v", eventTypes(events), err)
+	}
+	if e := events[0]; *e.TargetID != caster || *e.Healed != 25 || *e.HP != 65 || e.Damage != nil {
+		t.Fatalf("heal event = target %v, healed %d, hp %d", *e.TargetID, *e.Healed, *e.HP)
+	}
+
+	// 最大HPを超えては回復しない
+	session.Cast(caster, heal, caster, heal.Chant, now)
+	events, _ = session.Cast(caster, heal, caster, heal.Chant, now)
+	if p := session.Participants[caster]; p.HP != 100 || *events[0].Healed != 10 {
+		t.Fatalf("hp = %d, healed %d; want capped at 100", p.HP, *events[0].Healed)
+	}
+
+	// 相手は味方ではない
+	if _, err := session.Cast(caster, heal, opponent, heal.Chant, now); !errors.Is(err, ErrInvalidTarget) {
+		t.Fatalf("heal opponent err = %v, want ErrInvalidTarget", err)
+	}
+	if _, err := session.Cast(caster, testFireball, caster, testFireball.Chant, now); !errors.Is(err, ErrInvalidTarget) {
+		t.Fatalf("attack self err = %v, want ErrInvalidTarget", err)
+	}
+}
+
+func TestHub_CastRejectionGoesToCasterOnly(t *testing.T) {
+	hub := NewHub(GeofenceRules{}, DefenseRules{}, SessionRules{}, nil, nil)
+	snapshot, err := hub.CreateSession("stage-1", Arena{RadiusMeters: 50}, uuid.New(), 100, 100, 0)
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	caster := snapshot.Participants[0].UserID
+	watcher := uuid.New()
+	if _, err := hub.Join(snapshot.ID, watcher, 100, 100, 100); err != nil {
+		t.Fatalf("join: %v", err)
+	}
+
+	casterClient, watcherClient := NewClient(caster), NewClient(watcher)
+	for _, client := range []*Client{casterClient, watcherClient} {
+		if err := hub.Subscribe(snapshot.ID, client); err != nil {
+			t.Fatalf("subscribe: %v", err)
+		}
+	}
+
+	if err := hub.Cast(snapshot.ID, caster, testFireball, uuid.Nil, testFireball.Chant); err != nil {
+		t.Fatalf("cast: %v", err)
+	}
+	if len(casterClient.send) != 1 || len(watcherClient.send) != 0 {
+		t.Fatalf("queued messages: caster = %d, watcher = %d", len(casterClient.send), len(watcherClient.send))
+	}
+	if payload := string(<-casterClient.send); !strings.Contains(payload, `"type":"cast_rejected"`) || !strings.Contains(payload, `"reason":"insufficient_mp"`) {
+		t.Fatalf("rejection = %s", payload)
+	}
+}
+
+func TestSession_Cast_Cooldown(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	session, caster, _ := newActiveSession(t, now)
+	windCutter := domainmagic.MagicType{ID: "wind_cutter", MPCost: 10, Damage: 5, Chant: "風よ", ChantMatch: domainmagic.ChantExact, Cooldown: 2 * time.Second}
+
+	if _, err := session.Cast(caster, windCutter, uuid.Nil, "風よ", now); err != nil {
+		t.Fatalf("first cast: %v", err)
+	}
+
+	// 不発でも再使用までの時間は始まり、残り時間を返す
+	_, err := session.Cast(caster, windCutter, uuid.Nil, "風よ", now.Add(500*time.Millisecond))
+	var castErr *CastError
+	if !errors.As(err, &castErr) || !errors.Is(err, ErrOnCooldown) || castErr.Remaining != 1500*time.Millisecond {
+		t.Fatalf("early cast: err = %v, want ErrOnCooldown with 1.5s remaining", err)
+	}
+	if mp := session.Participants[caster].MP; mp != 90 {
+		t.Fatalf("rejected cast consumed mp: %d", mp)
+	}
+
+	if _, err := session.Cast(caster, windCutter, uuid.Nil, "風よ", now.Add(2*time.Second)); err != nil {
+		t.Fatalf("cast after cooldown: %v", err)
+	}
+}
+
+func TestSession_Cast_CastTimeInterruptedByStun(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	session, caster, opponent := newActiveSession(t, now)
+	slowFireball := testFireball
+	slowFireball.CastTime = 2 * time.Second
+	thunderbolt := domainmagic.MagicType{ID: "thunderbolt", MPCost: 20, Damage: 10, Chant: "雷よ", ChantMatch: domainmagic.ChantExact, Stun: 1500 * time.Millisecond}
+
+	events, err := session.Cast(caster, slowFireball, uuid.Nil, slowFireball.Chant, now)
+	if err != nil || !reflect.DeepEqual(eventTypes(events), []EventType{EventSpellCasting}) || !events[0].CastEndsAt.Equal(now.Add(2*time.Second)) {
+		t.Fatalf("start casting: %v (err=%v)", eventTypes(events), err)
+	}
+	if _, err := session.Cast(caster, thunderbolt, uuid.Nil, "雷よ", now); !errors.Is(err, ErrAlreadyCasting) {
+		t.Fatalf("second cast while casting: err = %v, want ErrAlreadyCasting", err)
+	}
+	if events := session.ResolveCasts(now.Add(time.Second)); len(events) != 0 {
+		t.Fatalf("resolved before cast time: %v", eventTypes(events))
+	}
+
+	// 詠唱時間の途中で行動不能になると中断され、行動不能の間は唱えられない
+	events, err = session.Cast(opponent, thunderbolt, uuid.Nil, "雷よ", now.Add(time.Second))
+	if err != nil || !reflect.DeepEqual(eventTypes(events), []EventType{EventSpellCast, EventSpellInterrupted}) {
+		t.Fatalf("stun: %v (err=%v)", eventTypes(events), err)
+	}
+	if events := session.ResolveCasts(now.Add(3 * time.Second)); len(events) != 0 {
+		t.Fatalf("interrupted cast resolved: %v", eventTypes(events))
+	}
+	if _, err := session.Cast(caster, thunderbolt, uuid.Nil, "雷よ", now.Add(2*time.Second)); !errors.Is(err, ErrStunned) {
+		t.Fatalf("cast while stunned: err = %v, want ErrStunned", err)
+	}
+
+	// 中断されなければ詠唱時間が過ぎてから発動する
+	events, err = session.Cast(caster, slowFireball, uuid.Nil, slowFireball.Chant, now.Add(3*time.Second))
+	if err != nil {
+		t.Fatalf("recast: %v", err)
+	}
+	events = session.ResolveCasts(now.Add(5 * time.Second))
+	if !reflect.DeepEqual(eventTypes(events), []EventType{EventSpellCast}) || *events[0].Damage != 60 || *events[0].TargetID != opponent {
+		t.Fatalf("resolved cast: %+v", events)
+	}
+}
@@ -0,0 +1,22 @@
+package battle
+
+import "time"
+
+// Cooldowns は参加者ごとの魔法の再使用までの時刻です（魔法 ID → 再び唱えられるようになる時刻）。
+// 参加者はセッションごとに作られるため、セッションをまたいで持ち越しません。
+type Cooldowns map[string]time.Time
+
+// Remaining は magicTypeID を再び唱えられるようになるまでの残り時間を返します（唱えられる場合は 0）
+func (c Cooldowns) Remaining(magicTypeID string, now time.Time) time.Duration {
+	if readyAt, ok := c[magicTypeID]; ok && now.Before(readyAt) {
+		return readyAt.Sub(now)
+	}
+	return 0
+}
+
+// Start は magicTypeID を now から cooldown の間唱えられないようにします
+func (c Cooldowns) Start(magicTypeID string, now time.Time, cooldown time.Duration) {
+	if cooldown > 0 {
+		c[magicTypeID] = now.Add(cooldown)
+	}
+}
@@ -0,0 +1,133 @@
+package battle
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrUnknownDefense は定義されていない防御行動のエラーです
+var ErrUnknownDefense = errors.New("unknown defense")
+
+// DefenseKind は防御行動の種類です（game_event_category の guard / shield と同じ値）
+type DefenseKind string
+
+const (
+	DefenseGuard  DefenseKind = "guard"  // 続く間、受けるダメージを一定の割合で減らす
+	DefenseShield DefenseKind = "shield" // 続く間、一定量までのダメージを吸収する
+)
+
+// DefenseRules は防御行動のルールです
+type DefenseRules struct {
+	GuardMPCost    int
+	GuardDuration  time.Duration
+	GuardReduction float64 // 受けるダメージを減らす割合（0〜1）
+	ShieldMPCost   int
+	ShieldDuration time.Duration
+	ShieldAbsorb   int // 吸収できるダメージの合計
+}
+
+// Defense は参加者に有効な防御です
+type Defense struct {
+	Kind      DefenseKind
+	EndsAt    time.Time
+	Reduction float64 // ガードで減らす割合
+	Absorb    int     // シールドの残りの吸収量
+}
+
+// Defend は参加者 userID が防御行動をとります。MP を消費し、既に有効な防御は置き換えます。
+// 行動不能の間と詠唱時間の途中は防御できません。
+func (s *Session) Defend(userID uuid.UUID, kind DefenseKind, rules DefenseRules, now time.Time) ([]Event, error) {
+	switch s.Status {
+	case StatusFinished:
+		return nil, ErrSessionFinished
+	case StatusWaiting:
+		return nil, ErrSessionNotActive
+	}
+
+	p, ok := s.Participants[userID]
+	if !ok || p.Forfeited {
+		return nil, ErrNotParticipant
+	}
+
+	var cost int
+	defense := &Defense{Kind: kind}
+	switch kind {
+	case DefenseGuard:
+		cost = rules.GuardMPCost
+		defense.EndsAt = now.Add(rules.GuardDuration)
+		defense.Reduction = rules.GuardReduction
+	case DefenseShield:
+		cost = rules.ShieldMPCost
+		defense.EndsAt = now.Add(rules.ShieldDuration)
+		defense.Absorb = rules.ShieldAbsorb
+	default:
+		return nil, ErrUnknownDefense
+	}
+
+	switch {
+	case now.Before(p.StunnedUntil):
+		return nil, &CastError{Err: ErrStunned, Remaining: p.StunnedUntil.Sub(now)}
+	case p.Casting != nil:
+		return nil, &CastError{Err: ErrAlreadyCasting, Remaining: p.Casting.ResolvesAt.Sub(now)}
+	case p.MP < cost:
+		return nil, ErrInsufficientMP
+	}
+
+	p.MP -= cost
+	p.Defense = defense
+
+	mp := p.MP
+	endsAt := defense.EndsAt
+	event := s.newEvent(EventDefenseStarted, &p.UserID, now)
+	event.Defense = kind
+	event.DefenseEndsAt = &endsAt
+	event.MP = &mp
+	if kind == DefenseShield {
+		absorb := defense.Absorb
+		event.ShieldRemaining = &absorb
+	}
+	return []Event{event}, nil
+}
+
+// mitigation は防御でダメージを減らした結果です
+type mitigation struct {
+	taken     int         // 実際に受けるダメージ
+	prevented int         // 防いだダメージ
+	defense   DefenseKind // 防いだ防御（防いでいなければ空）
+	shield    *int        // シールドの残りの吸収量
+	broken    bool        // シールドの吸収量を使い切った
+}
+
+// Defending は now の時点でガード / シールドが有効かを返します
+func (p *Participant) Defending(now time.Time) bool {
+	return p.Defense != nil && now.Before(p.Defense.EndsAt)
+}
+
+// mitigate は有効な防御で damage を減らします。期限切れの防御はここで外します。
+func (p *Participant) mitigate(damage int, now time.Time) mitigation {
+	result := mitigation{taken: damage}
+	if !p.Defending(now) {
+		p.Defense = nil
+		return result
+	}
+
+	result.defense = p.Defense.Kind
+	switch p.Defense.Kind {
+	case DefenseGuard:
+		result.prevented = int(math.Round(float64(damage) * p.Defense.Reduction))
+	case DefenseShield:
+		result.prevented = min(damage, p.Defense.Absorb)
+		p.Defense.Absorb -= result.prevented
+		absorb := p.Defense.Absorb
+		result.shield = &absorb
+		if absorb == 0 {
+			result.broken = true
+			p.Defense = nil
+		}
+	}
+	result.taken = damage - result.prevented
+	return result
+}
@@ -0,0 +1,71 @@
+package battle
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var testDefenseRules = DefenseRules{
+	GuardMPCost:    20,
+	GuardDuration:  3 * time.Second,
+	GuardReduction: 0.5,
+	ShieldMPCost:   30,
+	ShieldDuration: 5 * time.Second,
+	ShieldAbsorb:   40,
+}
+
+func TestSession_Defend_GuardReducesDamage(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	session, caster, defender := newActiveSession(t, now)
+
+	events, err := session.Defend(defender, DefenseGuard, testDefenseRules, now)
+	if err != nil || !reflect.DeepEqual(eventTypes(events), []EventType{EventDefenseStarted}) || *events[0].MP != 80 {
+		t.Fatalf("guard: %+v (err=%v)", events, err)
+	}
+
+	events, err = session.Cast(caster, testFireball, uuid.Nil, testFireball.Chant, now.Add(time.Second))
+	if err != nil {
+		t.Fatalf("cast: %v", err)
+	}
+	if e := events[0]; *e.Damage != 30 || *e.Mitigated != 30 || e.Defense != DefenseGuard || *e.HP != 70 {
+		t.Fatalf("guarded hit = %+v", e)
+	}
+
+	// ガードが切れた後は満額のダメージ
+	events, _ = session.Cast(caster, testFireball, uuid.Nil, testFireball.Chant, now.Add(3*time.Second))
+	if e := events[0]; *e.Damage != 60 || e.Mitigated != nil {
+		t.Fatalf("hit after guard expired = %+v", e)
+	}
+}
+
+func TestSession_Defend_ShieldAbsorbsUntilBroken(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	session, caster, defender := newActiveSession(t, now)
+	weak := testFireball
+	weak.Damage = 25
+
+	if _, err := session.Defend(defender, DefenseShield, testDefenseRules, now); err != nil {
+		t.Fatalf("shield: %v", err)
+	}
+
+	events, _ := session.Cast(caster, weak, uuid.Nil, weak.Chant, now)
+	if e := events[0]; *e.Damage != 0 || *e.Mitigated != 25 || *e.ShieldRemaining != 15 {
+		t.Fatalf("absorbed hit = %+v", e)
+	}
+
+	events, _ = session.Cast(caster, weak, uuid.Nil, weak.Chant, now)
+	if !reflect.DeepEqual(eventTypes(events), []EventType{EventSpellCast, EventShieldBroken}) {
+		t.Fatalf("breaking hit: %v", eventTypes(events))
+	}
+	if e := events[0]; *e.Damage != 10 || *e.Mitigated != 15 || session.Participants[defender].Defense != nil {
+		t.Fatalf("breaking hit = %+v", e)
+	}
+
+	session.Participants[defender].MP = 10
+	if _, err := session.Defend(defender, DefenseShield, testDefenseRules, now); err != ErrInsufficientMP {
+		t.Fatalf("expected ErrInsufficientMP, got %v", err)
+	}
+}
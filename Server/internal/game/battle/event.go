@@ -0,0 +1,72 @@
+package battle
+
+import (
+	"time"
+
+	domainmagic "server/internal/domain/magic"
+
+	"github.com/google/uuid"
+)
+
+// EventType はセッション参加者へ配信するイベントの種類です
+type EventType string
+
+const (
+	EventPlayerJoined     EventType = "player_joined"
+	EventSessionStarted   EventType = "session_started"
+	EventGeofenceWarning  EventType = "geofence_warning"
+	EventGeofenceReturned EventType = "geofence_returned"
+	EventGeofencePenalty  EventType = "geofence_penalty"
+	EventForfeit          EventType = "forfeit"
+	EventSessionFinished  EventType = "session_finished"
+	EventSpellCasting     EventType = "spell_casting"     // 詠唱時間のある魔法を唱え始めた
+	EventSpellCast        EventType = "spell_cast"        // 魔法が発動し、相手にダメージを与えた / 対象の HP を回復した
+	EventSpellFizzled     EventType = "spell_fizzled"     // 詠唱が不正確で不発に終わった
+	EventSpellInterrupted EventType = "spell_interrupted" // 詠唱時間の途中で行動不能になり中断した
+	EventCastRejected     EventType = "cast_rejected"     // 唱えられなかった（唱えた参加者にだけ送る）
+	EventDefenseStarted   EventType = "defense_started"   // ガード / シールドを張った
+	EventDefenseRejected  EventType = "defense_rejected"  // 防御できなかった（本人にだけ送る）
+	EventShieldBroken     EventType = "shield_broken"     // シールドの吸収量を使い切った
+)
+
+// RejectReason は魔法を唱えられなかった理由です
+type RejectReason string
+
+const (
+	RejectSessionNotActive RejectReason = "session_not_active"
+	RejectSessionFinished  RejectReason = "session_finished"
+	RejectInsufficientMP   RejectReason = "insufficient_mp"
+	RejectUnknownMagicType RejectReason = "unknown_magic_type"
+	RejectCooldown         RejectReason = "cooldown"
+	RejectCasting          RejectReason = "casting"
+	RejectStunned          RejectReason = "stunned"
+	RejectInvalidTarget    RejectReason = "invalid_target"
+)
+
+// Event は WebSocket でセッション参加者に配信されるメッセージです
+type Event struct {
+	Type            EventType                `json:"type"`
+	SessionID       uuid.UUID                `json:"sessionId"`
+	UserID          *uuid.UUID               `json:"userId,omitempty"`
+	DistanceMeters  *float64                 `json:"distanceMeters,omitempty"` // アリーナ中心からの距離
+	GraceEndsAt     *time.Time               `json:"graceEndsAt,omitempty"`    // この時刻までに戻らないと違反
+	Strikes         int                      `json:"strikes,omitempty"`
+	HP              *int                     `json:"hp,omitempty"` // spell_cast では対象の残り HP
+	WinnerID        *uuid.UUID               `json:"winnerId,omitempty"`
+	TargetID        *uuid.UUID               `json:"targetId,omitempty"`
+	MagicTypeID     string                   `json:"magicTypeId,omitempty"`
+	Damage          *int                     `json:"damage,omitempty"`
+	Healed          *int                     `json:"healed,omitempty"` // spell_cast で回復した HP
+	MP              *int                     `json:"mp,omitempty"`     // 唱えた参加者の残り MP
+	ChantAccuracy   *float64                 `json:"chantAccuracy,omitempty"`
+	ChantOutcome    domainmagic.ChantOutcome `json:"chantOutcome,omitempty"`
+	Reason          RejectReason             `json:"reason,omitempty"`
+	RemainingMS     *int64                   `json:"remainingMs,omitempty"` // cast_rejected で再び唱えられるまでの時間
+	CastEndsAt      *time.Time               `json:"castEndsAt,omitempty"`  // spell_casting で発動する時刻
+	StunEndsAt      *time.Time               `json:"stunEndsAt,omitempty"`  // spell_cast で対象が行動できるようになる時刻
+	Defense         DefenseKind              `json:"defense,omitempty"`     // 張った防御、または spell_cast でダメージを防いだ防御
+	DefenseEndsAt   *time.Time               `json:"defenseEndsAt,omitempty"`
+	Mitigated       *int                     `json:"mitigated,omitempty"`       // spell_cast で防御が防いだダメージ
+	ShieldRemaining *int                     `json:"shieldRemaining,omitempty"` // シールドの残りの吸収量
+	At              time.Time                `json:"at"`
+}
@@ -0,0 +1,109 @@
+package battle
+
+import (
+	"time"
+
+	"server/internal/domain/battlestage"
+
+	"github.com/google/uuid"
+)
+
+// GeofenceRules は場外判定とペナルティのルールです
+type GeofenceRules struct {
+	Tolerance   float64       // GPS 誤差として半径に加算する距離（メートル）
+	GracePeriod time.Duration // 場外に出てから違反とみなすまでの猶予
+	PenaltyHP   int           // 違反 1 回あたりに減らす HP
+	MaxStrikes  int           // この回数の違反で失格（0 の場合は失格なし）
+}
+
+// UpdatePosition は参加者の位置を更新し、アリーナの円内にいるかを検証します。
+func (s *Session) UpdatePosition(userID uuid.UUID, location battlestage.Location, now time.Time, rules GeofenceRules) ([]Event, error) {
+	p, ok := s.Participants[userID]
+	if !ok {
+		return nil, ErrNotParticipant
+	}
+	if s.Status == StatusFinished {
+		return nil, ErrSessionFinished
+	}
+
+	p.Position = &location
+	p.PositionAt = now
+
+	// 対戦開始前と失格後は位置だけ記録して判定しない
+	if s.Status != StatusActive || p.Forfeited {
+		return nil, nil
+	}
+
+	return s.checkGeofence(p, now, rules), nil
+}
+
+// CheckGeofence は位置更新が途絶えた参加者も含め、猶予切れの場外参加者を違反として処理します。
+// Hub が定期的に呼び出します。
+func (s *Session) CheckGeofence(now time.Time, rules GeofenceRules) []Event {
+	if s.Status != StatusActive {
+		return nil
+	}
+
+	var events []Event
+	for _, p := range s.Participants {
+		if p.Forfeited || p.Position == nil || !p.OutOfBounds() {
+			continue
+		}
+		events = append(events, s.checkGeofence(p, now, rules)...)
+		if s.Status != StatusActive {
+			break
+		}
+	}
+	return events
+}
+
+func (s *Session) checkGeofence(p *Participant, now time.Time, rules GeofenceRules) []Event {
+	distance := battlestage.DistanceMeters(s.Arena.Center, *p.Position)
+
+	if distance <= s.Arena.RadiusMeters+rules.Tolerance {
+		if !p.OutOfBounds() {
+			return nil
+		}
+		p.OutsideSince = time.Time{}
+		event := s.newEvent(EventGeofenceReturned, &p.UserID, now)
+		event.DistanceMeters = &distance
+		return []Event{event}
+	}
+
+	if !p.OutOfBounds() {
+		p.OutsideSince = now
+		graceEndsAt := now.Add(rules.GracePeriod)
+		event := s.newEvent(EventGeofenceWarning, &p.UserID, now)
+		event.DistanceMeters = &distance
+		event.GraceEndsAt = &graceEndsAt
+		event.Strikes = p.Strikes
+		return []Event{event}
+	}
+
+	if now.Sub(p.OutsideSince) < rules.GracePeriod {
+		return nil
+	}
+
+	// 猶予切れ: 違反を記録し、次の猶予期間を開始する
+	p.Strikes++
+	p.OutsideSince = now
+	p.HP -= rules.PenaltyHP
+	if p.HP < 0 {
+		p.HP = 0
+	}
+
+	hp := p.HP
+	graceEndsAt := now.Add(rules.GracePeriod)
+	event := s.newEvent(EventGeofencePenalty, &p.UserID, now)
+	event.DistanceMeters = &distance
+	event.GraceEndsAt = &graceEndsAt
+	event.Strikes = p.Strikes
+	event.HP = &hp
+	events := []Event{event}
+
+	if p.HP == 0 || (rules.MaxStrikes > 0 && p.Strikes >= rules.MaxStrikes) {
+		events = append(events, s.forfeit(p, now)...)
+	}
+
+	return events
+}
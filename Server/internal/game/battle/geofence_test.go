@@ -0,0 +1,154 @@
+package battle
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"server/internal/domain/battlestage"
+
+	"github.com/google/uuid"
+)
+
+// newActiveSession は 2 人が参加済みのテスト用セッションを作成します
+func newActiveSession(t *testing.T, now time.Time) (*Session, uuid.UUID, uuid.UUID) {
+	t.Helper()
+
+	arena := Arena{Center: battlestage.Location{Latitude: 35.0, Longitude: 139.0}, RadiusMeters: 50}
+	session := NewSession("stage-1", arena, now)
+
+	first, second := uuid.New(), uuid.New()
+	if _, err := session.Join(first, 100, 100, 100, now); err != nil {
+		t.Fatalf("failed to join first player: %v", err)
+	}
+	if _, err := session.Join(second, 100, 100, 100, now); err != nil {
+		t.Fatalf("failed to join second player: %v", err)
+	}
+	if session.Status != StatusActive {
+		t.Fatalf("expected session to be active, got %s", session.Status)
+	}
+
+	return session, first, second
+}
+
+// offset は基準点から北へ meters だけ離れた地点を返します
+func offset(meters float64) battlestage.Location {
+	return battlestage.Location{
+		Latitude:  35.0 + meters/battlestage.EarthRadiusMeters*180/math.Pi,
+		Longitude: 139.0,
+	}
+}
+
+func eventTypes(events []Event) []EventType {
+	types := make([]EventType, 0, len(events))
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+func TestSession_UpdatePosition_Geofence(t *testing.T) {
+	rules := GeofenceRules{Tolerance: 5, GracePeriod: 10 * time.Second, PenaltyHP: 30, MaxStrikes: 3}
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	session, player, opponent := newActiveSession(t, now)
+
+	// 半径 + 許容誤差以内は場内
+	events, err := session.UpdatePosition(player, offset(53), now, rules)
+	if err != nil || len(events) != 0 {
+		t.Fatalf("expected no events inside tolerance, got %v (err=%v)", eventTypes(events), err)
+	}
+
+	// 場外に出ると警告のみ
+	events, _ = session.UpdatePosition(player, offset(80), now.Add(time.Second), rules)
+	if len(events) != 1 || events[0].Type != EventGeofenceWarning {
+		t.Fatalf("expected geofence warning, got %v", eventTypes(events))
+	}
+
+	// 猶予期間中は何も起きない
+	events, _ = session.UpdatePosition(player, offset(80), now.Add(5*time.Second), rules)
+	if len(events) != 0 {
+		t.Fatalf("expected no events during grace period, got %v", eventTypes(events))
+	}
+
+	// 猶予切れで違反
+	events, _ = session.UpdatePosition(player, offset(80), now.Add(12*time.Second), rules)
+	if len(events) != 1 || events[0].Type != EventGeofencePenalty {
+		t.Fatalf("expected geofence penalty, got %v", eventTypes(events))
+	}
+	if p := session.Participants[player]; p.Strikes != 1 || p.HP != 70 {
+		t.Fatalf("expected 1 strike and 70 HP, got %d strikes and %d HP", p.Strikes, p.HP)
+	}
+
+	// 場内に戻ると解除
+	events, _ = session.UpdatePosition(player, offset(10), now.Add(13*time.Second), rules)
+	if len(events) != 1 || events[0].Type != EventGeofenceReturned {
+		t.Fatalf("expected geofence returned, got %v", eventTypes(events))
+	}
+	if session.Participants[player].OutOfBounds() {
+		t.Fatal("expected player to be back in bounds")
+	}
+
+	// 対戦相手の判定は独立している
+	if p := session.Participants[opponent]; p.Strikes != 0 || p.HP != 100 {
+		t.Fatalf("expected opponent untouched, got %d strikes and %d HP", p.Strikes, p.HP)
+	}
+}
+
+func TestSession_CheckGeofence_ForfeitAfterRepeatedViolations(t *testing.T) {
+	rules := GeofenceRules{GracePeriod: 10 * time.Second, PenaltyHP: 1, MaxStrikes: 2}
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	session, player, opponent := newActiveSession(t, now)
+
+	if _, err := session.UpdatePosition(player, offset(500), now, rules); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 位置更新が途絶えても定期チェックで違反になる
+	events := session.CheckGeofence(now.Add(10*time.Second), rules)
+	if len(events) != 1 || events[0].Type != EventGeofencePenalty {
+		t.Fatalf("expected first penalty, got %v", eventTypes(events))
+	}
+
+	events = session.CheckGeofence(now.Add(20*time.Second), rules)
+	expected := []EventType{EventGeofencePenalty, EventForfeit, EventSessionFinished}
+	if got := eventTypes(events); len(got) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	} else {
+		for i := range expected {
+			if got[i] != expected[i] {
+				t.Fatalf("expected %v, got %v", expected, got)
+			}
+		}
+	}
+
+	if session.Status != StatusFinished {
+		t.Fatalf("expected session finished, got %s", session.Status)
+	}
+	if session.WinnerID == nil || *session.WinnerID != opponent {
+		t.Fatalf("expected opponent to win, got %v", session.WinnerID)
+	}
+
+	if _, err := session.UpdatePosition(player, offset(0), now.Add(21*time.Second), rules); err != ErrSessionFinished {
+		t.Fatalf("expected ErrSessionFinished, got %v", err)
+	}
+}
+
+func TestSession_UpdatePosition_IgnoredBeforeStart(t *testing.T) {
+	rules := GeofenceRules{GracePeriod: time.Second, PenaltyHP: 10, MaxStrikes: 1}
+	now := time.Now()
+	session := NewSession("stage-1", Arena{Center: battlestage.Location{Latitude: 35.0, Longitude: 139.0}, RadiusMeters: 50}, now)
+
+	player := uuid.New()
+	if _, err := session.Join(player, 100, 100, 100, now); err != nil {
+		t.Fatalf("failed to join: %v", err)
+	}
+
+	events, err := session.UpdatePosition(player, offset(1000), now, rules)
+	if err != nil || len(events) != 0 {
+		t.Fatalf("expected position to be recorded without events, got %v (err=%v)", eventTypes(events), err)
+	}
+
+	if _, err := session.UpdatePosition(uuid.New(), offset(0), now, rules); err != ErrNotParticipant {
+		t.Fatalf("expected ErrNotParticipant, got %v", err)
+	}
+}
@@ -0,0 +1,354 @@
+package battle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"server/internal/apierror"
+	"server/internal/auth"
+	"server/internal/domain/battlestage"
+	"server/internal/domain/entities"
+	domainmagic "server/internal/domain/magic"
+	"server/internal/logging"
+	"server/internal/metrics"
+	"server/internal/request"
+	"server/internal/tracing"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	wsWriteWait  = 10 * time.Second
+	wsPongWait   = 60 * time.Second
+	wsPingPeriod = wsPongWait * 9 / 10
+	wsMaxMessage = 4096
+)
+
+// StageFinder はステージ ID からステージ形状を取得するインターフェースです
+type StageFinder interface {
+	FindByID(ctx context.Context, id string) (*battlestage.Stage, error)
+}
+
+// ReservationChecker は他のユーザーが予約している時間にステージで対戦を始めないよう確認するインターフェースです
+type ReservationChecker interface {
+	CheckReserved(ctx context.Context, stageID string, userID uuid.UUID) error
+}
+
+// PlayerRepository は参加者の初期 HP / MP を取得するためのインターフェースです
+type PlayerRepository interface {
+	GetPlayerByUserID(ctx context.Context, userID uuid.UUID) (*entities.Player, error)
+}
+
+// MagicTypeFinder は唱えられた魔法の定義を取得するためのインターフェースです
+type MagicTypeFinder interface {
+	Get(ctx context.Context, id string) (*domainmagic.MagicType, error)
+}
+
+// BattleHandler は対戦セッション関連の HTTP / WebSocket ハンドラーです
+type BattleHandler struct {
+	hub                *Hub
+	stages             StageFinder
+	reservations       ReservationChecker
+	playerRepo         PlayerRepository
+	magicTypes         MagicTypeFinder
+	hpRegen            entities.HPRegen
+	upgrader           websocket.Upgrader
+	defaultArenaRadius float64
+}
+
+// NewBattleHandler は新しい対戦ハンドラーを作成します。参加者は戦闘外の自然回復を加えた HP で参加します。
+// reservations が nil の場合は予約を確認せずに対戦を始めます。
+func NewBattleHandler(hub *Hub, stages StageFinder, reservations ReservationChecker, playerRepo PlayerRepository, magicTypes MagicTypeFinder, hpRegen entities.HPRegen, upgrader websocket.Upgrader, defaultArenaRadius float64) *BattleHandler {
+	return &BattleHandler{
+		hub:                hub,
+		stages:             stages,
+		reservations:       reservations,
+		playerRepo:         playerRepo,
+		magicTypes:         magicTypes,
+		hpRegen:            hpRegen,
+		upgrader:           upgrader,
+		defaultArenaRadius: defaultArenaRadius,
+	}
+}
+
+// CreateBattleRequest は対戦セッション作成リクエストです
+type CreateBattleRequest struct {
+	StageID string `json:"stageId" validate:"required,max=64"`
+}
+
+// Normalize は前後の空白を除きます
+func (r *CreateBattleRequest) Normalize() {
+	r.StageID = strings.TrimSpace(r.StageID)
+}
+
+// clientMessage はクライアントから WebSocket で届くメッセージです
+type clientMessage struct {
+	Type        string    `json:"type"`
+	Latitude    *float64  `json:"latitude"`
+	Longitude   *float64  `json:"longitude"`
+	MagicTypeID string    `json:"magicTypeId"`
+	TargetID    uuid.UUID `json:"targetId"`  // 魔法の対象（省略時は攻撃魔法は相手、回復魔法は本人）
+	ChantText   string    `json:"chantText"` // 音声認識で文字起こしした詠唱
+}
+
+// known はサーバーが処理する種類のメッセージかどうかを返します
+func (m clientMessage) known() bool {
+	switch m.Type {
+	case "position", "cast", "guard", "shield":
+		return true
+	}
+	return false
+}
+
+// HandleCreate はステージを指定して対戦セッションを作成し、作成者を参加させます。
+// ステージで対戦中・他のユーザーの予約と重なる・作成者が別の対戦に参加中の場合は 409 を返します。
+func (h *BattleHandler) HandleCreate(w http.ResponseWriter, r *http.Request) {
+	if h.stages == nil || h.playerRepo == nil {
+		apierror.Write(w, r, apierror.New(apierror.CodeServiceUnavailable, "Battle service unavailable"))
+		return
+	}
+
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, r, apierror.Internal(errors.New("user ID not found in context")))
+		return
+	}
+
+	var req CreateBattleRequest
+	if err := request.Decode(w, r, &req, request.DisallowUnknownFields()); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	stage, err := h.stages.FindByID(ctx, req.StageID)
+	if err != nil {
+		if errors.Is(err, battlestage.ErrStageNotFound) {
+			apierror.Write(w, r, apierror.New(apierror.CodeStageNotFound, "Stage not found"))
+			return
+		}
+		apierror.Write(w, r, apierror.Wrap(err, apierror.CodeUpstream, "Failed to load stage"))
+		return
+	}
+
+	if h.reservations != nil {
+		if err := h.reservations.CheckReserved(ctx, stage.ID, userID); err != nil {
+			if errors.Is(err, battlestage.ErrStageReserved) {
+				apierror.Write(w, r, apierror.New(apierror.CodeStageReserved, "Stage is reserved by another user"))
+				return
+			}
+			apierror.Write(w, r, apierror.Wrap(err, apierror.CodeUpstream, "Failed to check stage reservations"))
+			return
+		}
+	}
+
+	player, err := h.playerRepo.GetPlayerByUserID(ctx, userID)
+	if err != nil {
+		apierror.Write(w, r, apierror.Wrap(err, apierror.CodePlayerNotFound, "Player not found"))
+		return
+	}
+
+	snapshot, err := h.hub.CreateSession(stage.ID, NewArena(*stage, h.defaultArenaRadius), userID, player.CurrentHP(time.Now(), h.hpRegen), player.MaxHP, player.MP)
+	switch {
+	case errors.Is(err, ErrStageOccupied):
+		apierror.Write(w, r, apierror.New(apierror.CodeStageOccupied, "Stage is occupied by another battle"))
+		return
+	case errors.Is(err, ErrAlreadyInBattle):
+		apierror.Write(w, r, apierror.New(apierror.CodeAlreadyInBattle, "Already in another battle"))
+		return
+	case err != nil:
+		apierror.Write(w, r, apierror.Wrap(err, apierror.CodeInternal, "Failed to create battle"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(snapshot)
+}
+
+// HandleWebSocket は対戦セッションに参加し、位置情報の受信とイベント配信を行います。
+// クエリパラメータ sessionId で参加するセッションを指定します。
+func (h *BattleHandler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
+	if h.playerRepo == nil {
+		apierror.Write(w, r, apierror.New(apierror.CodeServiceUnavailable, "Battle service unavailable"))
+		return
+	}
+
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, r, apierror.Internal(errors.New("user ID not found in context")))
+		return
+	}
+
+	sessionID, err := uuid.Parse(r.URL.Query().Get("sessionId"))
+	if err != nil {
+		apierror.Write(w, r, apierror.New(apierror.CodeValidation, "sessionId is required"))
+		return
+	}
+
+	player, err := h.playerRepo.GetPlayerByUserID(r.Context(), userID)
+	if err != nil {
+		apierror.Write(w, r, apierror.Wrap(err, apierror.CodePlayerNotFound, "Player not found"))
+		return
+	}
+
+	// WebSocket にアップグレードできたリクエストだけを参加させる（参加できないことは先に HTTP のエラーで返す）
+	if err := h.hub.CanJoin(sessionID, userID); err != nil {
+		h.respondSessionError(w, r, err)
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// エラーレスポンスは Upgrader が書き込み済み
+		return
+	}
+
+	client := NewClient(userID)
+	_, err = h.hub.Join(sessionID, userID, player.CurrentHP(time.Now(), h.hpRegen), player.MaxHP, player.MP)
+	if err == nil {
+		err = h.hub.Subscribe(sessionID, client)
+	}
+	if err != nil {
+		// 確認の後に定員が埋まった・終了した場合は、理由を付けて接続を閉じる
+		conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, err.Error()), time.Now().Add(wsWriteWait))
+		conn.Close()
+		return
+	}
+
+	ctx := logging.With(r.Context(), "session_id", sessionID.String())
+	logging.FromContext(ctx).Info("battle: client connected")
+	defer metrics.WebSocketConnected("/ws/battle")()
+
+	go h.writePump(conn, client)
+	h.readPump(ctx, conn, sessionID, client)
+}
+
+// readPump はクライアントからのメッセージを処理します。終了時に購読を解除します。
+// ctx は接続時のリクエストの context で、メッセージごとのスパンはこのリクエストのスパンにリンクします。
+func (h *BattleHandler) readPump(ctx context.Context, conn *websocket.Conn, sessionID uuid.UUID, client *Client) {
+	defer func() {
+		h.hub.Unsubscribe(sessionID, client)
+		conn.Close()
+	}()
+
+	conn.SetReadLimit(wsMaxMessage)
+	conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		_, payload, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				logging.FromContext(ctx).Warn("battle: websocket read error", "error", err)
+			}
+			return
+		}
+
+		var msg clientMessage
+		if err := json.Unmarshal(payload, &msg); err != nil || !msg.known() {
+			continue
+		}
+
+		// 接続は長時間続くため、メッセージごとに新しいトレースを作り接続時のスパンにリンクする。
+		// スパン名は固定にし、メッセージの種類は属性で区別する
+		msgCtx, span := tracing.Start(ctx, "battle.ws.message",
+			trace.WithNewRoot(),
+			trace.WithLinks(trace.LinkFromContext(ctx)),
+			trace.WithAttributes(
+				attribute.String("battle.session_id", sessionID.String()),
+				attribute.String("battle.message_type", msg.Type),
+			),
+		)
+		err = h.handleMessage(msgCtx, sessionID, client, msg)
+		tracing.RecordError(span, err)
+		span.End()
+
+		if err != nil {
+			logging.FromContext(msgCtx).Warn("battle: failed to handle message", "type", msg.Type, "error", err)
+		}
+	}
+}
+
+// handleMessage は 1 件のクライアントメッセージを処理します。不正な内容のメッセージは無視します。
+func (h *BattleHandler) handleMessage(ctx context.Context, sessionID uuid.UUID, client *Client, msg clientMessage) error {
+	switch msg.Type {
+	case "position":
+		if msg.Latitude == nil || msg.Longitude == nil ||
+			*msg.Latitude < -90 || *msg.Latitude > 90 || *msg.Longitude < -180 || *msg.Longitude > 180 {
+			return nil
+		}
+		location := battlestage.Location{Latitude: *msg.Latitude, Longitude: *msg.Longitude}
+		if err := h.hub.UpdatePosition(sessionID, client.userID, location); err != nil && !errors.Is(err, ErrSessionFinished) {
+			return err
+		}
+	case "cast":
+		if h.magicTypes == nil || msg.MagicTypeID == "" {
+			return nil
+		}
+		magicType, err := h.magicTypes.Get(ctx, msg.MagicTypeID)
+		if errors.Is(err, domainmagic.ErrMagicTypeNotFound) {
+			return h.hub.Reject(sessionID, client.userID, msg.MagicTypeID, RejectUnknownMagicType)
+		}
+		if err != nil {
+			return err
+		}
+		return h.hub.Cast(sessionID, client.userID, *magicType, msg.TargetID, msg.ChantText)
+	case "guard", "shield":
+		return h.hub.Defend(sessionID, client.userID, DefenseKind(msg.Type))
+	}
+	return nil
+}
+
+// writePump は Hub からの配信メッセージと ping を送信します
+func (h *BattleHandler) writePump(conn *websocket.Conn, client *Client) {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer func() {
+		ticker.Stop()
+		conn.Close()
+	}()
+
+	for {
+		select {
+		case payload, ok := <-client.Send():
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if !ok {
+				conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (h *BattleHandler) respondSessionError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrSessionNotFound):
+		apierror.Write(w, r, apierror.New(apierror.CodeBattleSessionNotFound, "Battle session not found"))
+	case errors.Is(err, ErrSessionFull):
+		apierror.Write(w, r, apierror.New(apierror.CodeBattleSessionFull, "Battle session is full"))
+	case errors.Is(err, ErrSessionFinished):
+		apierror.Write(w, r, apierror.New(apierror.CodeBattleSessionFinished, "Battle session already finished"))
+	case errors.Is(err, ErrAlreadyInBattle):
+		apierror.Write(w, r, apierror.New(apierror.CodeAlreadyInBattle, "Already in another battle"))
+	default:
+		apierror.Write(w, r, apierror.Wrap(err, apierror.CodeInternal, "Failed to join battle"))
+	}
+}
@@ -0,0 +1,143 @@
+package battle
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"server/internal/auth"
+	"server/internal/domain/battlestage"
+	"server/internal/domain/entities"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type fakePlayers struct{}
+
+func (fakePlayers) GetPlayerByUserID(_ context.Context, userID uuid.UUID) (*entities.Player, error) {
+	return entities.NewPlayer(&userID, "player"), nil
+}
+
+func TestBattleHandler_WebSocketJoinsOnlyAfterUpgrade(t *testing.T) {
+	hub := NewHub(GeofenceRules{}, DefenseRules{}, SessionRules{}, nil, nil)
+	snapshot, err := hub.CreateSession("stage-1", Arena{RadiusMeters: 50}, uuid.New(), 100, 100, 100)
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	handler := NewBattleHandler(hub, nil, nil, fakePlayers{}, nil, entities.HPRegen{}, websocket.Upgrader{}, 50)
+
+	// ハンドシェイクのない GET はアップグレードに失敗し、参加もしない
+	req := httptest.NewRequest(http.MethodGet, "/ws/battle?sessionId="+snapshot.ID.String(), nil)
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserIDKey, uuid.New()))
+	rec := httptest.NewRecorder()
+	handler.HandleWebSocket(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", rec.Code)
+	}
+	if session, _ := hub.Session(snapshot.ID); len(session.Participants) != 1 || session.Status != StatusWaiting {
+		t.Fatalf("failed upgrade joined the session: %+v", session)
+	}
+}
+
+type fakeStages struct{}
+
+func (fakeStages) FindByID(_ context.Context, id string) (*battlestage.Stage, error) {
+	return &battlestage.Stage{ID: id}, nil
+}
+
+type fakeReservations map[string]bool
+
+func (f fakeReservations) CheckReserved(_ context.Context, stageID string, _ uuid.UUID) error {
+	if f[stageID] {
+		return battlestage.ErrStageReserved
+	}
+	return nil
+}
+
+func TestBattleHandler_CreateRejectsBusyStagesAndPlayers(t *testing.T) {
+	hub := NewHub(GeofenceRules{}, DefenseRules{}, SessionRules{}, nil, nil)
+	handler := NewBattleHandler(hub, fakeStages{}, fakeReservations{"reserved": true}, fakePlayers{}, nil, entities.HPRegen{}, websocket.Upgrader{}, 50)
+	creator, other := uuid.New(), uuid.New()
+
+	create := func(userID uuid.UUID, stageID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/battles", strings.NewReader(`{"stageId":"`+stageID+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req = req.WithContext(context.WithValue(req.Context(), auth.UserIDKey, userID))
+		rec := httptest.NewRecorder()
+		handler.HandleCreate(rec, req)
+		return rec
+	}
+
+	if rec := create(creator, "park"); rec.Code != http.StatusCreated {
+		t.Fatalf("create: status = %d (%s)", rec.Code, rec.Body.String())
+	}
+	testCases := []struct {
+		name    string
+		userID  uuid.UUID
+		stageID string
+		code    string
+	}{
+		{"occupied stage", other, "park", `"stage_occupied"`},
+		{"reserved by another user", other, "reserved", `"stage_reserved"`},
+		{"creator already in battle", creator, "beach", `"already_in_battle"`},
+	}
+	for _, tc := range testCases {
+		rec := create(tc.userID, tc.stageID)
+		if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), tc.code) {
+			t.Errorf("%s: status = %d (%s)", tc.name, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestBattleHandler_MessageSpansUseFixedName(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+
+	hub := NewHub(GeofenceRules{}, DefenseRules{}, SessionRules{}, nil, nil)
+	userID := uuid.New()
+	snapshot, err := hub.CreateSession("stage-1", Arena{RadiusMeters: 50}, userID, 100, 100, 100)
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	handler := NewBattleHandler(hub, nil, nil, fakePlayers{}, nil, entities.HPRegen{}, websocket.Upgrader{}, 50)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.HandleWebSocket(w, r.WithContext(context.WithValue(r.Context(), auth.UserIDKey, userID)))
+	}))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"?sessionId="+snapshot.ID.String(), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	// 未知の種類のメッセージはスパンを作らずに読み捨てる
+	for _, message := range []string{`{"type":"unknown-1"}`, `{"type":"unknown-2"}`, `{"type":"position","latitude":0,"longitude":0}`} {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	conn.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(recorder.Ended()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Name() != "battle.ws.message" {
+		t.Fatalf("spans = %d, want one battle.ws.message span", len(spans))
+	}
+	for _, attr := range spans[0].Attributes() {
+		if attr.Key == "battle.message_type" && attr.Value.AsString() != "position" {
+			t.Fatalf("battle.message_type = %q", attr.Value.AsString())
+		}
+	}
+}
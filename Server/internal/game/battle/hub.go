@@ -0,0 +1,501 @@
+package battle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"server/internal/domain/battlestage"
+	domainmagic "server/internal/domain/magic"
+	"server/internal/metrics"
+
+	"github.com/google/uuid"
+)
+
+// clientSendBuffer はクライアントごとの送信キューの長さです
+const clientSendBuffer = 32
+
+// Client はセッションを購読している WebSocket 接続です
+type Client struct {
+	userID uuid.UUID
+	send   chan []byte
+}
+
+// NewClient は購読クライアントを作成します
+func NewClient(userID uuid.UUID) *Client {
+	return &Client{userID: userID, send: make(chan []byte, clientSendBuffer)}
+}
+
+// Send は配信メッセージを受け取るチャネルを返します。Hub から外されると close されます。
+func (c *Client) Send() <-chan []byte {
+	return c.send
+}
+
+// Hub は進行中の対戦セッションと購読クライアントを管理し、イベントを配信します。
+// セッションはこのプロセスのメモリにだけ保持するため、ステージの占有や参加中の判定が正しいのはサーバーが 1 インスタンスの場合だけです。
+type Hub struct {
+	mu        sync.Mutex
+	sessions  map[uuid.UUID]*Session
+	clients   map[uuid.UUID]map[*Client]struct{}
+	rules     GeofenceRules
+	defense   DefenseRules
+	lifecycle SessionRules
+	recorder  EventRecorder
+	results   ResultSaver
+	now       func() time.Time
+
+	// Run のループが動いているかをヘルスチェックで確認するための値（UnixNano）
+	interval atomic.Int64
+	sweptAt  atomic.Int64
+}
+
+// NewHub は新しい Hub を作成します。recorder が nil の場合は対戦の経過を記録せず、
+// results が nil の場合は対戦を終えた参加者の HP / MP を保存しません。
+func NewHub(rules GeofenceRules, defense DefenseRules, lifecycle SessionRules, recorder EventRecorder, results ResultSaver) *Hub {
+	return &Hub{
+		sessions:  make(map[uuid.UUID]*Session),
+		clients:   make(map[uuid.UUID]map[*Client]struct{}),
+		rules:     rules,
+		defense:   defense,
+		lifecycle: lifecycle,
+		recorder:  recorder,
+		results:   results,
+		now:       time.Now,
+	}
+}
+
+// Run は ctx が終了するまで interval ごとに詠唱時間が過ぎた魔法の発動、場外参加者の猶予切れ、
+// 放置されたセッションと応答しない参加者を判定し、保持期間を過ぎた終了済みのセッションを削除します
+func (h *Hub) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	h.interval.Store(int64(interval))
+	h.sweptAt.Store(h.now().UnixNano())
+	defer h.sweptAt.Store(0)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.sweep()
+			h.sweptAt.Store(h.now().UnixNano())
+		}
+	}
+}
+
+// Check は Run のループが止まっていないかを確認します。
+// 止まっている場合、場外判定とペナルティが行われません。
+func (h *Hub) Check(context.Context) error {
+	sweptAt := h.sweptAt.Load()
+	if sweptAt == 0 {
+		return errors.New("battle hub is not running")
+	}
+	// ロック待ちなどで数回遅れるのは許容する
+	if since := h.now().Sub(time.Unix(0, sweptAt)); since > 3*time.Duration(h.interval.Load()) {
+		return fmt.Errorf("battle hub has not swept for %s", since.Round(time.Millisecond))
+	}
+	return nil
+}
+
+func (h *Hub) sweep() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := h.now()
+	for id, session := range h.sessions {
+		if session.Status == StatusFinished {
+			if now.Sub(*session.EndedAt) >= h.lifecycle.FinishedRetention {
+				h.removeSessionLocked(id)
+			}
+			continue
+		}
+		h.broadcastLocked(id, session.ResolveCasts(now))
+		h.broadcastLocked(id, session.CheckGeofence(now, h.rules))
+		h.broadcastLocked(id, session.CheckTimeouts(now, h.lifecycle))
+	}
+}
+
+// removeSessionLocked はセッションを削除し、購読中の接続を閉じます
+func (h *Hub) removeSessionLocked(sessionID uuid.UUID) {
+	for client := range h.clients[sessionID] {
+		h.removeClientLocked(sessionID, client)
+	}
+	delete(h.sessions, sessionID)
+}
+
+// CreateSession はステージ上に新しいセッションを作成し、作成者を参加させます。
+// ステージ上に終了していないセッションがある場合は ErrStageOccupied、作成者が別の対戦に参加中の場合は ErrAlreadyInBattle を返します。
+func (h *Hub) CreateSession(stageID string, arena Arena, creatorID uuid.UUID, creatorHP, creatorMaxHP, creatorMP int) (Snapshot, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.inBattleLocked(creatorID, uuid.Nil) {
+		return Snapshot{}, ErrAlreadyInBattle
+	}
+	for _, session := range h.sessions {
+		if session.StageID == stageID && session.Status != StatusFinished {
+			return Snapshot{}, ErrStageOccupied
+		}
+	}
+
+	now := h.now()
+	session := NewSession(stageID, arena, now)
+	if _, err := session.Join(creatorID, creatorHP, creatorMaxHP, creatorMP, now); err != nil {
+		return Snapshot{}, err
+	}
+	h.sessions[session.ID] = session
+	metrics.BattleCreated()
+
+	return session.Snapshot(), nil
+}
+
+// Join はセッションにプレイヤーを参加させます
+func (h *Hub) Join(sessionID, userID uuid.UUID, hp, maxHP, mp int) (Snapshot, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	session, ok := h.sessions[sessionID]
+	if !ok {
+		return Snapshot{}, ErrSessionNotFound
+	}
+	if h.inBattleLocked(userID, sessionID) {
+		return Snapshot{}, ErrAlreadyInBattle
+	}
+
+	events, err := session.Join(userID, hp, maxHP, mp, h.now())
+	if err != nil {
+		return Snapshot{}, err
+	}
+	h.broadcastLocked(sessionID, events)
+
+	return session.Snapshot(), nil
+}
+
+// CanJoin はセッションに参加できるかを、参加させずに確認します
+func (h *Hub) CanJoin(sessionID, userID uuid.UUID) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	session, ok := h.sessions[sessionID]
+	if !ok {
+		return ErrSessionNotFound
+	}
+	if h.inBattleLocked(userID, sessionID) {
+		return ErrAlreadyInBattle
+	}
+	return session.CanJoin(userID)
+}
+
+// Session はセッションの現在の状態を返します
+func (h *Hub) Session(sessionID uuid.UUID) (Snapshot, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	session, ok := h.sessions[sessionID]
+	if !ok {
+		return Snapshot{}, ErrSessionNotFound
+	}
+	return session.Snapshot(), nil
+}
+
+// UpdatePosition は参加者の位置を更新し、発生したジオフェンスイベントを配信します
+func (h *Hub) UpdatePosition(sessionID, userID uuid.UUID, location battlestage.Location) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	session, ok := h.sessions[sessionID]
+	if !ok {
+		return ErrSessionNotFound
+	}
+
+	now := h.now()
+	session.Touch(userID, now)
+	events, err := session.UpdatePosition(userID, location, now, h.rules)
+	if err != nil {
+		return err
+	}
+	h.broadcastLocked(sessionID, events)
+
+	return nil
+}
+
+// Cast は参加者の魔法を処理し、結果を配信します。詠唱時間のある魔法は時間が過ぎた時点で発動します。
+// 対戦開始前・MP 不足・再使用までの時間が残っている・対象にできない参加者を指定した場合などは、唱えた参加者にだけ cast_rejected を送ります。
+func (h *Hub) Cast(sessionID, userID uuid.UUID, magicType domainmagic.MagicType, targetID uuid.UUID, chantText string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	session, ok := h.sessions[sessionID]
+	if !ok {
+		return ErrSessionNotFound
+	}
+
+	now := h.now()
+	session.Touch(userID, now)
+	events, err := session.Cast(userID, magicType, targetID, chantText, now)
+	if err != nil {
+		rejected := session.newEvent(EventCastRejected, &userID, now)
+		rejected.MagicTypeID = magicType.ID
+		return h.rejectLocked(sessionID, userID, rejected, err)
+	}
+	h.broadcastLocked(sessionID, events)
+
+	// 定期処理の間隔を待たずに発動する（定期処理でも発動するため、タイマーが遅れても取りこぼさない）
+	if pending := session.Participants[userID].Casting; pending != nil {
+		time.AfterFunc(pending.ResolvesAt.Sub(now), func() { h.resolveCasts(sessionID) })
+	}
+
+	return nil
+}
+
+// Reject は唱えられなかったことを参加者にだけ通知します（存在しない魔法など）
+func (h *Hub) Reject(sessionID, userID uuid.UUID, magicTypeID string, reason RejectReason) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	session, ok := h.sessions[sessionID]
+	if !ok {
+		return ErrSessionNotFound
+	}
+	event := session.newEvent(EventCastRejected, &userID, h.now())
+	event.MagicTypeID = magicTypeID
+	event.Reason = reason
+	h.deliverLocked(sessionID, &userID, []Event{event})
+
+	return nil
+}
+
+// Defend は参加者のガード / シールドを処理し、結果を配信します。
+// 防御できない場合は本人にだけ defense_rejected を送ります。
+func (h *Hub) Defend(sessionID, userID uuid.UUID, kind DefenseKind) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	session, ok := h.sessions[sessionID]
+	if !ok {
+		return ErrSessionNotFound
+	}
+
+	now := h.now()
+	session.Touch(userID, now)
+	events, err := session.Defend(userID, kind, h.defense, now)
+	if err != nil {
+		rejected := session.newEvent(EventDefenseRejected, &userID, now)
+		rejected.Defense = kind
+		return h.rejectLocked(sessionID, userID, rejected, err)
+	}
+	h.broadcastLocked(sessionID, events)
+
+	return nil
+}
+
+// rejectLocked は err が本人に通知する拒否であれば、理由と再び行動できるまでの時間を入れた event を本人にだけ送ります。
+// 通知の対象でないエラー（セッションに参加していないなど）はそのまま返します。
+func (h *Hub) rejectLocked(sessionID, userID uuid.UUID, event Event, err error) error {
+	reason, remaining, ok := rejectReason(err)
+	if !ok {
+		return err
+	}
+	event.Reason = reason
+	if remaining > 0 {
+		// 切り捨てると早すぎる再送を招くため、ミリ秒単位に切り上げる
+		remainingMS := (remaining + time.Millisecond - 1).Milliseconds()
+		event.RemainingMS = &remainingMS
+	}
+	h.deliverLocked(sessionID, &userID, []Event{event})
+	return nil
+}
+
+// resolveCasts はセッションの詠唱時間が過ぎた魔法を発動し、結果を配信します
+func (h *Hub) resolveCasts(sessionID uuid.UUID) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if session, ok := h.sessions[sessionID]; ok {
+		h.broadcastLocked(sessionID, session.ResolveCasts(h.now()))
+	}
+}
+
+// Subscribe はクライアントをセッションのイベント配信先に登録します
+func (h *Hub) Subscribe(sessionID uuid.UUID, client *Client) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	session, ok := h.sessions[sessionID]
+	if !ok {
+		return ErrSessionNotFound
+	}
+	if _, ok := session.Participants[client.userID]; !ok {
+		return ErrNotParticipant
+	}
+
+	if h.clients[sessionID] == nil {
+		h.clients[sessionID] = make(map[*Client]struct{})
+	}
+	h.clients[sessionID][client] = struct{}{}
+	session.connect(client.userID, h.now())
+	return nil
+}
+
+// Unsubscribe はクライアントを配信先から外し、送信チャネルを閉じます。
+// 接続がなくなった参加者は SessionRules.DisconnectTimeout の後に失格になります。
+func (h *Hub) Unsubscribe(sessionID uuid.UUID, client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.removeClientLocked(sessionID, client)
+}
+
+func (h *Hub) removeClientLocked(sessionID uuid.UUID, client *Client) {
+	clients := h.clients[sessionID]
+	if _, ok := clients[client]; !ok {
+		return
+	}
+	delete(clients, client)
+	close(client.send)
+	if session, ok := h.sessions[sessionID]; ok {
+		session.disconnect(client.userID, h.now())
+	}
+	if len(clients) == 0 {
+		delete(h.clients, sessionID)
+	}
+}
+
+// broadcastLocked はイベントをセッションの購読者全員に送信し、記録に残すイベントと対戦を終えた参加者の HP / MP を保存します
+func (h *Hub) broadcastLocked(sessionID uuid.UUID, events []Event) {
+	h.recordLocked(sessionID, events)
+	h.saveResultsLocked(sessionID, events)
+	h.deliverLocked(sessionID, nil, events)
+}
+
+// recordLocked は記録に残すイベントを別の goroutine で保存します（ロックを持ったままデータベースを待たない）。
+// 保存に失敗しても対戦は続けます。
+func (h *Hub) recordLocked(sessionID uuid.UUID, events []Event) {
+	session, ok := h.sessions[sessionID]
+	if h.recorder == nil || !ok {
+		return
+	}
+	record, ok := session.record(events)
+	if !ok {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), recordTimeout)
+		defer cancel()
+		if err := h.recorder.RecordBattle(ctx, record); err != nil {
+			slog.Error("battle: failed to record events", "session_id", sessionID.String(), "error", err)
+		}
+	}()
+}
+
+// saveResultsLocked は失格・終了で対戦を終えた参加者の HP / MP を別の goroutine で保存します
+func (h *Hub) saveResultsLocked(sessionID uuid.UUID, events []Event) {
+	session, ok := h.sessions[sessionID]
+	if h.results == nil || !ok {
+		return
+	}
+	results := session.results(events)
+	if len(results) == 0 {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), recordTimeout)
+		defer cancel()
+		for _, result := range results {
+			if err := h.results.SaveBattleResult(ctx, result.UserID, result.HP, result.MP, result.At); err != nil {
+				slog.Error("battle: failed to save battle result", "session_id", sessionID.String(), "user_id", result.UserID.String(), "error", err)
+			}
+		}
+	}()
+}
+
+// deliverLocked はイベントを送信します。userID を指定した場合はそのユーザーの接続にだけ送ります。
+// 送信キューが詰まっているクライアントは切断扱いにします。
+func (h *Hub) deliverLocked(sessionID uuid.UUID, userID *uuid.UUID, events []Event) {
+	for _, event := range events {
+		metrics.BattleEvent(string(event.Type))
+
+		payload, err := json.Marshal(event)
+		if err != nil {
+			slog.Error("battle: failed to encode event", "event", event.Type, "error", err)
+			continue
+		}
+
+		for client := range h.clients[sessionID] {
+			if userID != nil && client.userID != *userID {
+				continue
+			}
+			select {
+			case client.send <- payload:
+			default:
+				slog.Warn("battle: dropping slow client", "user_id", client.userID.String(), "session_id", sessionID.String())
+				h.removeClientLocked(sessionID, client)
+			}
+		}
+	}
+}
+
+// BattleHP はユーザーが終了していないセッションに参加中（失格していない）であれば、その対戦での HP を返します
+func (h *Hub) BattleHP(userID uuid.UUID) (int, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, session := range h.sessions {
+		if session.Status == StatusFinished {
+			continue
+		}
+		if p, ok := session.Participants[userID]; ok && !p.Forfeited {
+			return p.HP, true
+		}
+	}
+	return 0, false
+}
+
+// inBattleLocked は except 以外の終了していないセッションにユーザーが参加中かを返します
+func (h *Hub) inBattleLocked(userID, except uuid.UUID) bool {
+	for _, session := range h.sessions {
+		if session.ID == except || session.Status == StatusFinished {
+			continue
+		}
+		if p, ok := session.Participants[userID]; ok && !p.Forfeited {
+			return true
+		}
+	}
+	return false
+}
+
+// StageOccupancy は指定ステージ上で終了していないセッションを返します。
+// このプロセスの Hub が管理するセッションだけが対象です。放置された待機中のセッションは SessionRules.JoinTimeout で終了するまで含まれます。
+func (h *Hub) StageOccupancy(stageIDs []string) map[string][]battlestage.Occupancy {
+	wanted := make(map[string]struct{}, len(stageIDs))
+	for _, id := range stageIDs {
+		wanted[id] = struct{}{}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	result := make(map[string][]battlestage.Occupancy)
+	for _, session := range h.sessions {
+		if session.Status == StatusFinished {
+			continue
+		}
+		if _, ok := wanted[session.StageID]; !ok {
+			continue
+		}
+		result[session.StageID] = append(result[session.StageID], battlestage.Occupancy{
+			SessionID: session.ID.String(),
+			Status:    string(session.Status),
+			StartedAt: session.StartedAt,
+			CreatedAt: session.CreatedAt,
+		})
+	}
+	return result
+}
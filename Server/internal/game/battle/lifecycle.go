@@ -0,0 +1,87 @@
+package battle
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SessionRules は放置されたセッションと応答しない参加者の扱いです。
+// JoinTimeout・DisconnectTimeout・IdleTimeout が 0 の場合はその判定をしません。
+type SessionRules struct {
+	JoinTimeout       time.Duration // 相手が参加しないまま待機できる時間
+	DisconnectTimeout time.Duration // 接続がない状態が続くと失格になるまでの時間
+	IdleTimeout       time.Duration // 接続したままメッセージを送らない参加者が失格になるまでの時間
+	FinishedRetention time.Duration // 終了したセッションを Hub に残す時間
+}
+
+// Touch は参加者から最後にメッセージを受け取った時刻を更新します
+func (s *Session) Touch(userID uuid.UUID, now time.Time) {
+	if p, ok := s.Participants[userID]; ok {
+		p.LastSeenAt = now
+	}
+}
+
+// connect / disconnect は参加者の接続数を更新します。接続の開始と終了もメッセージとして扱います。
+func (s *Session) connect(userID uuid.UUID, now time.Time) {
+	if p, ok := s.Participants[userID]; ok {
+		p.Connections++
+		p.LastSeenAt = now
+	}
+}
+
+func (s *Session) disconnect(userID uuid.UUID, now time.Time) {
+	if p, ok := s.Participants[userID]; ok && p.Connections > 0 {
+		p.Connections--
+		p.LastSeenAt = now
+	}
+}
+
+// CheckTimeouts は放置されたセッションを終了し、応答しない参加者を失格にします。Hub が定期的に呼び出します。
+// 待機中のセッションは JoinTimeout を過ぎるか、参加者全員の接続がない状態が DisconnectTimeout 続くと勝者なしで終了します。
+// 対戦中は接続がない状態が DisconnectTimeout、接続したままメッセージがない状態が IdleTimeout 続いた参加者を失格にします。
+func (s *Session) CheckTimeouts(now time.Time, rules SessionRules) []Event {
+	switch s.Status {
+	case StatusWaiting:
+		if rules.JoinTimeout > 0 && now.Sub(s.CreatedAt) >= rules.JoinTimeout {
+			return s.expire(now)
+		}
+		for _, p := range s.Participants {
+			if !p.disconnectedFor(now, rules) {
+				return nil
+			}
+		}
+		return s.expire(now)
+	case StatusActive:
+		var events []Event
+		for _, p := range s.Participants {
+			if p.Forfeited || !p.unresponsive(now, rules) {
+				continue
+			}
+			events = append(events, s.forfeit(p, now)...)
+			if s.Status != StatusActive {
+				break
+			}
+		}
+		return events
+	}
+	return nil
+}
+
+// expire は相手がそろわないまま放置されたセッションを勝者なしで終了します
+func (s *Session) expire(now time.Time) []Event {
+	s.Status = StatusFinished
+	s.EndedAt = &now
+	return []Event{s.newEvent(EventSessionFinished, nil, now)}
+}
+
+func (p *Participant) disconnectedFor(now time.Time, rules SessionRules) bool {
+	return p.Connections == 0 && rules.DisconnectTimeout > 0 && now.Sub(p.LastSeenAt) >= rules.DisconnectTimeout
+}
+
+func (p *Participant) unresponsive(now time.Time, rules SessionRules) bool {
+	if p.Connections == 0 {
+		return p.disconnectedFor(now, rules)
+	}
+	return rules.IdleTimeout > 0 && now.Sub(p.LastSeenAt) >= rules.IdleTimeout
+}
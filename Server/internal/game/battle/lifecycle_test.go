@@ -0,0 +1,101 @@
+package battle
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var testSessionRules = SessionRules{
+	JoinTimeout:       5 * time.Minute,
+	DisconnectTimeout: 30 * time.Second,
+	IdleTimeout:       2 * time.Minute,
+	FinishedRetention: time.Minute,
+}
+
+func TestSession_CheckTimeouts_WaitingSessionExpires(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	session := NewSession("stage-1", Arena{RadiusMeters: 50}, now)
+	creator := uuid.New()
+	session.Join(creator, 100, 100, 100, now)
+	session.connect(creator, now)
+
+	if events := session.CheckTimeouts(now.Add(4*time.Minute), testSessionRules); len(events) != 0 {
+		t.Fatalf("expired before join timeout: %v", eventTypes(events))
+	}
+	events := session.CheckTimeouts(now.Add(5*time.Minute), testSessionRules)
+	if !reflect.DeepEqual(eventTypes(events), []EventType{EventSessionFinished}) || session.Status != StatusFinished || session.WinnerID != nil {
+		t.Fatalf("join timeout: %v, status = %s", eventTypes(events), session.Status)
+	}
+
+	// 作成者が接続しないまま（または切断したまま）のセッションは参加を待たずに終了する
+	abandoned := NewSession("stage-1", Arena{RadiusMeters: 50}, now)
+	abandoned.Join(creator, 100, 100, 100, now)
+	if events := abandoned.CheckTimeouts(now.Add(30*time.Second), testSessionRules); abandoned.Status != StatusFinished {
+		t.Fatalf("abandoned session: %v, status = %s", eventTypes(events), abandoned.Status)
+	}
+}
+
+func TestSession_CheckTimeouts_ForfeitsUnresponsiveParticipants(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// 切断したまま DisconnectTimeout が過ぎると失格
+	session, first, second := newActiveSession(t, now)
+	session.connect(first, now)
+	session.connect(second, now)
+	session.disconnect(second, now.Add(10*time.Second))
+	if events := session.CheckTimeouts(now.Add(30*time.Second), testSessionRules); len(events) != 0 {
+		t.Fatalf("forfeited before disconnect timeout: %v", eventTypes(events))
+	}
+	events := session.CheckTimeouts(now.Add(40*time.Second), testSessionRules)
+	if !reflect.DeepEqual(eventTypes(events), []EventType{EventForfeit, EventSessionFinished}) || *events[0].UserID != second || *session.WinnerID != first {
+		t.Fatalf("disconnect: %+v", events)
+	}
+
+	// 接続したままでもメッセージが IdleTimeout 途絶えると失格
+	session, first, second = newActiveSession(t, now)
+	session.connect(first, now)
+	session.connect(second, now)
+	session.Touch(first, now.Add(time.Minute))
+	events = session.CheckTimeouts(now.Add(2*time.Minute), testSessionRules)
+	if !reflect.DeepEqual(eventTypes(events), []EventType{EventForfeit, EventSessionFinished}) || *events[0].UserID != second {
+		t.Fatalf("idle: %+v", events)
+	}
+}
+
+func TestHub_SweepRemovesFinishedSessions(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	hub := NewHub(GeofenceRules{}, DefenseRules{}, testSessionRules, nil, nil)
+	hub.now = func() time.Time { return now }
+
+	creator := uuid.New()
+	snapshot, err := hub.CreateSession("stage-1", Arena{RadiusMeters: 50}, creator, 100, 100, 100)
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	client := NewClient(creator)
+	if err := hub.Subscribe(snapshot.ID, client); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	now = now.Add(5 * time.Minute)
+	hub.sweep()
+	if session, err := hub.Session(snapshot.ID); err != nil || session.Status != StatusFinished {
+		t.Fatalf("after join timeout: %+v (err=%v)", session, err)
+	}
+	if _, ok := hub.BattleHP(creator); ok {
+		t.Fatal("creator of an expired session is still in battle")
+	}
+
+	now = now.Add(time.Minute)
+	hub.sweep()
+	if _, err := hub.Session(snapshot.ID); err != ErrSessionNotFound {
+		t.Fatalf("finished session was not removed: err = %v", err)
+	}
+	<-client.Send() // session_finished
+	if _, ok := <-client.Send(); ok {
+		t.Fatal("client of a removed session was not closed")
+	}
+}
@@ -0,0 +1,101 @@
+package battle
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// recordTimeout は 1 回の記録の保存を待つ時間の上限です
+const recordTimeout = 5 * time.Second
+
+// EventRecorder は対戦の経過を game_sessions / game_events に保存するインターフェースです
+type EventRecorder interface {
+	RecordBattle(ctx context.Context, record Record) error
+}
+
+// EventCategory は記録するイベントの分類です（game_event_category と同じ値）
+type EventCategory string
+
+const (
+	CategoryAttack EventCategory = "attack"
+	CategoryHeal   EventCategory = "heal"
+	CategoryGuard  EventCategory = EventCategory(DefenseGuard)
+	CategoryShield EventCategory = EventCategory(DefenseShield)
+)
+
+// RecordType は記録するイベントの種類です（game_event_type と同じ値）
+type RecordType string
+
+const (
+	RecordFire   RecordType = "fire"   // 魔法が発動した
+	RecordDefend RecordType = "defend" // ガード / シールドを張った
+	RecordBreak  RecordType = "break"  // シールドの吸収量を使い切った
+)
+
+// Record は記録する時点のセッションの状態とイベントです。
+// イベントがなくても、対戦が始まったセッションの終了は状態を更新するために記録します。
+type Record struct {
+	SessionID uuid.UUID
+	StageID   string
+	Status    Status
+	StartedAt *time.Time
+	EndedAt   *time.Time
+	Events    []RecordedEvent
+}
+
+// RecordedEvent は game_events の 1 行です。参加者はユーザー ID で、保存するときにプレイヤーの ID に置き換えます。
+type RecordedEvent struct {
+	TriggerID   uuid.UUID  // 行動した参加者（shield_broken はシールドを張っていた参加者）
+	TargetID    *uuid.UUID // 魔法の対象
+	TriggerHP   *int
+	TargetHP    *int
+	Category    EventCategory
+	Type        RecordType
+	MagicTypeID string
+	Amount      *int // 与えたダメージ・回復量・張ったシールドの吸収量
+	Mitigated   *int // 防御が防いだダメージ
+	At          time.Time
+}
+
+// record は配信するイベントのうち記録に残すもの（spell_cast・defense_started・shield_broken）を Record にします。
+// 記録するものがなければ false を返します。
+func (s *Session) record(events []Event) (Record, bool) {
+	record := Record{SessionID: s.ID, StageID: s.StageID, Status: s.Status, StartedAt: s.StartedAt, EndedAt: s.EndedAt}
+	finished := false
+	for _, event := range events {
+		if event.Type == EventSessionFinished {
+			finished = true
+		}
+		if event.UserID == nil {
+			continue
+		}
+
+		recorded := RecordedEvent{TriggerID: *event.UserID, MagicTypeID: event.MagicTypeID, At: event.At}
+		switch event.Type {
+		case EventSpellCast:
+			recorded.Type = RecordFire
+			recorded.Category, recorded.Amount = CategoryAttack, event.Damage
+			if event.Healed != nil {
+				recorded.Category, recorded.Amount = CategoryHeal, event.Healed
+			}
+			recorded.TargetID, recorded.TargetHP = event.TargetID, event.HP
+			recorded.Mitigated = event.Mitigated
+		case EventDefenseStarted:
+			recorded.Type = RecordDefend
+			recorded.Category, recorded.Amount = EventCategory(event.Defense), event.ShieldRemaining
+		case EventShieldBroken:
+			recorded.Type = RecordBreak
+			recorded.Category = CategoryShield
+		default:
+			continue
+		}
+		if p, ok := s.Participants[*event.UserID]; ok {
+			hp := p.HP
+			recorded.TriggerHP = &hp
+		}
+		record.Events = append(record.Events, recorded)
+	}
+	return record, len(record.Events) > 0 || (finished && s.StartedAt != nil)
+}
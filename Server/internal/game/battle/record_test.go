@@ -0,0 +1,74 @@
+package battle
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	domainmagic "server/internal/domain/magic"
+
+	"github.com/google/uuid"
+)
+
+type fakeRecorder chan Record
+
+func (f fakeRecorder) RecordBattle(_ context.Context, record Record) error {
+	f <- record
+	return nil
+}
+
+func TestHub_RecordsDefenseAndSpellEvents(t *testing.T) {
+	recorder := make(fakeRecorder, 8)
+	hub := NewHub(GeofenceRules{}, testDefenseRules, SessionRules{}, recorder, nil)
+	caster, defender := uuid.New(), uuid.New()
+	snapshot, err := hub.CreateSession("stage-1", Arena{RadiusMeters: 50}, caster, 100, 100, 100)
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	if _, err := hub.Join(snapshot.ID, defender, 100, 100, 100); err != nil {
+		t.Fatalf("join: %v", err)
+	}
+
+	heal := domainmagic.MagicType{ID: "heal", Kind: domainmagic.KindHeal, Target: domainmagic.TargetSelf, MPCost: 10, Heal: 25, Chant: "癒やしよ", ChantMatch: domainmagic.ChantExact}
+	if err := hub.Defend(snapshot.ID, defender, DefenseShield); err != nil {
+		t.Fatalf("shield: %v", err)
+	}
+	if err := hub.Cast(snapshot.ID, caster, testFireball, uuid.Nil, testFireball.Chant); err != nil {
+		t.Fatalf("cast: %v", err)
+	}
+	if err := hub.Cast(snapshot.ID, defender, heal, uuid.Nil, heal.Chant); err != nil {
+		t.Fatalf("heal: %v", err)
+	}
+
+	// 記録は別の goroutine で保存されるため、届いた順序は問わない
+	recorded := make(map[RecordType]map[EventCategory]RecordedEvent)
+	for range 3 {
+		select {
+		case record := <-recorder:
+			if record.SessionID != snapshot.ID || record.StageID != "stage-1" || record.Status != StatusActive {
+				t.Fatalf("record = %+v", record)
+			}
+			for _, event := range record.Events {
+				if recorded[event.Type] == nil {
+					recorded[event.Type] = make(map[EventCategory]RecordedEvent)
+				}
+				recorded[event.Type][event.Category] = event
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for records")
+		}
+	}
+
+	if e := recorded[RecordDefend][CategoryShield]; e.TriggerID != defender || *e.Amount != testDefenseRules.ShieldAbsorb {
+		t.Errorf("defense_started = %+v", e)
+	}
+	if e := recorded[RecordFire][CategoryAttack]; e.TriggerID != caster || *e.TargetID != defender || *e.Amount != 20 || *e.Mitigated != 40 || *e.TargetHP != 80 || e.MagicTypeID != "fireball" {
+		t.Errorf("attack spell_cast = %+v", e)
+	}
+	if e := recorded[RecordBreak][CategoryShield]; e.TriggerID != defender {
+		t.Errorf("shield_broken = %+v", e)
+	}
+	if e := recorded[RecordFire][CategoryHeal]; e.TriggerID != defender || *e.TargetID != defender || *e.Amount != 20 || *e.TargetHP != 100 {
+		t.Errorf("heal spell_cast = %+v", e)
+	}
+}
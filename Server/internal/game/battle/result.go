@@ -0,0 +1,43 @@
+package battle
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ResultSaver は対戦を終えた参加者の HP / MP をプレイヤーに保存するインターフェースです。
+// 保存した時刻 at から戦闘外の自然回復が始まります。
+type ResultSaver interface {
+	SaveBattleResult(ctx context.Context, userID uuid.UUID, hp, mp int, at time.Time) error
+}
+
+// Result は対戦を終えた参加者の HP / MP です
+type Result struct {
+	UserID uuid.UUID
+	HP     int
+	MP     int
+	At     time.Time
+}
+
+// results は失格した参加者と、セッションが終了した時点で残っていた参加者の HP / MP を返します。
+// 対戦が始まらないまま終了したセッションは HP / MP が変わっていないため返しません。
+func (s *Session) results(events []Event) []Result {
+	var results []Result
+	for _, event := range events {
+		switch {
+		case event.Type == EventForfeit && event.UserID != nil:
+			if p, ok := s.Participants[*event.UserID]; ok {
+				results = append(results, Result{UserID: p.UserID, HP: p.HP, MP: p.MP, At: event.At})
+			}
+		case event.Type == EventSessionFinished && s.StartedAt != nil:
+			for _, p := range s.Participants {
+				if !p.Forfeited {
+					results = append(results, Result{UserID: p.UserID, HP: p.HP, MP: p.MP, At: event.At})
+				}
+			}
+		}
+	}
+	return results
+}
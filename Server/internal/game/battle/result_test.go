@@ -0,0 +1,69 @@
+package battle
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type fakeResults chan Result
+
+func (f fakeResults) SaveBattleResult(_ context.Context, userID uuid.UUID, hp, mp int, at time.Time) error {
+	f <- Result{UserID: userID, HP: hp, MP: mp, At: at}
+	return nil
+}
+
+func TestHub_SavesResultsWhenParticipantsLeaveTheBattle(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	results := make(fakeResults, 4)
+	hub := NewHub(GeofenceRules{}, DefenseRules{}, testSessionRules, nil, results)
+	hub.now = func() time.Time { return now }
+
+	// 相手が参加しないまま終了したセッションは HP / MP が変わらないため保存しない
+	waiting, err := hub.CreateSession("stage-1", Arena{RadiusMeters: 50}, uuid.New(), 100, 100, 100)
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	now = now.Add(testSessionRules.JoinTimeout)
+	hub.sweep()
+	if session, _ := hub.Session(waiting.ID); session.Status != StatusFinished {
+		t.Fatalf("waiting session was not expired: %s", session.Status)
+	}
+
+	caster, target := uuid.New(), uuid.New()
+	snapshot, err := hub.CreateSession("stage-2", Arena{RadiusMeters: 50}, caster, 70, 100, 100)
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	if _, err := hub.Join(snapshot.ID, target, 50, 100, 100); err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	now = now.Add(time.Second)
+	if err := hub.Cast(snapshot.ID, caster, testFireball, uuid.Nil, testFireball.Chant); err != nil {
+		t.Fatalf("cast: %v", err)
+	}
+
+	// 失格した相手と、終了時に残っていた唱えた参加者の HP / MP を終了時刻で保存する
+	saved := make(map[uuid.UUID]Result)
+	for range 2 {
+		select {
+		case result := <-results:
+			saved[result.UserID] = result
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for results: %+v", saved)
+		}
+	}
+	if r := saved[target]; r.HP != 0 || r.MP != 100 || !r.At.Equal(now) {
+		t.Errorf("forfeited participant = %+v", r)
+	}
+	if r := saved[caster]; r.HP != 70 || r.MP != 60 || !r.At.Equal(now) {
+		t.Errorf("winner = %+v", r)
+	}
+	select {
+	case result := <-results:
+		t.Fatalf("unexpected result: %+v", result)
+	default:
+	}
+}
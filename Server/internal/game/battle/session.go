@@ -0,0 +1,250 @@
+package battle
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"server/internal/domain/battlestage"
+
+	"github.com/google/uuid"
+)
+
+// MaxParticipants は 1 セッションに参加できるプレイヤー数です（1 対 1）
+const MaxParticipants = 2
+
+var (
+	// ErrSessionNotFound は指定したセッションが存在しない場合のエラーです
+	ErrSessionNotFound = errors.New("battle session not found")
+	// ErrSessionFull はセッションの参加枠が埋まっている場合のエラーです
+	ErrSessionFull = errors.New("battle session is full")
+	// ErrSessionFinished は終了済みセッションへの操作のエラーです
+	ErrSessionFinished = errors.New("battle session already finished")
+	// ErrNotParticipant はセッションに参加していないユーザーの操作のエラーです
+	ErrNotParticipant = errors.New("user is not a participant of this session")
+	// ErrSessionNotActive は対戦開始前のセッションでの行動のエラーです
+	ErrSessionNotActive = errors.New("battle session is not active")
+	// ErrInsufficientMP は MP が魔法のコストに足りない場合のエラーです
+	ErrInsufficientMP = errors.New("insufficient mp")
+	// ErrOnCooldown は魔法の再使用までの時間が残っている場合のエラーです
+	ErrOnCooldown = errors.New("magic type is on cooldown")
+	// ErrAlreadyCasting は別の魔法の詠唱時間の途中で唱えた場合のエラーです
+	ErrAlreadyCasting = errors.New("already casting")
+	// ErrStunned は行動不能の間に唱えた場合のエラーです
+	ErrStunned = errors.New("participant is stunned")
+	// ErrInvalidTarget は魔法の対象にできない参加者を指定した場合のエラーです
+	ErrInvalidTarget = errors.New("invalid spell target")
+	// ErrStageOccupied はステージ上に終了していないセッションがある場合のエラーです
+	ErrStageOccupied = errors.New("stage is occupied by another battle")
+	// ErrAlreadyInBattle は別のセッションに参加中のユーザーが対戦を始めようとした場合のエラーです
+	ErrAlreadyInBattle = errors.New("user is already in another battle")
+)
+
+// Status はセッションの進行状態です（game_sessions.status と同じ値）
+type Status string
+
+const (
+	StatusWaiting  Status = "waiting"
+	StatusActive   Status = "active"
+	StatusFinished Status = "finished"
+)
+
+// Arena は対戦エリアとなる円形の領域です
+type Arena struct {
+	Center       battlestage.Location
+	RadiusMeters float64
+}
+
+// NewArena はステージ情報からアリーナを作成します。
+// ステージに radius_m が設定されていない場合は defaultRadius を使用します。
+func NewArena(stage battlestage.Stage, defaultRadius float64) Arena {
+	radius := defaultRadius
+	if stage.RadiusMeters != nil && *stage.RadiusMeters > 0 {
+		radius = *stage.RadiusMeters
+	}
+	return Arena{Center: stage.Location, RadiusMeters: radius}
+}
+
+// Participant はセッション参加者の対戦中の状態です
+type Participant struct {
+	UserID       uuid.UUID
+	HP           int
+	MaxHP        int // 回復魔法で回復できる上限
+	MP           int
+	Position     *battlestage.Location
+	PositionAt   time.Time
+	OutsideSince time.Time // 場外に出た時刻（場内にいる場合はゼロ値）
+	Strikes      int       // ジオフェンス違反回数
+	Forfeited    bool
+	Cooldowns    Cooldowns
+	Casting      *PendingCast // 詠唱時間の途中の魔法（なければ nil）
+	StunnedUntil time.Time    // この時刻まで行動できない
+	Defense      *Defense     // 有効なガード / シールド（なければ nil）
+	Connections  int          // 購読中の WebSocket 接続の数
+	LastSeenAt   time.Time    // 最後にメッセージを受け取った時刻（参加・接続・切断を含む）
+}
+
+// OutOfBounds は参加者が場外にいるかを返します
+func (p *Participant) OutOfBounds() bool {
+	return !p.OutsideSince.IsZero()
+}
+
+// Session は対戦セッションです。ステージの形状を保持し、参加者の位置を検証します。
+// Session 自体は排他制御を持たないため、Hub のロック下で操作してください。
+type Session struct {
+	ID           uuid.UUID
+	StageID      string
+	Arena        Arena
+	Status       Status
+	Participants map[uuid.UUID]*Participant
+	WinnerID     *uuid.UUID
+	CreatedAt    time.Time
+	StartedAt    *time.Time
+	EndedAt      *time.Time
+}
+
+// NewSession は待機状態のセッションを作成します
+func NewSession(stageID string, arena Arena, now time.Time) *Session {
+	return &Session{
+		ID:           uuid.New(),
+		StageID:      stageID,
+		Arena:        arena,
+		Status:       StatusWaiting,
+		Participants: make(map[uuid.UUID]*Participant),
+		CreatedAt:    now,
+	}
+}
+
+// Join はプレイヤーをセッションに追加します。定員に達した時点で対戦を開始します。
+// 既に参加済みの場合は何もせず nil を返します（再接続）。
+func (s *Session) Join(userID uuid.UUID, hp, maxHP, mp int, now time.Time) ([]Event, error) {
+	if err := s.CanJoin(userID); err != nil {
+		return nil, err
+	}
+	if _, exists := s.Participants[userID]; exists {
+		return nil, nil
+	}
+
+	s.Participants[userID] = &Participant{UserID: userID, HP: hp, MaxHP: max(maxHP, hp), MP: mp, Cooldowns: make(Cooldowns), LastSeenAt: now}
+	events := []Event{s.newEvent(EventPlayerJoined, &userID, now)}
+
+	if len(s.Participants) == MaxParticipants {
+		s.Status = StatusActive
+		s.StartedAt = &now
+		events = append(events, s.newEvent(EventSessionStarted, nil, now))
+	}
+
+	return events, nil
+}
+
+// CanJoin はプレイヤーがセッションに参加（参加済みなら再接続）できるかを返します
+func (s *Session) CanJoin(userID uuid.UUID) error {
+	if s.Status == StatusFinished {
+		return ErrSessionFinished
+	}
+	if _, exists := s.Participants[userID]; !exists && len(s.Participants) >= MaxParticipants {
+		return ErrSessionFull
+	}
+	return nil
+}
+
+// forfeit は参加者を失格にし、残り 1 人になった場合はセッションを終了します
+func (s *Session) forfeit(p *Participant, now time.Time) []Event {
+	if p.Forfeited {
+		return nil
+	}
+	p.Forfeited = true
+	events := []Event{s.newEvent(EventForfeit, &p.UserID, now)}
+
+	var remaining []*Participant
+	for _, other := range s.Participants {
+		if !other.Forfeited {
+			remaining = append(remaining, other)
+		}
+	}
+
+	if len(remaining) <= 1 {
+		s.Status = StatusFinished
+		s.EndedAt = &now
+		if len(remaining) == 1 {
+			winner := remaining[0].UserID
+			s.WinnerID = &winner
+		}
+		finished := s.newEvent(EventSessionFinished, nil, now)
+		finished.WinnerID = s.WinnerID
+		events = append(events, finished)
+	}
+
+	return events
+}
+
+func (s *Session) newEvent(eventType EventType, userID *uuid.UUID, now time.Time) Event {
+	return Event{Type: eventType, SessionID: s.ID, UserID: userID, At: now}
+}
+
+// Snapshot はセッション状態の読み取り専用コピーです（API レスポンス用）
+type Snapshot struct {
+	ID           uuid.UUID             `json:"id"`
+	StageID      string                `json:"stageId"`
+	Status       Status                `json:"status"`
+	Arena        ArenaSnapshot         `json:"arena"`
+	Participants []ParticipantSnapshot `json:"participants"`
+	WinnerID     *uuid.UUID            `json:"winnerId,omitempty"`
+	CreatedAt    time.Time             `json:"createdAt"`
+	StartedAt    *time.Time            `json:"startedAt,omitempty"`
+	EndedAt      *time.Time            `json:"endedAt,omitempty"`
+}
+
+// ArenaSnapshot はアリーナ形状のレスポンス表現です
+type ArenaSnapshot struct {
+	Latitude     float64 `json:"latitude"`
+	Longitude    float64 `json:"longitude"`
+	RadiusMeters float64 `json:"radiusMeters"`
+}
+
+// ParticipantSnapshot は参加者状態のレスポンス表現です
+type ParticipantSnapshot struct {
+	UserID      uuid.UUID `json:"userId"`
+	HP          int       `json:"hp"`
+	MaxHP       int       `json:"maxHp"`
+	MP          int       `json:"mp"`
+	Strikes     int       `json:"strikes"`
+	OutOfBounds bool      `json:"outOfBounds"`
+	Forfeited   bool      `json:"forfeited"`
+}
+
+// Snapshot は現在の状態をコピーして返します
+func (s *Session) Snapshot() Snapshot {
+	snapshot := Snapshot{
+		ID:      s.ID,
+		StageID: s.StageID,
+		Status:  s.Status,
+		Arena: ArenaSnapshot{
+			Latitude:     s.Arena.Center.Latitude,
+			Longitude:    s.Arena.Center.Longitude,
+			RadiusMeters: s.Arena.RadiusMeters,
+		},
+		Participants: make([]ParticipantSnapshot, 0, len(s.Participants)),
+		WinnerID:     s.WinnerID,
+		CreatedAt:    s.CreatedAt,
+		StartedAt:    s.StartedAt,
+		EndedAt:      s.EndedAt,
+	}
+
+	for _, p := range s.Participants {
+		snapshot.Participants = append(snapshot.Participants, ParticipantSnapshot{
+			UserID:      p.UserID,
+			HP:          p.HP,
+			MaxHP:       p.MaxHP,
+			MP:          p.MP,
+			Strikes:     p.Strikes,
+			OutOfBounds: p.OutOfBounds(),
+			Forfeited:   p.Forfeited,
+		})
+	}
+	sort.Slice(snapshot.Participants, func(i, j int) bool {
+		return snapshot.Participants[i].UserID.String() < snapshot.Participants[j].UserID.String()
+	})
+
+	return snapshot
+}
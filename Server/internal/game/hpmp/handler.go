@@ -3,11 +3,15 @@ package hpmp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
+	"server/internal/apierror"
 	"server/internal/auth"
 	"server/internal/domain/entities"
+	"server/internal/request"
 
 	"github.com/google/uuid"
 )
@@ -20,21 +24,33 @@ type PlayerRepository interface {
 	UpdatePlayerMP(ctx context.Context, playerID uuid.UUID, mp int) error
 }
 
+// BattleChecker は対戦中のユーザーの HP を返すインターフェースです（対戦中は対戦の HP を返し、自然回復しない）
+type BattleChecker interface {
+	BattleHP(userID uuid.UUID) (int, bool)
+}
+
 // HPMPHandler はHP/MP関連のHTTPハンドラーです
 type HPMPHandler struct {
 	playerRepo PlayerRepository
+	regen      entities.HPRegen
+	battles    BattleChecker
+	now        func() time.Time
 }
 
-// NewHPMPHandler は新しいHP/MPハンドラーを作成します
-func NewHPMPHandler(playerRepo PlayerRepository) *HPMPHandler {
+// NewHPMPHandler は新しいHP/MPハンドラーを作成します。battles が nil の場合は常に戦闘外として扱います。
+func NewHPMPHandler(playerRepo PlayerRepository, regen entities.HPRegen, battles BattleChecker) *HPMPHandler {
 	return &HPMPHandler{
 		playerRepo: playerRepo,
+		regen:      regen,
+		battles:    battles,
+		now:        time.Now,
 	}
 }
 
 // HPResponse HP取得レスポンス
 type HPResponse struct {
-	HP int `json:"hp"`
+	HP    int `json:"hp"`
+	MaxHP int `json:"max_hp"`
 }
 
 // MPResponse MP取得レスポンス
@@ -42,43 +58,34 @@ type MPResponse struct {
 	MP int `json:"mp"`
 }
 
-// UpdateHPRequest HP更新リクエスト
+// UpdateHPRequest HP更新リクエスト（0 も有効な値のため、未指定と区別できるようポインターにする）。
+// 上限はプレイヤーごとの最大HP（entities.MaxHPLimit 以下）でハンドラーが確認します。
 type UpdateHPRequest struct {
-	HP int `json:"hp"`
+	HP *int `json:"hp" validate:"required,min=0"`
 }
 
 // UpdateMPRequest MP更新リクエスト
 type UpdateMPRequest struct {
-	MP int `json:"mp"`
+	MP *int `json:"mp" validate:"required,min=0,max=1000"`
 }
 
 // HandleGetHP はログインしているユーザーのHPを取得します
 func (h *HPMPHandler) HandleGetHP(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	player, err := h.getCurrentPlayer(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		apierror.Write(w, r, err)
 		return
 	}
 
-	response := HPResponse{HP: player.HP}
+	response := HPResponse{HP: h.currentHP(player), MaxHP: player.MaxHP}
 	h.respondJSON(w, response)
 }
 
 // HandleGetMP はログインしているユーザーのMPを取得します
 func (h *HPMPHandler) HandleGetMP(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	player, err := h.getCurrentPlayer(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		apierror.Write(w, r, err)
 		return
 	}
 
@@ -86,29 +93,19 @@ func (h *HPMPHandler) HandleGetMP(w http.ResponseWriter, r *http.Request) {
 	h.respondJSON(w, response)
 }
 
-// HandleUpdateHP はログインしているユーザーのHPを更新します
+// HandleUpdateHP はログインしているユーザーのHPを更新します。
+// HP を増やせるのは回復魔法と自然回復だけのため、現在の HP（自然回復を含む）より大きい値と最大HPを超える値は受け付けません。
 func (h *HPMPHandler) HandleUpdateHP(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPut {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	// 認証ミドルウェアからユーザーIDを取得
 	userID, ok := auth.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusInternalServerError)
+		apierror.Write(w, r, apierror.Internal(errors.New("user ID not found in context")))
 		return
 	}
 
 	var req UpdateHPRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
-		return
-	}
-
-	// HP値のバリデーション
-	if req.HP < 0 || req.HP > 1000 {
-		http.Error(w, "HP must be between 0 and 1000", http.StatusBadRequest)
+	if err := request.Decode(w, r, &req, request.DisallowUnknownFields(), request.ValidationCode(apierror.CodeInvalidHP)); err != nil {
+		apierror.Write(w, r, err)
 		return
 	}
 
@@ -117,46 +114,45 @@ func (h *HPMPHandler) HandleUpdateHP(w http.ResponseWriter, r *http.Request) {
 	// プレイヤーを取得
 	player, err := h.playerRepo.GetPlayerByUserID(ctx, userID)
 	if err != nil {
-		http.Error(w, "Player not found", http.StatusNotFound)
+		apierror.Write(w, r, apierror.Wrap(err, apierror.CodePlayerNotFound, "Player not found"))
+		return
+	}
+
+	if *req.HP > player.MaxHP {
+		apierror.Write(w, r, apierror.New(apierror.CodeInvalidHP, fmt.Sprintf("HP must not exceed max HP (%d)", player.MaxHP)))
+		return
+	}
+	if current := h.currentHP(player); *req.HP > current {
+		apierror.Write(w, r, apierror.New(apierror.CodeInvalidHP, fmt.Sprintf("HP cannot be raised above the current HP (%d)", current)))
 		return
 	}
 
-	// HPを更新
-	if err := h.playerRepo.UpdatePlayerHP(ctx, player.ID, req.HP); err != nil {
-		http.Error(w, "Failed to update HP", http.StatusInternalServerError)
+	// HPを更新（自然回復の起点もリセットされる）
+	if err := h.playerRepo.UpdatePlayerHP(ctx, player.ID, *req.HP); err != nil {
+		apierror.Write(w, r, apierror.Wrap(err, apierror.CodeInternal, "Failed to update HP"))
 		return
 	}
 
 	// レスポンスを返す
-	response := HPResponse(req)
+	response := HPResponse{HP: *req.HP, MaxHP: player.MaxHP}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
-// HandleUpdateMP はログインしているユーザーのMPを更新します
+// HandleUpdateMP はログインしているユーザーのMPを更新します。
+// HP と同じく、現在の MP より大きい値は受け付けません（MP を増やすのはサーバー側の処理だけ）。
 func (h *HPMPHandler) HandleUpdateMP(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPut {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	// 認証ミドルウェアからユーザーIDを取得
 	userID, ok := auth.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusInternalServerError)
+		apierror.Write(w, r, apierror.Internal(errors.New("user ID not found in context")))
 		return
 	}
 
 	var req UpdateMPRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
-		return
-	}
-
-	// MP値のバリデーション
-	if req.MP < 0 || req.MP > 1000 {
-		http.Error(w, "MP must be between 0 and 1000", http.StatusBadRequest)
+	if err := request.Decode(w, r, &req, request.DisallowUnknownFields(), request.ValidationCode(apierror.CodeInvalidMP)); err != nil {
+		apierror.Write(w, r, err)
 		return
 	}
 
@@ -165,33 +161,48 @@ func (h *HPMPHandler) HandleUpdateMP(w http.ResponseWriter, r *http.Request) {
 	// プレイヤーを取得
 	player, err := h.playerRepo.GetPlayerByUserID(ctx, userID)
 	if err != nil {
-		http.Error(w, "Player not found", http.StatusNotFound)
+		apierror.Write(w, r, apierror.Wrap(err, apierror.CodePlayerNotFound, "Player not found"))
+		return
+	}
+
+	if *req.MP > player.MP {
+		apierror.Write(w, r, apierror.New(apierror.CodeInvalidMP, fmt.Sprintf("MP cannot be raised above the current MP (%d)", player.MP)))
 		return
 	}
 
 	// MPを更新
-	if err := h.playerRepo.UpdatePlayerMP(ctx, player.ID, req.MP); err != nil {
-		http.Error(w, "Failed to update MP", http.StatusInternalServerError)
+	if err := h.playerRepo.UpdatePlayerMP(ctx, player.ID, *req.MP); err != nil {
+		apierror.Write(w, r, apierror.Wrap(err, apierror.CodeInternal, "Failed to update MP"))
 		return
 	}
 
 	// レスポンスを返す
-	response := MPResponse(req)
+	response := MPResponse{MP: *req.MP}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
+// currentHP はプレイヤーの現在の HP を返します。対戦中は対戦の HP、戦闘外であれば自然回復を加えた HP です。
+func (h *HPMPHandler) currentHP(player *entities.Player) int {
+	if h.battles != nil && player.UserID != nil {
+		if hp, ok := h.battles.BattleHP(*player.UserID); ok {
+			return hp
+		}
+	}
+	return player.CurrentHP(h.now(), h.regen)
+}
+
 // getCurrentPlayer は現在ログインしているユーザーのプレイヤーを取得します
 func (h *HPMPHandler) getCurrentPlayer(r *http.Request) (*entities.Player, error) {
 	userID, ok := auth.GetUserIDFromContext(r.Context())
 	if !ok {
-		return nil, fmt.Errorf("user ID not found in context")
+		return nil, apierror.Internal(errors.New("user ID not found in context"))
 	}
 
 	player, err := h.playerRepo.GetPlayerByUserID(r.Context(), userID)
 	if err != nil {
-		return nil, fmt.Errorf("player not found")
+		return nil, apierror.Wrap(err, apierror.CodePlayerNotFound, "Player not found")
 	}
 
 	return player, nil
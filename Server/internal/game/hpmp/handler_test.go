@@ -15,13 +15,15 @@ import (
 	"github.com/google/uuid"
 )
 
-// createTestPlayer はテスト用のプレイヤーを作成します
+// createTestPlayer はテスト用のプレイヤーを作成します（最大HPは上限の 1000）
 func createTestPlayer(userID uuid.UUID, hp, mp int) *entities.Player {
 	return &entities.Player{
 		ID:          uuid.New(),
 		UserID:      &userID,
 		DisplayName: "Test Player",
 		HP:          hp,
+		MaxHP:       entities.MaxHPLimit,
+		HPUpdatedAt: time.Now(),
 		MP:          mp,
 		Rank:        0,
 		CreatedAt:   time.Now(),
@@ -34,7 +36,7 @@ func TestHPMPHandler_HandleGetHP(t *testing.T) {
 	player := createTestPlayer(userID, 150, 200)
 	mockRepo := auth.NewMockPlayerRepository()
 	mockRepo.CreatePlayer(context.Background(), player)
-	handler := NewHPMPHandler(mockRepo)
+	handler := NewHPMPHandler(mockRepo, entities.HPRegen{}, nil)
 
 	req := httptest.NewRequest(http.MethodGet, "/api/hp", nil)
 	req = req.WithContext(context.WithValue(req.Context(), auth.UserIDKey, userID))
@@ -61,7 +63,7 @@ func TestHPMPHandler_HandleGetMP(t *testing.T) {
 	player := createTestPlayer(userID, 150, 200)
 	mockRepo := auth.NewMockPlayerRepository()
 	mockRepo.CreatePlayer(context.Background(), player)
-	handler := NewHPMPHandler(mockRepo)
+	handler := NewHPMPHandler(mockRepo, entities.HPRegen{}, nil)
 
 	req := httptest.NewRequest(http.MethodGet, "/api/mp", nil)
 	req = req.WithContext(context.WithValue(req.Context(), auth.UserIDKey, userID))
@@ -85,13 +87,14 @@ func TestHPMPHandler_HandleGetMP(t *testing.T) {
 
 func TestHPMPHandler_HandleUpdateHP(t *testing.T) {
 	userID := uuid.New()
-	player := createTestPlayer(userID, 100, 100)
+	player := createTestPlayer(userID, 300, 100)
 	mockRepo := auth.NewMockPlayerRepository()
 	mockRepo.CreatePlayer(context.Background(), player)
-	handler := NewHPMPHandler(mockRepo)
+	handler := NewHPMPHandler(mockRepo, entities.HPRegen{}, nil)
 
 	// リクエストボディを作成
-	updateReq := UpdateHPRequest{HP: 250}
+	hp := 250
+	updateReq := UpdateHPRequest{HP: &hp}
 	reqBody, _ := json.Marshal(updateReq)
 
 	// リクエストを作成
@@ -132,13 +135,14 @@ func TestHPMPHandler_HandleUpdateHP(t *testing.T) {
 
 func TestHPMPHandler_HandleUpdateMP(t *testing.T) {
 	userID := uuid.New()
-	player := createTestPlayer(userID, 100, 100)
+	player := createTestPlayer(userID, 100, 500)
 	mockRepo := auth.NewMockPlayerRepository()
 	mockRepo.CreatePlayer(context.Background(), player)
-	handler := NewHPMPHandler(mockRepo)
+	handler := NewHPMPHandler(mockRepo, entities.HPRegen{}, nil)
 
 	// リクエストボディを作成
-	updateReq := UpdateMPRequest{MP: 300}
+	mp := 300
+	updateReq := UpdateMPRequest{MP: &mp}
 	reqBody, _ := json.Marshal(updateReq)
 
 	// リクエストを作成
@@ -179,10 +183,10 @@ func TestHPMPHandler_HandleUpdateMP(t *testing.T) {
 
 func TestHPMPHandler_HandleUpdateHP_Validation(t *testing.T) {
 	userID := uuid.New()
-	player := createTestPlayer(userID, 100, 100)
+	player := createTestPlayer(userID, 1000, 100)
 	mockRepo := auth.NewMockPlayerRepository()
 	mockRepo.CreatePlayer(context.Background(), player)
-	handler := NewHPMPHandler(mockRepo)
+	handler := NewHPMPHandler(mockRepo, entities.HPRegen{}, nil)
 
 	testCases := []struct {
 		name         string
@@ -197,7 +201,7 @@ func TestHPMPHandler_HandleUpdateHP_Validation(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			// リクエストボディを作成
-			updateReq := UpdateHPRequest{HP: tc.hp}
+			updateReq := UpdateHPRequest{HP: &tc.hp}
 			reqBody, _ := json.Marshal(updateReq)
 
 			// リクエストを作成
@@ -219,19 +223,97 @@ func TestHPMPHandler_HandleUpdateHP_Validation(t *testing.T) {
 	}
 }
 
+// fakeBattles は対戦中のユーザーとその HP を返すテスト用の BattleChecker です
+type fakeBattles map[uuid.UUID]int
+
+func (f fakeBattles) BattleHP(userID uuid.UUID) (int, bool) {
+	hp, ok := f[userID]
+	return hp, ok
+}
+
+func TestHPMPHandler_MaxHPAndRegen(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	userID := uuid.New()
+	player := createTestPlayer(userID, 40, 100)
+	player.MaxHP = 120
+	player.HPUpdatedAt = now.Add(-2 * time.Minute)
+	mockRepo := auth.NewMockPlayerRepository()
+	mockRepo.CreatePlayer(context.Background(), player)
+	battles := fakeBattles{}
+	handler := NewHPMPHandler(mockRepo, entities.HPRegen{PerMinute: 10, Delay: 30 * time.Second}, battles)
+	handler.now = func() time.Time { return now }
+
+	getHP := func() HPResponse {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/hp", nil)
+		req = req.WithContext(context.WithValue(req.Context(), auth.UserIDKey, userID))
+		w := httptest.NewRecorder()
+		handler.HandleGetHP(w, req)
+		var response HPResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response
+	}
+	updateHP := func(hp int) int {
+		t.Helper()
+		reqBody, _ := json.Marshal(UpdateHPRequest{HP: &hp})
+		req := httptest.NewRequest(http.MethodPut, "/api/hp/update", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		req = req.WithContext(context.WithValue(req.Context(), auth.UserIDKey, userID))
+		w := httptest.NewRecorder()
+		handler.HandleUpdateHP(w, req)
+		return w.Code
+	}
+
+	// 待機時間 30 秒を除いた 1 分 30 秒で 15 回復する
+	if got := getHP(); got.HP != 55 || got.MaxHP != 120 {
+		t.Fatalf("Expected HP 55/120, got %d/%d", got.HP, got.MaxHP)
+	}
+
+	// 対戦中は対戦の HP を返し、自然回復しない
+	battles[userID] = 30
+	if got := getHP(); got.HP != 30 {
+		t.Fatalf("Expected battle HP 30 during battle, got %d", got.HP)
+	}
+	delete(battles, userID)
+
+	// 現在の HP や最大HPより大きい値には更新できない
+	for _, hp := range []int{56, 121} {
+		if code := updateHP(hp); code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d for HP %d, got %d", http.StatusBadRequest, hp, code)
+		}
+	}
+
+	// 更新すると自然回復の起点がリセットされる
+	if code := updateHP(50); code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, code)
+	}
+	updated, _ := mockRepo.GetPlayerByUserID(context.Background(), userID)
+	handler.now = func() time.Time { return updated.HPUpdatedAt.Add(20 * time.Second) }
+	if got := getHP(); got.HP != 50 {
+		t.Fatalf("Expected HP 50 before the regen delay, got %d", got.HP)
+	}
+	handler.now = func() time.Time { return updated.HPUpdatedAt.Add(time.Hour) }
+	if got := getHP(); got.HP != 120 {
+		t.Fatalf("Expected HP to regenerate up to max HP 120, got %d", got.HP)
+	}
+}
+
 func TestHPMPHandler_HandleUpdateMP_Validation(t *testing.T) {
 	userID := uuid.New()
 	player := createTestPlayer(userID, 100, 100)
 	mockRepo := auth.NewMockPlayerRepository()
 	mockRepo.CreatePlayer(context.Background(), player)
-	handler := NewHPMPHandler(mockRepo)
+	handler := NewHPMPHandler(mockRepo, entities.HPRegen{}, nil)
 
 	testCases := []struct {
 		name         string
 		mp           int
 		expectedCode int
 	}{
-		{"Valid MP", 500, http.StatusOK},
+		{"Valid MP", 40, http.StatusOK},
+		{"MP above current", 101, http.StatusBadRequest},
 		{"MP too high", 1001, http.StatusBadRequest},
 		{"MP negative", -1, http.StatusBadRequest},
 	}
@@ -239,7 +321,7 @@ func TestHPMPHandler_HandleUpdateMP_Validation(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			// リクエストボディを作成
-			updateReq := UpdateMPRequest{MP: tc.mp}
+			updateReq := UpdateMPRequest{MP: &tc.mp}
 			reqBody, _ := json.Marshal(updateReq)
 
 			// リクエストを作成
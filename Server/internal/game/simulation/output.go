@@ -0,0 +1,53 @@
+package simulation
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// WriteJSON は結果を JSON で書き出します
+func WriteJSON(w io.Writer, report Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// WriteCSV は結果の表（"spells" または "matchups"）を CSV で書き出します
+func WriteCSV(w io.Writer, report Report, table string) error {
+	var rows [][]string
+	switch table {
+	case "spells":
+		rows = append(rows, []string{"id", "name", "mp_cost", "damage", "heal", "duels", "wins", "win_rate", "avg_time_to_kill_s", "casts", "hits", "hit_rate", "fizzles", "interrupted", "stuns", "damage_dealt", "damage_mitigated", "hp_healed", "mp_spent", "mp_efficiency"})
+		for _, s := range report.Spells {
+			rows = append(rows, []string{
+				s.ID, s.Name, strconv.Itoa(s.MPCost), strconv.Itoa(s.Damage), strconv.Itoa(s.Heal),
+				strconv.Itoa(s.Duels), strconv.Itoa(s.Wins), formatFloat(s.WinRate), formatFloat(s.AvgTimeToKillSeconds),
+				strconv.Itoa(s.Casts), strconv.Itoa(s.Hits), formatFloat(s.HitRate), strconv.Itoa(s.Fizzles), strconv.Itoa(s.Interrupted), strconv.Itoa(s.Stuns),
+				formatFloat(s.DamageDealt), strconv.Itoa(s.DamageMitigated), formatFloat(s.HPHealed), strconv.Itoa(s.MPSpent), formatFloat(s.MPEfficiency),
+			})
+		}
+	case "matchups":
+		rows = append(rows, []string{"strategy", "opponent", "duels", "wins", "losses", "draws", "win_rate", "avg_time_to_kill_s"})
+		for _, m := range report.Matchups {
+			rows = append(rows, []string{
+				m.Strategy, m.Opponent, strconv.Itoa(m.Duels), strconv.Itoa(m.Wins), strconv.Itoa(m.Losses), strconv.Itoa(m.Draws),
+				formatFloat(m.WinRate), formatFloat(m.AvgTimeToKillSeconds),
+			})
+		}
+	default:
+		return fmt.Errorf("unknown table %q (use spells or matchups)", table)
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("write csv: %w", err)
+	}
+	return nil
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', 4, 64)
+}
@@ -0,0 +1,241 @@
+package simulation
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Options はシミュレーションの設定です
+type Options struct {
+	Rules      Rules
+	Spells     []Spell
+	Strategies []Strategy // 総当たりで対戦させる戦略
+	Duels      int        // 組み合わせごとの対戦回数
+	Seed       uint64
+}
+
+// Report はシミュレーションの結果です
+type Report struct {
+	Seed     uint64          `json:"seed"`
+	Duels    int             `json:"duelsPerMatchup"`
+	Rules    RulesReport     `json:"rules"`
+	Matchups []MatchupResult `json:"matchups"`
+	Spells   []SpellResult   `json:"spells"`
+}
+
+// RulesReport は結果に含めるルールです（時間は秒）
+type RulesReport struct {
+	StartHP               int           `json:"startHp"`
+	MaxHP                 int           `json:"maxHp"`
+	StartMP               int           `json:"startMp"`
+	Defense               DefenseReport `json:"defense"`
+	ChantErrorRate        float64       `json:"chantErrorRate"`
+	ActionIntervalSeconds float64       `json:"actionIntervalSeconds"`
+	MaxDurationSeconds    float64       `json:"maxDurationSeconds"`
+}
+
+// DefenseReport は結果に含める防御行動のルールです（時間は秒）
+type DefenseReport struct {
+	GuardMPCost           int     `json:"guardMpCost"`
+	GuardDurationSeconds  float64 `json:"guardDurationSeconds"`
+	GuardReduction        float64 `json:"guardReduction"`
+	ShieldMPCost          int     `json:"shieldMpCost"`
+	ShieldDurationSeconds float64 `json:"shieldDurationSeconds"`
+	ShieldAbsorb          int     `json:"shieldAbsorb"`
+}
+
+// MatchupResult は戦略同士の対戦成績です（Strategy から見た値）
+type MatchupResult struct {
+	Strategy             string  `json:"strategy"`
+	Opponent             string  `json:"opponent"`
+	Duels                int     `json:"duels"`
+	Wins                 int     `json:"wins"`
+	Losses               int     `json:"losses"`
+	Draws                int     `json:"draws"`
+	WinRate              float64 `json:"winRate"`
+	AvgTimeToKillSeconds float64 `json:"avgTimeToKillSeconds"` // 勝った対戦で相手を倒すまでの平均時間
+}
+
+// SpellResult は魔法ごとの成績です。
+// その魔法だけを唱える参加者（only:<id>）を、指定したすべての戦略と対戦させて集計します。
+type SpellResult struct {
+	ID                   string  `json:"id"`
+	Name                 string  `json:"name"`
+	MPCost               int     `json:"mpCost"`
+	Damage               int     `json:"damage"`
+	Heal                 int     `json:"heal"`
+	Duels                int     `json:"duels"`
+	Wins                 int     `json:"wins"`
+	WinRate              float64 `json:"winRate"`
+	AvgTimeToKillSeconds float64 `json:"avgTimeToKillSeconds"`
+	Casts                int     `json:"casts"`
+	Hits                 int     `json:"hits"`    // 発動した回数（spell_cast）
+	HitRate              float64 `json:"hitRate"` // 唱えた回数のうち発動した割合
+	Fizzles              int     `json:"fizzles"`
+	Interrupted          int     `json:"interrupted"`
+	Stuns                int     `json:"stuns"`
+	DamageDealt          float64 `json:"damageDealt"`
+	DamageMitigated      int     `json:"damageMitigated"` // 相手のガード / シールドに防がれたダメージ
+	HPHealed             float64 `json:"hpHealed"`
+	MPSpent              int     `json:"mpSpent"`
+	MPEfficiency         float64 `json:"mpEfficiency"` // MP 1 あたりのダメージ
+}
+
+// spellTally は 1 人の参加者が対戦中に唱えた魔法ごとの集計です
+type spellTally struct {
+	ID          string
+	Casts       int
+	Hits        int
+	Fizzles     int
+	Interrupted int
+	Stuns       int
+	MPSpent     int
+	Damage      float64
+	Mitigated   int
+	Healed      float64
+}
+
+func (t *spellTally) add(other *spellTally) {
+	t.Casts += other.Casts
+	t.Hits += other.Hits
+	t.Fizzles += other.Fizzles
+	t.Interrupted += other.Interrupted
+	t.Stuns += other.Stuns
+	t.MPSpent += other.MPSpent
+	t.Damage += other.Damage
+	t.Mitigated += other.Mitigated
+	t.Healed += other.Healed
+}
+
+// record は一方の参加者から見た対戦結果の集計です
+type record struct {
+	duels, wins, losses, draws int
+	killTime                   time.Duration
+}
+
+func (r *record) add(result duelResult, side int) {
+	r.duels++
+	switch result.winner {
+	case -1:
+		r.draws++
+	case side:
+		r.wins++
+		r.killTime += result.duration
+	default:
+		r.losses++
+	}
+}
+
+func (r *record) winRate() float64 {
+	return ratio(float64(r.wins), float64(r.duels))
+}
+
+func (r *record) avgTimeToKill() float64 {
+	return ratio(r.killTime.Seconds(), float64(r.wins))
+}
+
+// Run はすべての組み合わせで対戦させて結果を返します。同じ Seed なら同じ結果になります。
+func Run(options Options) Report {
+	random := rand.New(rand.NewPCG(options.Seed, options.Seed^0x9e3779b97f4a7c15))
+	rules := options.Rules
+	report := Report{
+		Seed:  options.Seed,
+		Duels: options.Duels,
+		Rules: RulesReport{
+			StartHP: rules.StartHP,
+			MaxHP:   max(rules.MaxHP, rules.StartHP),
+			StartMP: rules.StartMP,
+			Defense: DefenseReport{
+				GuardMPCost:           rules.Defense.GuardMPCost,
+				GuardDurationSeconds:  rules.Defense.GuardDuration.Seconds(),
+				GuardReduction:        rules.Defense.GuardReduction,
+				ShieldMPCost:          rules.Defense.ShieldMPCost,
+				ShieldDurationSeconds: rules.Defense.ShieldDuration.Seconds(),
+				ShieldAbsorb:          rules.Defense.ShieldAbsorb,
+			},
+			ChantErrorRate:        rules.ChantErrorRate,
+			ActionIntervalSeconds: rules.ActionInterval.Seconds(),
+			MaxDurationSeconds:    rules.MaxDuration.Seconds(),
+		},
+		Matchups: []MatchupResult{},
+		Spells:   []SpellResult{},
+	}
+
+	// 戦略の総当たり（a 対 b の結果から b 対 a の行も作る）
+	strategies := options.Strategies
+	for i := 0; i < len(strategies); i++ {
+		for j := i + 1; j < len(strategies); j++ {
+			var first, second record
+			for n := 0; n < options.Duels; n++ {
+				result := duel(rules, options.Spells, [2]Strategy{strategies[i], strategies[j]}, random)
+				first.add(result, 0)
+				second.add(result, 1)
+			}
+			report.Matchups = append(report.Matchups,
+				matchupResult(strategies[i], strategies[j], first),
+				matchupResult(strategies[j], strategies[i], second),
+			)
+		}
+	}
+
+	// 魔法ごとに、その魔法だけを唱える参加者を各戦略と対戦させる
+	for i := range options.Spells {
+		spell := &options.Spells[i]
+		only := onlyStrategy{spell: spell}
+		var rec record
+		tally := spellTally{ID: spell.ID}
+		for _, opponent := range strategies {
+			for n := 0; n < options.Duels; n++ {
+				result := duel(rules, options.Spells, [2]Strategy{only, opponent}, random)
+				rec.add(result, 0)
+				if t, ok := result.stats[0][spell.ID]; ok {
+					tally.add(t)
+				}
+			}
+		}
+		report.Spells = append(report.Spells, SpellResult{
+			ID:                   spell.ID,
+			Name:                 spell.Name,
+			MPCost:               spell.MPCost,
+			Damage:               spell.Damage,
+			Heal:                 spell.Heal,
+			Duels:                rec.duels,
+			Wins:                 rec.wins,
+			WinRate:              rec.winRate(),
+			AvgTimeToKillSeconds: rec.avgTimeToKill(),
+			Casts:                tally.Casts,
+			Hits:                 tally.Hits,
+			HitRate:              ratio(float64(tally.Hits), float64(tally.Casts)),
+			Fizzles:              tally.Fizzles,
+			Interrupted:          tally.Interrupted,
+			Stuns:                tally.Stuns,
+			DamageDealt:          tally.Damage,
+			DamageMitigated:      tally.Mitigated,
+			HPHealed:             tally.Healed,
+			MPSpent:              tally.MPSpent,
+			MPEfficiency:         ratio(tally.Damage, float64(tally.MPSpent)),
+		})
+	}
+
+	return report
+}
+
+func matchupResult(strategy, opponent Strategy, rec record) MatchupResult {
+	return MatchupResult{
+		Strategy:             strategy.Name(),
+		Opponent:             opponent.Name(),
+		Duels:                rec.duels,
+		Wins:                 rec.wins,
+		Losses:               rec.losses,
+		Draws:                rec.draws,
+		WinRate:              rec.winRate(),
+		AvgTimeToKillSeconds: rec.avgTimeToKill(),
+	}
+}
+
+func ratio(numerator, denominator float64) float64 {
+	if denominator == 0 {
+		return 0
+	}
+	return numerator / denominator
+}
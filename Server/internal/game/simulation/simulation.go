@@ -0,0 +1,255 @@
+// Package simulation は魔法のバランス調整のための 1 対 1（battle.MaxParticipants と同じ）の対戦シミュレーターです。
+//
+// 対戦はサーバーと同じ battle.Session で進め、仮想の時計で Cast・Defend・ResolveCasts を呼び出します。
+// 詠唱の正確さによるダメージの増減、再使用までの時間、詠唱時間と行動不能による中断、ガード / シールドは battle パッケージのルールに従います。
+// シミュレーター自身が決めるのはプレイヤーの振る舞い（戦略、詠唱の聞き取りの誤り、行動の間隔）と制限時間だけです。
+package simulation
+
+import (
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"server/internal/domain/entities"
+	domainmagic "server/internal/domain/magic"
+	"server/internal/game/battle"
+
+	"github.com/google/uuid"
+)
+
+// Spell はシミュレーションで使う魔法です
+type Spell struct {
+	domainmagic.MagicType
+}
+
+// Rules は対戦のルールです。防御行動はサーバーの設定（config.LoadBattle）と同じ値を渡します。
+type Rules struct {
+	StartHP        int
+	MaxHP          int // StartHP より小さい場合は StartHP を最大 HP とする
+	StartMP        int
+	Defense        battle.DefenseRules
+	ChantErrorRate float64       // 音声認識で詠唱の 1 文字が聞き取れずに抜け落ちる確率（プレイヤーの想定）
+	ActionInterval time.Duration // 行動してから次の行動を始めるまでの時間（プレイヤーの想定）
+	Tick           time.Duration // シミュレーションの時間の刻み
+	MaxDuration    time.Duration // これを超えると引き分け
+}
+
+// DefaultRules はサーバーのプレイヤーの初期値（entities.NewPlayer）に合わせたルールです
+func DefaultRules(defense battle.DefenseRules) Rules {
+	player := entities.NewPlayer(nil, "")
+	return Rules{
+		StartHP:        player.HP,
+		MaxHP:          player.MaxHP,
+		StartMP:        player.MP,
+		Defense:        defense,
+		ChantErrorRate: 0.05,
+		ActionInterval: 2 * time.Second,
+		Tick:           100 * time.Millisecond,
+		MaxDuration:    5 * time.Minute,
+	}
+}
+
+// duelist は対戦中の参加者のシミュレーター側の状態です（HP・MP などは battle.Participant が持つ）
+type duelist struct {
+	id           uuid.UUID
+	strategy     Strategy
+	nextActionAt time.Time
+	stats        map[string]*spellTally
+}
+
+func (d *duelist) spellStats(id string) *spellTally {
+	stats, ok := d.stats[id]
+	if !ok {
+		stats = &spellTally{ID: id}
+		d.stats[id] = stats
+	}
+	return stats
+}
+
+// State は戦略が行動を選ぶときに参照できる状態です
+type State struct {
+	HP, MP, OpponentHP int
+	Spells             []Spell // 再使用までの時間が過ぎている魔法
+	Defense            battle.DefenseRules
+	Defending          bool // ガード / シールドが有効
+	OpponentCasting    bool // 相手が詠唱時間の途中
+	Random             *rand.Rand
+}
+
+// Action は戦略が選んだ行動です。Spell と Defense のどちらか一方を指定します。
+type Action struct {
+	Spell   *Spell
+	Defense battle.DefenseKind
+}
+
+// duelResult は 1 回の対戦の結果です
+type duelResult struct {
+	winner   int // 0 または 1。引き分けは -1
+	duration time.Duration
+	stats    [2]map[string]*spellTally
+}
+
+// duel は 2 つの戦略で 1 回対戦します
+func duel(rules Rules, spells []Spell, strategies [2]Strategy, random *rand.Rand) duelResult {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	session := battle.NewSession("simulation", battle.Arena{}, start)
+
+	var duelists [2]*duelist
+	for i := range duelists {
+		// ResolveCasts が同時に発動する魔法を ID 順に処理するため、ID は対戦ごとに同じ値にする
+		duelists[i] = &duelist{id: uuid.UUID{byte(i + 1)}, strategy: strategies[i], nextActionAt: start, stats: map[string]*spellTally{}}
+		session.Join(duelists[i].id, rules.StartHP, rules.MaxHP, rules.StartMP, start)
+	}
+
+	for now := start; now.Sub(start) <= rules.MaxDuration; now = now.Add(rules.Tick) {
+		tally(duelists, session.ResolveCasts(now))
+		if result, done := finished(session, duelists, now.Sub(start)); done {
+			return result
+		}
+
+		// 同じ刻みで行動する順番は毎回ランダムに決める
+		order := [2]int{0, 1}
+		if random.IntN(2) == 1 {
+			order = [2]int{1, 0}
+		}
+		for _, i := range order {
+			act(rules, spells, session, duelists[i], duelists[1-i], now, random)
+			if result, done := finished(session, duelists, now.Sub(start)); done {
+				return result
+			}
+		}
+
+		if stalled(session, spells) {
+			break
+		}
+	}
+
+	return duelResult{winner: -1, duration: rules.MaxDuration, stats: [2]map[string]*spellTally{duelists[0].stats, duelists[1].stats}}
+}
+
+// act は行動できる参加者の戦略で行動を選び、セッションに送ります。
+// 行動不能・詠唱時間の途中・再使用までの時間・MP 不足でサーバーが拒否した場合は次の刻みで選び直します。
+func act(rules Rules, spells []Spell, session *battle.Session, self, opponent *duelist, now time.Time, random *rand.Rand) {
+	if now.Before(self.nextActionAt) {
+		return
+	}
+	me, them := session.Participants[self.id], session.Participants[opponent.id]
+
+	ready := make([]Spell, 0, len(spells))
+	for _, spell := range spells {
+		if me.Cooldowns.Remaining(spell.ID, now) == 0 {
+			ready = append(ready, spell)
+		}
+	}
+	action := self.strategy.Choose(State{
+		HP:              me.HP,
+		MP:              me.MP,
+		OpponentHP:      them.HP,
+		Spells:          ready,
+		Defense:         rules.Defense,
+		Defending:       me.Defending(now),
+		OpponentCasting: them.Casting != nil,
+		Random:          random,
+	})
+
+	var events []battle.Event
+	var err error
+	switch {
+	case action.Spell != nil:
+		events, err = session.Cast(self.id, action.Spell.MagicType, uuid.Nil, transcribe(action.Spell.Chant, rules.ChantErrorRate, random), now)
+		if err == nil {
+			stats := self.spellStats(action.Spell.ID)
+			stats.Casts++
+			stats.MPSpent += action.Spell.MPCost
+		}
+	case action.Defense != "":
+		events, err = session.Defend(self.id, action.Defense, rules.Defense, now)
+	default:
+		return
+	}
+	if err != nil {
+		return
+	}
+	self.nextActionAt = now.Add(rules.ActionInterval)
+	tally([2]*duelist{self, opponent}, events)
+}
+
+// transcribe はプレイヤーが唱えた詠唱の文字起こしです。各文字が errorRate の確率で抜け落ちます。
+func transcribe(chant string, errorRate float64, random *rand.Rand) string {
+	var b strings.Builder
+	for _, r := range chant {
+		if random.Float64() >= errorRate {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// tally はセッションのイベントを唱えた参加者の魔法ごとの成績に集計します
+func tally(duelists [2]*duelist, events []battle.Event) {
+	for _, event := range events {
+		if event.UserID == nil {
+			continue
+		}
+		var owner *duelist
+		for _, d := range duelists {
+			if d.id == *event.UserID {
+				owner = d
+			}
+		}
+		if owner == nil || event.MagicTypeID == "" {
+			continue
+		}
+
+		stats := owner.spellStats(event.MagicTypeID)
+		switch event.Type {
+		case battle.EventSpellCast:
+			stats.Hits++
+			if event.Damage != nil {
+				stats.Damage += float64(*event.Damage)
+			}
+			if event.Healed != nil {
+				stats.Healed += float64(*event.Healed)
+			}
+			if event.Mitigated != nil {
+				stats.Mitigated += *event.Mitigated
+			}
+			if event.StunEndsAt != nil {
+				stats.Stuns++
+			}
+		case battle.EventSpellFizzled:
+			stats.Fizzles++
+		case battle.EventSpellInterrupted:
+			stats.Interrupted++
+		}
+	}
+}
+
+// stalled は詠唱時間の途中の魔法がなく、どちらの参加者も MP が足りる魔法を持たない（以降 HP が変わらない）かを返します
+func stalled(session *battle.Session, spells []Spell) bool {
+	for _, p := range session.Participants {
+		if p.Casting != nil {
+			return false
+		}
+		for _, spell := range spells {
+			if spell.MPCost <= p.MP {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func finished(session *battle.Session, duelists [2]*duelist, elapsed time.Duration) (duelResult, bool) {
+	if session.Status != battle.StatusFinished {
+		return duelResult{}, false
+	}
+
+	result := duelResult{winner: -1, duration: elapsed, stats: [2]map[string]*spellTally{duelists[0].stats, duelists[1].stats}}
+	for i, d := range duelists {
+		if session.WinnerID != nil && *session.WinnerID == d.id {
+			result.winner = i
+		}
+	}
+	return result, true
+}
@@ -0,0 +1,161 @@
+package simulation
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	domainmagic "server/internal/domain/magic"
+	"server/internal/game/battle"
+)
+
+var testDefense = battle.DefenseRules{
+	GuardMPCost:    20,
+	GuardDuration:  3 * time.Second,
+	GuardReduction: 0.5,
+	ShieldMPCost:   30,
+	ShieldDuration: 5 * time.Second,
+	ShieldAbsorb:   40,
+}
+
+func testSpells() []Spell {
+	return []Spell{
+		{MagicType: domainmagic.MagicType{ID: "strong", Name: "強", Kind: domainmagic.KindAttack, Target: domainmagic.TargetEnemy, MPCost: 40, Damage: 60, Chant: "業火よ、すべてを焼き払え", ChantMatch: domainmagic.ChantFuzzy}},
+		{MagicType: domainmagic.MagicType{ID: "weak", Name: "弱", Kind: domainmagic.KindAttack, Target: domainmagic.TargetEnemy, MPCost: 40, Damage: 10, Chant: "そよ風よ、吹け", ChantMatch: domainmagic.ChantFuzzy}},
+	}
+}
+
+func mustStrategies(t *testing.T, spells []Spell, names ...string) []Strategy {
+	t.Helper()
+	strategies := make([]Strategy, 0, len(names))
+	for _, name := range names {
+		strategy, err := ParseStrategy(name, spells)
+		if err != nil {
+			t.Fatalf("ParseStrategy(%q): %v", name, err)
+		}
+		strategies = append(strategies, strategy)
+	}
+	return strategies
+}
+
+func TestRun_StrongerSpellWins(t *testing.T) {
+	spells := testSpells()
+	report := Run(Options{
+		Rules:      DefaultRules(testDefense),
+		Spells:     spells,
+		Strategies: mustStrategies(t, spells, "only:strong", "only:weak"),
+		Duels:      200,
+		Seed:       7,
+	})
+
+	if len(report.Matchups) != 2 {
+		t.Fatalf("matchups = %d, want 2", len(report.Matchups))
+	}
+	strong := report.Matchups[0]
+	if strong.Strategy != "only:strong" || strong.WinRate < 0.9 || strong.AvgTimeToKillSeconds <= 0 {
+		t.Fatalf("strong vs weak = %+v", strong)
+	}
+	if weak := report.Matchups[1]; weak.Wins != strong.Losses || weak.Losses != strong.Wins {
+		t.Fatalf("mirrored row = %+v, want the inverse of %+v", weak, strong)
+	}
+
+	for _, spell := range report.Spells {
+		if spell.MPSpent != spell.Casts*40 || spell.Hits > spell.Casts {
+			t.Fatalf("spell tally = %+v", spell)
+		}
+		if spell.MPEfficiency != spell.DamageDealt/float64(spell.MPSpent) {
+			t.Fatalf("%s: mp efficiency = %v", spell.ID, spell.MPEfficiency)
+		}
+	}
+	if report.Spells[0].WinRate <= report.Spells[1].WinRate {
+		t.Fatalf("strong win rate %v <= weak win rate %v", report.Spells[0].WinRate, report.Spells[1].WinRate)
+	}
+}
+
+func TestRun_IsReproducibleWithSeed(t *testing.T) {
+	spells := testSpells()
+	spells[1].Stun = time.Second
+	options := Options{
+		Rules:      DefaultRules(testDefense),
+		Spells:     spells,
+		Strategies: mustStrategies(t, spells, "random", "efficient"),
+		Duels:      50,
+		Seed:       42,
+	}
+
+	if first, second := Run(options), Run(options); !reflect.DeepEqual(first, second) {
+		t.Fatal("same seed produced different reports")
+	}
+}
+
+func TestRun_FizzledChantsEndInDraw(t *testing.T) {
+	spells := testSpells()
+	rules := DefaultRules(testDefense)
+	rules.ChantErrorRate = 1 // 詠唱がまったく聞き取れず、すべて不発になる
+
+	report := Run(Options{Rules: rules, Spells: spells, Strategies: mustStrategies(t, spells, "greedy", "random"), Duels: 10, Seed: 1})
+	if m := report.Matchups[0]; m.Draws != 10 || m.AvgTimeToKillSeconds != 0 {
+		t.Fatalf("matchup = %+v, want only draws", m)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	spells := testSpells()
+	report := Run(Options{Rules: DefaultRules(testDefense), Spells: spells, Strategies: mustStrategies(t, spells, "greedy"), Duels: 5, Seed: 1})
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, report, "spells"); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "id,name,mp_cost,damage,") || !strings.HasPrefix(lines[1], "strong,強,40,60,") {
+		t.Fatalf("csv = %q", buf.String())
+	}
+
+	if err := WriteCSV(&buf, report, "stages"); err == nil {
+		t.Fatal("unknown table was accepted")
+	}
+}
+
+func TestRun_RespectsCooldown(t *testing.T) {
+	spells := testSpells()[:1]
+	spells[0].Cooldown = time.Minute
+	rules := DefaultRules(testDefense)
+	rules.StartMP = 1000
+	rules.ChantErrorRate = 1
+	rules.MaxDuration = 90 * time.Second
+
+	report := Run(Options{Rules: rules, Spells: spells, Strategies: mustStrategies(t, spells, "greedy"), Duels: 3, Seed: 1})
+	// 不発でも再使用までの時間は始まるため、その魔法だけを唱える参加者は 1 対戦あたり 2 回（0 秒と 60 秒）まで
+	if casts := report.Spells[0].Casts; casts != 3*2 {
+		t.Fatalf("casts = %d, want %d", casts, 3*2)
+	}
+}
+
+func TestRun_FollowsBattleRules(t *testing.T) {
+	spells := testSpells()
+	spells[0].CastTime = time.Second
+	spells[1].Stun = 2 * time.Second
+	report := Run(Options{
+		Rules:      DefaultRules(testDefense),
+		Spells:     spells,
+		Strategies: mustStrategies(t, spells, "defensive", "only:weak"),
+		Duels:      100,
+		Seed:       3,
+	})
+
+	strong, weak := report.Spells[0], report.Spells[1]
+	// 詠唱時間のある魔法は相手の防御に防がれ、ダメージを与えた魔法の行動不能で中断される
+	if strong.DamageMitigated == 0 {
+		t.Fatalf("strong was never mitigated by guard or shield: %+v", strong)
+	}
+	if weak.Stuns == 0 || weak.Stuns > weak.Hits {
+		t.Fatalf("weak stuns = %d, hits = %d", weak.Stuns, weak.Hits)
+	}
+	// 聞き取りの誤りで満額にならなかった詠唱はダメージが減る
+	if weak.DamageDealt >= float64(weak.Hits*10) && weak.Fizzles == 0 {
+		t.Fatalf("chant accuracy never reduced damage: %+v", weak)
+	}
+}
@@ -0,0 +1,114 @@
+package simulation
+
+import (
+	"fmt"
+	"strings"
+
+	"server/internal/game/battle"
+)
+
+// Strategy は対戦中の行動を選びます。空の Action を返すとその刻みは何もしません。
+type Strategy interface {
+	Name() string
+	Choose(state State) Action
+}
+
+// ParseStrategy は名前から戦略を作成します。
+//
+//   - random: MP が足りる魔法からランダムに選ぶ
+//   - greedy: MP が足りる魔法のうちダメージが最大のもの
+//   - efficient: MP が足りる魔法のうち MP あたりのダメージが最大のもの
+//   - defensive: 相手が詠唱時間の途中ならシールド（MP が足りなければガード）を張り、それ以外は efficient と同じ
+//   - only:<id>: 指定した魔法だけを MP が貯まるたびに唱える
+func ParseStrategy(name string, spells []Spell) (Strategy, error) {
+	switch name {
+	case "random":
+		return randomStrategy{}, nil
+	case "greedy":
+		return rankedStrategy{name: name, score: damage}, nil
+	case "efficient":
+		return rankedStrategy{name: name, score: damagePerMP}, nil
+	case "defensive":
+		return defensiveStrategy{rankedStrategy{name: name, score: damagePerMP}}, nil
+	}
+
+	if id, ok := strings.CutPrefix(name, "only:"); ok {
+		for i := range spells {
+			if spells[i].ID == id {
+				return onlyStrategy{spell: &spells[i]}, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown magic type %q in strategy %q", id, name)
+	}
+	return nil, fmt.Errorf("unknown strategy %q (use random, greedy, efficient, defensive or only:<id>)", name)
+}
+
+func damage(s Spell) float64 { return float64(s.Damage) }
+
+func damagePerMP(s Spell) float64 { return float64(s.Damage) / float64(s.MPCost) }
+
+type randomStrategy struct{}
+
+func (randomStrategy) Name() string { return "random" }
+
+func (randomStrategy) Choose(state State) Action {
+	affordable := make([]*Spell, 0, len(state.Spells))
+	for i := range state.Spells {
+		if state.Spells[i].MPCost <= state.MP {
+			affordable = append(affordable, &state.Spells[i])
+		}
+	}
+	if len(affordable) == 0 {
+		return Action{}
+	}
+	return Action{Spell: affordable[state.Random.IntN(len(affordable))]}
+}
+
+// rankedStrategy は MP が足りる魔法のうち score が最大のものを選びます（同点は先に定義された魔法）
+type rankedStrategy struct {
+	name  string
+	score func(Spell) float64
+}
+
+func (s rankedStrategy) Name() string { return s.name }
+
+func (s rankedStrategy) Choose(state State) Action {
+	var best *Spell
+	for i := range state.Spells {
+		spell := &state.Spells[i]
+		if spell.MPCost > state.MP {
+			continue
+		}
+		if best == nil || s.score(*spell) > s.score(*best) {
+			best = spell
+		}
+	}
+	return Action{Spell: best}
+}
+
+// defensiveStrategy は相手の詠唱中の魔法を防御で受けます
+type defensiveStrategy struct {
+	rankedStrategy
+}
+
+func (s defensiveStrategy) Choose(state State) Action {
+	if state.OpponentCasting && !state.Defending {
+		switch {
+		case state.Defense.ShieldMPCost <= state.MP:
+			return Action{Defense: battle.DefenseShield}
+		case state.Defense.GuardMPCost <= state.MP:
+			return Action{Defense: battle.DefenseGuard}
+		}
+	}
+	return s.rankedStrategy.Choose(state)
+}
+
+type onlyStrategy struct {
+	spell *Spell
+}
+
+func (s onlyStrategy) Name() string { return "only:" + s.spell.ID }
+
+func (s onlyStrategy) Choose(state State) Action {
+	return Action{Spell: s.spell}
+}
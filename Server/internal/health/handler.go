@@ -0,0 +1,43 @@
+package health
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"server/internal/logging"
+)
+
+// Handler は登録済みのチェックを実行して結果を JSON で返します。
+// 必須の依存先が停止している場合は 503、任意の依存先のみの停止は 200 で status を degraded にします。
+func Handler(registry *Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := registry.Run(r.Context())
+
+		logger := logging.FromContext(r.Context())
+		for name, result := range report.Checks {
+			if result.Status != CheckOK {
+				logger.Warn("health check failed",
+					slog.String("check", name),
+					slog.Bool("critical", result.Critical),
+					slog.Any("error", result.Err()),
+				)
+			}
+		}
+
+		status := http.StatusOK
+		if report.Status == StatusUnavailable {
+			status = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		if r.Method == http.MethodHead {
+			return
+		}
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			logger.Error("failed to encode readiness report", "error", err)
+		}
+	})
+}
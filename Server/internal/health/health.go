@@ -0,0 +1,166 @@
+// Package health は依存先ごとのヘルスチェックを集約し、レディネスを判定します。
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout は Timeout を指定しなかったチェックのタイムアウトです
+const DefaultTimeout = 2 * time.Second
+
+// レディネス全体の状態
+const (
+	StatusOK          = "ok"          // すべての依存先が利用可能
+	StatusDegraded    = "degraded"    // 任意の依存先のみ停止している（リクエストは受け付ける）
+	StatusUnavailable = "unavailable" // 必須の依存先が停止している
+)
+
+// チェックごとの状態
+const (
+	CheckOK      = "ok"
+	CheckDown    = "down"
+	CheckTimeout = "timeout"
+)
+
+// Checker は依存先の疎通を確認します。利用できない場合はエラーを返します。
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc は関数を Checker として使うためのアダプターです
+type CheckerFunc func(ctx context.Context) error
+
+// Check は f(ctx) を呼びます
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Check は登録するヘルスチェックです
+type Check struct {
+	Name     string
+	Checker  Checker
+	Critical bool          // true の場合、停止時にレディネスを失敗させる
+	Timeout  time.Duration // 0 の場合は DefaultTimeout
+}
+
+// Result は 1 つのチェックの結果です。エラーの詳細は接続先などを含むためログにのみ出力します。
+type Result struct {
+	Status     string  `json:"status"`
+	Critical   bool    `json:"critical"`
+	DurationMs float64 `json:"durationMs"`
+
+	err error
+}
+
+// Err はチェックが失敗した原因を返します
+func (r Result) Err() error {
+	return r.err
+}
+
+// Report はすべてのチェックの結果です
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Registry はヘルスチェックを保持します
+type Registry struct {
+	mu     sync.RWMutex
+	checks []Check
+}
+
+// NewRegistry は空の Registry を作成します
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register はチェックを追加します。同じ名前のチェックは置き換えます。
+func (r *Registry) Register(check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.checks {
+		if r.checks[i].Name == check.Name {
+			r.checks[i] = check
+			return
+		}
+	}
+	r.checks = append(r.checks, check)
+}
+
+// Run はすべてのチェックを並行に実行して結果をまとめます
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := append([]Check(nil), r.checks...)
+	r.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = runCheck(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+	for i, check := range checks {
+		result := results[i]
+		report.Checks[check.Name] = result
+		if result.Status == CheckOK {
+			continue
+		}
+		if check.Critical {
+			report.Status = StatusUnavailable
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+// runCheck は 1 つのチェックをタイムアウト付きで実行します。
+// ctx を無視して戻らないチェックがあっても、タイムアウトで打ち切ります。
+func runCheck(ctx context.Context, check Check) Result {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- check.Checker.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := Result{
+		Status:     CheckOK,
+		Critical:   check.Critical,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+		err:        err,
+	}
+	switch {
+	case err == nil:
+	case errors.Is(err, context.DeadlineExceeded):
+		result.Status = CheckTimeout
+		result.err = fmt.Errorf("timed out after %s: %w", timeout, err)
+	default:
+		result.Status = CheckDown
+	}
+	return result
+}
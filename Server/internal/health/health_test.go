@@ -0,0 +1,105 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func ok(context.Context) error { return nil }
+
+func down(context.Context) error { return errors.New("connection refused") }
+
+func TestHandler_Status(t *testing.T) {
+	tests := []struct {
+		name       string
+		checks     []Check
+		wantCode   int
+		wantStatus string
+	}{
+		{
+			name: "all ok",
+			checks: []Check{
+				{Name: "database", Checker: CheckerFunc(ok), Critical: true},
+				{Name: "battle_hub", Checker: CheckerFunc(ok)},
+			},
+			wantCode:   http.StatusOK,
+			wantStatus: StatusOK,
+		},
+		{
+			name: "optional down",
+			checks: []Check{
+				{Name: "database", Checker: CheckerFunc(ok), Critical: true},
+				{Name: "battle_hub", Checker: CheckerFunc(down)},
+			},
+			wantCode:   http.StatusOK,
+			wantStatus: StatusDegraded,
+		},
+		{
+			name: "critical down",
+			checks: []Check{
+				{Name: "database", Checker: CheckerFunc(down), Critical: true},
+				{Name: "battle_hub", Checker: CheckerFunc(down)},
+			},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: StatusUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry()
+			for _, check := range tt.checks {
+				registry.Register(check)
+			}
+
+			rec := httptest.NewRecorder()
+			Handler(registry).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status code = %d, want %d", rec.Code, tt.wantCode)
+			}
+			var report Report
+			if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if report.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", report.Status, tt.wantStatus)
+			}
+			if len(report.Checks) != len(tt.checks) {
+				t.Errorf("checks = %d, want %d", len(report.Checks), len(tt.checks))
+			}
+		})
+	}
+}
+
+func TestRegistry_RunTimesOutHungCheck(t *testing.T) {
+	registry := NewRegistry()
+	// ctx を無視して戻らないチェック
+	hung := make(chan struct{})
+	defer close(hung)
+	registry.Register(Check{
+		Name:     "database",
+		Checker:  CheckerFunc(func(context.Context) error { <-hung; return nil }),
+		Critical: true,
+		Timeout:  20 * time.Millisecond,
+	})
+
+	start := time.Now()
+	report := registry.Run(context.Background())
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Run took %s, want it to stop at the check timeout", elapsed)
+	}
+	result := report.Checks["database"]
+	if result.Status != CheckTimeout || report.Status != StatusUnavailable {
+		t.Errorf("got check %q / report %q, want %q / %q", result.Status, report.Status, CheckTimeout, StatusUnavailable)
+	}
+	if !errors.Is(result.Err(), context.DeadlineExceeded) {
+		t.Errorf("err = %v, want deadline exceeded", result.Err())
+	}
+}
@@ -0,0 +1,240 @@
+// Package idempotency は Idempotency-Key ヘッダーによる POST / PUT / PATCH の再送対策です。
+//
+// 同じユーザーが同じキーで送ったリクエストは最初の 1 回だけハンドラーで処理し、
+// 以降は保存したレスポンスをそのまま返します。キーが同じでボディなどが異なる場合は 422 を返します。
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"server/internal/apierror"
+	"server/internal/auth"
+	"server/internal/domain/entities"
+	"server/internal/logging"
+	"server/internal/request"
+)
+
+const (
+	// Header はクライアントがキーを指定するリクエストヘッダーです
+	Header = "Idempotency-Key"
+	// ReplayedHeader は保存したレスポンスを返した場合に付けるレスポンスヘッダーです
+	ReplayedHeader = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+)
+
+// replayedHeaders は保存して再送時に返すレスポンスヘッダーです（リクエスト ID やレート制限など、リクエストごとに変わるものは含めない）
+var replayedHeaders = []string{"Content-Type", "Location"}
+
+// Repository はキーごとのリクエストとレスポンスを保存します
+type Repository interface {
+	// Reserve は record を処理中として保存します。
+	// 有効期限内の同じキーがあれば保存せずにそれを返します。ただし staleBefore より前から処理中のものは中断されたとみなして置き換えます。
+	Reserve(ctx context.Context, record *entities.IdempotencyRecord, staleBefore time.Time) (*entities.IdempotencyRecord, error)
+	// Complete は処理中のレコードにレスポンスを保存します
+	Complete(ctx context.Context, record *entities.IdempotencyRecord) error
+	// Release は処理中のレコードを削除し、同じキーで再試行できるようにします
+	Release(ctx context.Context, userID uuid.UUID, key string) error
+	// DeleteExpired は有効期限を過ぎたレコードを削除します
+	DeleteExpired(ctx context.Context, now time.Time) error
+}
+
+// Middleware は Idempotency-Key を処理するミドルウェアです
+type Middleware struct {
+	repo        Repository
+	ttl         time.Duration
+	lockTimeout time.Duration
+	now         func() time.Time
+}
+
+// NewMiddleware は Middleware を作成します。
+// ttl はレスポンスを保存する期間、lockTimeout は処理中のまま応答のないリクエストを中断されたとみなすまでの時間です。
+func NewMiddleware(repo Repository, ttl, lockTimeout time.Duration) *Middleware {
+	return &Middleware{repo: repo, ttl: ttl, lockTimeout: lockTimeout, now: time.Now}
+}
+
+// Handler は next を Idempotency-Key に対応させます。ユーザーごとにキーを区別するため RequireAuth の内側に置きます。
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(Header)
+		if key == "" || !appliesTo(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		userID, ok := auth.GetUserIDFromContext(r.Context())
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !validKey(key) {
+			apierror.Write(w, r, apierror.New(apierror.CodeInvalidIdempotencyKey, "Idempotency-Key must be 1 to 255 printable ASCII characters"))
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, request.DefaultMaxBytes))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				apierror.Write(w, r, apierror.New(apierror.CodePayloadTooLarge, "request body is too large"))
+				return
+			}
+			apierror.Write(w, r, apierror.Wrap(err, apierror.CodeInvalidBody, "failed to read request body"))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		now := m.now()
+		record := &entities.IdempotencyRecord{
+			UserID:      userID,
+			Key:         key,
+			Fingerprint: fingerprint(r, body),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(m.ttl),
+		}
+		existing, err := m.repo.Reserve(r.Context(), record, now.Add(-m.lockTimeout))
+		if err != nil {
+			apierror.Write(w, r, apierror.Wrap(err, apierror.CodeUpstream, "failed to check idempotency key"))
+			return
+		}
+		if existing != nil {
+			replay(w, r, existing, record.Fingerprint)
+			return
+		}
+
+		m.serve(w, r, next, record)
+	})
+}
+
+// serve は最初のリクエストを処理してレスポンスを保存します。
+// 5xx やパニックの場合は保存せず、同じキーで再試行できるようにします。
+func (m *Middleware) serve(w http.ResponseWriter, r *http.Request, next http.Handler, record *entities.IdempotencyRecord) {
+	// クライアントが切断しても結果は保存する
+	ctx := context.WithoutCancel(r.Context())
+	recorder := &responseRecorder{ResponseWriter: w}
+
+	completed := false
+	defer func() {
+		if completed {
+			return
+		}
+		if err := m.repo.Release(ctx, record.UserID, record.Key); err != nil {
+			logging.FromContext(ctx).Warn("failed to release idempotency key", "error", err)
+		}
+	}()
+
+	next.ServeHTTP(recorder, r)
+
+	status := recorder.statusCode()
+	if status >= http.StatusInternalServerError {
+		return
+	}
+	record.StatusCode = status
+	record.Header = map[string][]string{}
+	for _, name := range replayedHeaders {
+		if values := recorder.Header().Values(name); len(values) > 0 {
+			record.Header[name] = values
+		}
+	}
+	record.Body = recorder.body.Bytes()
+	if err := m.repo.Complete(ctx, record); err != nil {
+		logging.FromContext(ctx).Warn("failed to store idempotent response", "error", err)
+		return
+	}
+	completed = true
+}
+
+// Run は interval ごとに期限切れのレコードを削除します。ctx がキャンセルされると戻ります。
+func (m *Middleware) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := m.repo.DeleteExpired(ctx, now); err != nil {
+				logging.FromContext(ctx).Warn("failed to delete expired idempotency keys", "error", err)
+			}
+		}
+	}
+}
+
+func replay(w http.ResponseWriter, r *http.Request, record *entities.IdempotencyRecord, fingerprint string) {
+	switch {
+	case record.Fingerprint != fingerprint:
+		apierror.Write(w, r, apierror.New(apierror.CodeIdempotencyKeyReused, "Idempotency-Key was already used for a different request"))
+	case !record.Completed():
+		w.Header().Set("Retry-After", "1")
+		apierror.Write(w, r, apierror.New(apierror.CodeIdempotencyInProgress, "a request with this Idempotency-Key is still being processed"))
+	default:
+		for name, values := range record.Header {
+			w.Header()[name] = values
+		}
+		w.Header().Set(ReplayedHeader, "true")
+		w.WriteHeader(record.StatusCode)
+		w.Write(record.Body)
+	}
+}
+
+func appliesTo(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch
+}
+
+func validKey(key string) bool {
+	if len(key) > maxKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// fingerprint はメソッド・パス（クエリを含む）・ボディのハッシュです
+func fingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	io.WriteString(hash, r.Method+" "+r.URL.RequestURI()+"\n")
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder はレスポンスをクライアントに書き込みながら保存用に記録します
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	r.body.Write(p)
+	return r.ResponseWriter.Write(p)
+}
+
+func (r *responseRecorder) statusCode() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+	return r.status
+}
@@ -0,0 +1,128 @@
+package idempotency
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"server/internal/auth"
+	"server/internal/infrastructure/memory"
+)
+
+func TestHandler_ReplaysFirstResponse(t *testing.T) {
+	calls := 0
+	status := http.StatusCreated
+	mw := NewMiddleware(memory.NewStore().IdempotencyKeys(), time.Hour, time.Minute)
+	handler := mw.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-ID", uuid.NewString())
+		w.WriteHeader(status)
+		w.Write(body)
+	}))
+
+	user := uuid.New()
+	send := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/reservations", strings.NewReader(body))
+		req.Header.Set(Header, key)
+		req = req.WithContext(context.WithValue(req.Context(), auth.UserIDKey, user))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := send("key-1", `{"stageId":"a"}`)
+	if first.Code != http.StatusCreated || first.Header().Get(ReplayedHeader) != "" {
+		t.Fatalf("first: status = %d, replayed = %q", first.Code, first.Header().Get(ReplayedHeader))
+	}
+
+	// ハンドラーの結果が変わっても最初のレスポンスを返す
+	status = http.StatusConflict
+	replayed := send("key-1", `{"stageId":"a"}`)
+	if calls != 1 {
+		t.Fatalf("handler called %d times, want 1", calls)
+	}
+	if replayed.Code != http.StatusCreated || replayed.Body.String() != `{"stageId":"a"}` || replayed.Header().Get(ReplayedHeader) != "true" {
+		t.Fatalf("replay: status = %d, body = %q, headers = %v", replayed.Code, replayed.Body.String(), replayed.Header())
+	}
+	if replayed.Header().Get("Content-Type") != "application/json" || replayed.Header().Get("X-Request-ID") != "" {
+		t.Fatalf("replay headers = %v", replayed.Header())
+	}
+
+	if rec := send("key-1", `{"stageId":"b"}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("different body: status = %d, want 422", rec.Code)
+	}
+	if rec := send("key 1", `{}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid key: status = %d, want 400", rec.Code)
+	}
+}
+
+func TestHandler_ReleasesKeyOnServerError(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	repo := memory.NewStore().IdempotencyKeys()
+	handler := NewMiddleware(repo, time.Hour, time.Minute).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+
+	user := uuid.New()
+	send := func() int {
+		req := httptest.NewRequest(http.MethodPut, "/api/hp/update", strings.NewReader(`{"hp":10}`))
+		req.Header.Set(Header, "retry")
+		req = req.WithContext(context.WithValue(req.Context(), auth.UserIDKey, user))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if got := send(); got != http.StatusServiceUnavailable {
+		t.Fatalf("first: status = %d", got)
+	}
+	// 5xx は保存されないため再試行はハンドラーに届く
+	status = http.StatusOK
+	if got := send(); got != http.StatusOK {
+		t.Fatalf("retry after 5xx: status = %d, want 200", got)
+	}
+}
+
+func TestHandler_RejectsConcurrentRequest(t *testing.T) {
+	repo := memory.NewStore().IdempotencyKeys()
+	mw := NewMiddleware(repo, time.Hour, time.Minute)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := mw.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	user := uuid.New()
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/api/battles", strings.NewReader(`{}`))
+		req.Header.Set(Header, "in-flight")
+		return req.WithContext(context.WithValue(req.Context(), auth.UserIDKey, user))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest())
+	}()
+	<-started
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, newRequest())
+	close(release)
+	<-done
+
+	if rec.Code != http.StatusConflict || rec.Header().Get("Retry-After") != "1" {
+		t.Fatalf("concurrent: status = %d, Retry-After = %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+}
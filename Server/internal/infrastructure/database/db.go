@@ -0,0 +1,211 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrNotConfigured は接続先が設定されていない場合のエラーです
+var ErrNotConfigured = errors.New("database not configured")
+
+// ErrNoRows は行が見つからない場合のエラーです（pgx.ErrNoRows と同一）
+var ErrNoRows = pgx.ErrNoRows
+
+// Options は接続プールの設定です
+type Options struct {
+	URL              string
+	MaxConns         int32         // プールの最大接続数
+	MinConns         int32         // 常に確保しておく接続数
+	MaxConnLifetime  time.Duration // 接続を作り直すまでの時間
+	MaxConnIdleTime  time.Duration // アイドル接続を閉じるまでの時間
+	StatementTimeout time.Duration // 1 文あたりのタイムアウト（0 で無制限）
+	ConnectTimeout   time.Duration // 起動時の接続確認のタイムアウト
+	SlowQuery        time.Duration // この時間を超えたクエリを警告ログに出す（0 で無効）
+}
+
+// Querier はプールとトランザクションに共通するクエリ操作です
+type Querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// DB はアプリケーション全体で共有する PostgreSQL の接続プールです。
+// WithTx の中で渡された context を使うと、クエリは自動的にそのトランザクション上で実行されます。
+type DB struct {
+	pool *pgxpool.Pool
+}
+
+type txKey struct{}
+
+// Open は接続プールを作成し、疎通を確認します
+func Open(ctx context.Context, options Options) (*DB, error) {
+	url := strings.TrimSpace(options.URL)
+	if url == "" {
+		return nil, ErrNotConfigured
+	}
+
+	poolConfig, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		return nil, fmt.Errorf("parse database url: %w", err)
+	}
+	if options.MaxConns > 0 {
+		poolConfig.MaxConns = options.MaxConns
+	}
+	if options.MinConns > 0 {
+		poolConfig.MinConns = options.MinConns
+	}
+	if options.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = options.MaxConnLifetime
+	}
+	if options.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = options.MaxConnIdleTime
+	}
+	if options.StatementTimeout > 0 {
+		poolConfig.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(options.StatementTimeout.Milliseconds(), 10)
+	}
+	poolConfig.ConnConfig.Tracer = &queryTracer{slowQuery: options.SlowQuery}
+
+	connectTimeout := options.ConnectTimeout
+	if connectTimeout <= 0 {
+		connectTimeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, connectTimeout)
+	defer cancel()
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("create database pool: %w", err)
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("ping database: %w", err)
+	}
+
+	return &DB{pool: pool}, nil
+}
+
+// Ready は接続プールが利用可能かを返します
+func (db *DB) Ready() bool {
+	return db != nil && db.pool != nil
+}
+
+// Health はデータベースに Ping を送り疎通を確認します
+func (db *DB) Health(ctx context.Context) error {
+	if !db.Ready() {
+		return ErrNotConfigured
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 4*time.Second)
+	defer cancel()
+
+	if err := db.pool.Ping(ctx); err != nil {
+		return fmt.Errorf("database ping failed: %w", err)
+	}
+	return nil
+}
+
+// Stats は接続プールの統計を返します
+func (db *DB) Stats() *pgxpool.Stat {
+	if !db.Ready() {
+		return nil
+	}
+	return db.pool.Stat()
+}
+
+// Close は接続プールを解放します
+func (db *DB) Close() {
+	if db.Ready() {
+		db.pool.Close()
+	}
+}
+
+// Exec は結果行を返さない SQL を実行します
+func (db *DB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	q, err := db.querier(ctx)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	return q.Exec(ctx, sql, args...)
+}
+
+// Query は複数行を返す SQL を実行します
+func (db *DB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	q, err := db.querier(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return q.Query(ctx, sql, args...)
+}
+
+// QueryRow は 1 行を返す SQL を実行します
+func (db *DB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	q, err := db.querier(ctx)
+	if err != nil {
+		return errRow{err: err}
+	}
+	return q.QueryRow(ctx, sql, args...)
+}
+
+// WithTx は fn をトランザクション内で実行します。
+// fn がエラーを返すか panic した場合はロールバックし、それ以外はコミットします。
+// 既にトランザクション内の context が渡された場合はそのトランザクションに参加します。
+func (db *DB) WithTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+	if !db.Ready() {
+		return ErrNotConfigured
+	}
+
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback(context.WithoutCancel(ctx))
+			panic(p)
+		}
+		if err != nil {
+			_ = tx.Rollback(context.WithoutCancel(ctx))
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// querier は context にトランザクションがあればそれを、なければプールを返します
+func (db *DB) querier(ctx context.Context) (Querier, error) {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx, nil
+	}
+	if !db.Ready() {
+		return nil, ErrNotConfigured
+	}
+	return db.pool, nil
+}
+
+// errRow は QueryRow でクエリを発行できなかった場合のエラーを Scan 時に返します
+type errRow struct {
+	err error
+}
+
+func (r errRow) Scan(...any) error {
+	return r.err
+}
@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+// openTestDB は TEST_SUPABASE_DB_URL の接続プールを開きます。未設定の場合はスキップします。
+func openTestDB(t *testing.T, options Options) *DB {
+	t.Helper()
+
+	options.URL = os.Getenv("TEST_SUPABASE_DB_URL")
+	if options.URL == "" {
+		t.Skip("TEST_SUPABASE_DB_URL is not set")
+	}
+
+	db, err := Open(context.Background(), options)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(db.Close)
+	return db
+}
+
+func TestWithTxRollsBackOnError(t *testing.T) {
+	db := openTestDB(t, Options{})
+	ctx := context.Background()
+
+	if _, err := db.Exec(ctx, `CREATE TEMP TABLE tx_probe (id int)`); err != nil {
+		t.Fatalf("failed to create temp table: %v", err)
+	}
+
+	errAbort := errors.New("abort")
+	err := db.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := db.Exec(ctx, `INSERT INTO tx_probe VALUES (1)`); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("expected abort error, got %v", err)
+	}
+
+	var count int
+	if err := db.QueryRow(ctx, `SELECT count(*) FROM tx_probe`).Scan(&count); err != nil {
+		t.Fatalf("failed to count rows: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("expected rollback, found %d rows", count)
+	}
+}
+
+func TestStatementTimeout(t *testing.T) {
+	db := openTestDB(t, Options{MaxConns: 1, StatementTimeout: 100 * time.Millisecond})
+
+	if _, err := db.Exec(context.Background(), `SELECT pg_sleep(1)`); err == nil {
+		t.Fatal("expected statement timeout error")
+	}
+}
+
+func TestNilDBIsNotReady(t *testing.T) {
+	var db *DB
+	if db.Ready() {
+		t.Fatal("nil DB must not be ready")
+	}
+	if _, err := db.Exec(context.Background(), `SELECT 1`); !errors.Is(err, ErrNotConfigured) {
+		t.Fatalf("expected ErrNotConfigured, got %v", err)
+	}
+	if err := db.QueryRow(context.Background(), `SELECT 1`).Scan(); !errors.Is(err, ErrNotConfigured) {
+		t.Fatalf("expected ErrNotConfigured, got %v", err)
+	}
+}
@@ -0,0 +1,295 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// migrationLockKey は同時に起動した複数インスタンスのマイグレーションを直列化する advisory lock のキーです
+const migrationLockKey int64 = 0x6f735f32353136 // "os_2516"
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([A-Za-z0-9_]+)\.(up|down)\.sql$`)
+
+// ErrSchemaBehind は未適用のマイグレーションが残っている場合のエラーです
+var ErrSchemaBehind = errors.New("database schema is behind")
+
+// Migration は 1 つのバージョンのマイグレーションです
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string // 空の場合は取り消し不可
+}
+
+// MigrationStatus は現在のスキーマの状態です
+type MigrationStatus struct {
+	Current int64       // 適用済みの最大バージョン（未適用なら 0）
+	Applied []int64     // 適用済みのバージョン
+	Pending []Migration // 未適用のマイグレーション（バージョン順）
+}
+
+// LoadMigrations は fsys 直下の NNN_name.up.sql / NNN_name.down.sql を読み込み、バージョン順に返します
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", entry.Name())
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version: %s", entry.Name())
+		}
+		body, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d has conflicting names: %s, %s", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(body)
+		} else {
+			migration.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %03d_%s has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrator は schema_migrations テーブルでバージョンを管理しながらマイグレーションを適用します
+type Migrator struct {
+	db         *DB
+	migrations []Migration
+}
+
+// NewMigrator は fsys のマイグレーションを適用する Migrator を作成します
+func NewMigrator(db *DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Status は適用済みのバージョンと未適用のマイグレーションを返します
+func (m *Migrator) Status(ctx context.Context) (*MigrationStatus, error) {
+	if !m.db.Ready() {
+		return nil, ErrNotConfigured
+	}
+
+	conn, err := m.db.pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	return m.status(ctx, conn)
+}
+
+// CheckCurrent は未適用のマイグレーションがあれば ErrSchemaBehind を返します
+func (m *Migrator) CheckCurrent(ctx context.Context) error {
+	status, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if len(status.Pending) > 0 {
+		return fmt.Errorf("%w: %d pending migration(s), next is %03d_%s",
+			ErrSchemaBehind, len(status.Pending), status.Pending[0].Version, status.Pending[0].Name)
+	}
+	return nil
+}
+
+// Up は未適用のマイグレーションをすべて適用し、適用したものを返します。
+// 各マイグレーションは個別のトランザクションで実行され、失敗した時点で停止します。
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		status, err := m.status(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range status.Pending {
+			if err := applyMigration(ctx, conn, migration.Up, func(ctx context.Context, exec Querier) error {
+				_, err := exec.Exec(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, migration.Version, migration.Name)
+				return err
+			}); err != nil {
+				return fmt.Errorf("apply migration %03d_%s: %w", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down は適用済みのマイグレーションを新しい順に steps 件取り消し、取り消したものを返します
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("steps must be positive")
+	}
+
+	known := make(map[int64]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+
+	var reverted []Migration
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		status, err := m.status(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(status.Applied) - 1; i >= 0 && len(reverted) < steps; i-- {
+			version := status.Applied[i]
+			migration, ok := known[version]
+			if !ok {
+				return fmt.Errorf("migration %03d is applied but not known to this binary", version)
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("migration %03d_%s has no down file", migration.Version, migration.Name)
+			}
+
+			if err := applyMigration(ctx, conn, migration.Down, func(ctx context.Context, exec Querier) error {
+				_, err := exec.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+				return err
+			}); err != nil {
+				return fmt.Errorf("revert migration %03d_%s: %w", migration.Version, migration.Name, err)
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// withLock は advisory lock を保持した 1 つの接続上で fn を実行します
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	if !m.db.Ready() {
+		return ErrNotConfigured
+	}
+
+	conn, err := m.db.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	// マイグレーションは statement_timeout の対象外にする
+	if _, err := conn.Exec(ctx, `SET statement_timeout = 0`); err != nil {
+		return fmt.Errorf("disable statement timeout: %w", err)
+	}
+	defer conn.Exec(context.WithoutCancel(ctx), `RESET statement_timeout`)
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer conn.Exec(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, migrationLockKey)
+
+	if err := ensureMigrationTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func (m *Migrator) status(ctx context.Context, conn *pgxpool.Conn) (*MigrationStatus, error) {
+	// schema_migrations がまだ無い場合はすべて未適用として扱う（作成はロック内でのみ行う）
+	var exists bool
+	if err := conn.QueryRow(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("check schema_migrations: %w", err)
+	}
+	if !exists {
+		return &MigrationStatus{Pending: append([]Migration(nil), m.migrations...)}, nil
+	}
+
+	rows, err := conn.Query(ctx, `SELECT version FROM schema_migrations ORDER BY version`)
+	if err != nil {
+		return nil, fmt.Errorf("query schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	status := &MigrationStatus{}
+	applied := make(map[int64]bool)
+	for rows.Next() {
+		var version int64
+		if err := rows.Scan(&version); err != nil {
+			return nil, fmt.Errorf("scan schema_migrations: %w", err)
+		}
+		applied[version] = true
+		status.Applied = append(status.Applied, version)
+		status.Current = version
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate schema_migrations: %w", err)
+	}
+
+	for _, migration := range m.migrations {
+		if !applied[migration.Version] {
+			status.Pending = append(status.Pending, migration)
+		}
+	}
+	return status, nil
+}
+
+func ensureMigrationTable(ctx context.Context, conn *pgxpool.Conn) error {
+	const query = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+)`
+	if _, err := conn.Exec(ctx, query); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+// applyMigration は SQL と schema_migrations の更新を 1 つのトランザクションで実行します
+func applyMigration(ctx context.Context, conn *pgxpool.Conn, sql string, record func(ctx context.Context, exec Querier) error) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(context.WithoutCancel(ctx))
+
+	if _, err := tx.Exec(ctx, sql); err != nil {
+		return err
+	}
+	if err := record(ctx, tx); err != nil {
+		return fmt.Errorf("record version: %w", err)
+	}
+	return tx.Commit(ctx)
+}
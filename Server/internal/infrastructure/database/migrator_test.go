@@ -0,0 +1,77 @@
+package database
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"server/migrations"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"002_second.up.sql":   {Data: []byte("CREATE TABLE b ();")},
+		"001_first.up.sql":    {Data: []byte("CREATE TABLE a ();")},
+		"001_first.down.sql":  {Data: []byte("DROP TABLE a;")},
+		"README.md":           {Data: []byte("ignored")},
+		"010_tenth.up.sql":    {Data: []byte("SELECT 1;")},
+		"010_tenth.down.sql":  {Data: []byte("SELECT 1;")},
+		"002_second.down.sql": {Data: []byte("DROP TABLE b;")},
+	}
+
+	loaded, err := LoadMigrations(fsys)
+	if err != nil {
+		t.Fatalf("LoadMigrations returned error: %v", err)
+	}
+
+	want := []struct {
+		version int64
+		name    string
+	}{{1, "first"}, {2, "second"}, {10, "tenth"}}
+	if len(loaded) != len(want) {
+		t.Fatalf("expected %d migrations, got %d", len(want), len(loaded))
+	}
+	for i, w := range want {
+		if loaded[i].Version != w.version || loaded[i].Name != w.name {
+			t.Errorf("index %d: expected %03d_%s, got %03d_%s", i, w.version, w.name, loaded[i].Version, loaded[i].Name)
+		}
+	}
+	if loaded[0].Down != "DROP TABLE a;" {
+		t.Errorf("expected down SQL to be loaded, got %q", loaded[0].Down)
+	}
+}
+
+func TestLoadMigrationsRejectsInvalidFiles(t *testing.T) {
+	cases := map[string]fstest.MapFS{
+		"bad name":      {"001-first.up.sql": {Data: []byte("SELECT 1;")}},
+		"missing up":    {"001_first.down.sql": {Data: []byte("SELECT 1;")}},
+		"name conflict": {"001_a.up.sql": {Data: []byte("SELECT 1;")}, "001_b.down.sql": {Data: []byte("SELECT 1;")}},
+		"zero version":  {"000_zero.up.sql": {Data: []byte("SELECT 1;")}},
+		"no direction":  {"001_first.sql": {Data: []byte("SELECT 1;")}},
+	}
+	for name, fsys := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := LoadMigrations(fsys); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+// TestEmbeddedMigrations は埋め込みのマイグレーションがすべて up/down を持ち、連番であることを確認します
+func TestEmbeddedMigrations(t *testing.T) {
+	loaded, err := LoadMigrations(migrations.FS)
+	if err != nil {
+		t.Fatalf("LoadMigrations returned error: %v", err)
+	}
+	if len(loaded) == 0 {
+		t.Fatal("expected embedded migrations")
+	}
+	for i, migration := range loaded {
+		if migration.Version != int64(i+1) {
+			t.Errorf("expected version %d, got %03d_%s", i+1, migration.Version, migration.Name)
+		}
+		if migration.Down == "" {
+			t.Errorf("migration %03d_%s has no down file", migration.Version, migration.Name)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"server/internal/logging"
+	"server/internal/tracing"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// queryTracer は pgx のトレーサーです。クエリごとにスパンを作成し、リクエストの context ロガーに記録します。
+// 引数にはパスワードハッシュなどが含まれるため、スパンにもログにも出力しません。
+type queryTracer struct {
+	slowQuery time.Duration
+}
+
+type queryStartKey struct{}
+
+type queryStart struct {
+	sql   string
+	start time.Time
+	span  trace.Span
+}
+
+func (t *queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	sql := compactSQL(data.SQL)
+	ctx, span := tracing.Start(ctx, "db.query",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.statement", sql),
+		),
+	)
+	return context.WithValue(ctx, queryStartKey{}, queryStart{sql: sql, start: time.Now(), span: span})
+}
+
+func (t *queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	started, ok := ctx.Value(queryStartKey{}).(queryStart)
+	if !ok {
+		return
+	}
+
+	elapsed := time.Since(started.start)
+	started.span.SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+	tracing.RecordError(started.span, data.Err)
+	started.span.End()
+
+	level := slog.LevelDebug
+	message := "db query"
+	switch {
+	case data.Err != nil:
+		message = "db query failed"
+	case t.slowQuery > 0 && elapsed >= t.slowQuery:
+		level = slog.LevelWarn
+		message = "slow db query"
+	}
+
+	logger := logging.FromContext(ctx)
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []any{
+		slog.String("sql", started.sql),
+		slog.Float64("duration_ms", float64(elapsed.Microseconds())/1000),
+		slog.Int64("rows", data.CommandTag.RowsAffected()),
+	}
+	if data.Err != nil {
+		attrs = append(attrs, slog.Any("error", data.Err))
+	}
+	logger.Log(ctx, level, message, attrs...)
+}
+
+// compactSQL は改行やインデントを 1 つの空白にまとめます
+func compactSQL(sql string) string {
+	return strings.Join(strings.Fields(sql), " ")
+}
@@ -0,0 +1,151 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	domainbattlestage "server/internal/domain/battlestage"
+
+	"github.com/google/uuid"
+)
+
+// BattleStageRepository はインメモリのバトルステージリポジトリです
+type BattleStageRepository struct {
+	store *Store
+}
+
+// AddStage はステージを登録します（シード用）
+func (r *BattleStageRepository) AddStage(stage domainbattlestage.Stage) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.stages[stage.ID] = cloneStage(stage)
+}
+
+// FindNearby はハーサイン式で半径内のステージを (距離, ID) 順に検索します
+func (r *BattleStageRepository) FindNearby(ctx context.Context, query domainbattlestage.NearbyQuery) ([]domainbattlestage.StageWithDistance, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	results := make([]domainbattlestage.StageWithDistance, 0)
+	for _, stage := range r.store.stages {
+		distance := domainbattlestage.DistanceMeters(query.Origin, stage.Location)
+		if distance > query.RadiusMeters {
+			continue
+		}
+		if query.After != nil {
+			if distance < query.After.DistanceMeters ||
+				(distance == query.After.DistanceMeters && stage.ID <= query.After.StageID) {
+				continue
+			}
+		}
+		results = append(results, domainbattlestage.StageWithDistance{
+			Stage:          cloneStage(stage),
+			DistanceMeters: distance,
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].DistanceMeters != results[j].DistanceMeters {
+			return results[i].DistanceMeters < results[j].DistanceMeters
+		}
+		return results[i].Stage.ID < results[j].Stage.ID
+	})
+
+	if query.Limit > 0 && len(results) > query.Limit {
+		results = results[:query.Limit]
+	}
+	return results, nil
+}
+
+// FindByID は ID を指定してステージを取得します
+func (r *BattleStageRepository) FindByID(ctx context.Context, id string) (*domainbattlestage.Stage, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	stage, exists := r.store.stages[id]
+	if !exists {
+		return nil, domainbattlestage.ErrStageNotFound
+	}
+	found := cloneStage(stage)
+	return &found, nil
+}
+
+// StageReservationRepository はインメモリのステージ予約リポジトリです
+type StageReservationRepository struct {
+	store *Store
+}
+
+// CreateReservation は予約を作成します。同じステージで枠が重なる場合は ErrReservationConflict を返します。
+func (r *StageReservationRepository) CreateReservation(ctx context.Context, reservation *domainbattlestage.Reservation) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.stages[reservation.StageID]; !exists {
+		return domainbattlestage.ErrStageNotFound
+	}
+	for _, existing := range r.store.reservations {
+		if existing.StageID == reservation.StageID && existing.Overlaps(reservation.StartsAt, reservation.EndsAt) {
+			return domainbattlestage.ErrReservationConflict
+		}
+	}
+
+	r.store.reservations[reservation.ID] = *reservation
+	id := reservation.ID
+	r.store.recordUndo(ctx, func() { delete(r.store.reservations, id) })
+	return nil
+}
+
+// ListReservations は指定ステージの [from, to) と重なる予約を開始時刻順に返します
+func (r *StageReservationRepository) ListReservations(ctx context.Context, stageIDs []string, from, to time.Time) ([]domainbattlestage.Reservation, error) {
+	wanted := make(map[string]struct{}, len(stageIDs))
+	for _, id := range stageIDs {
+		wanted[id] = struct{}{}
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	results := make([]domainbattlestage.Reservation, 0)
+	for _, reservation := range r.store.reservations {
+		if _, ok := wanted[reservation.StageID]; !ok {
+			continue
+		}
+		if reservation.Overlaps(from, to) {
+			results = append(results, reservation)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].StartsAt.Before(results[j].StartsAt)
+	})
+	return results, nil
+}
+
+// DeleteReservation は userID が所有する予約を削除します
+func (r *StageReservationRepository) DeleteReservation(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	reservation, exists := r.store.reservations[id]
+	if !exists || reservation.UserID != userID {
+		return domainbattlestage.ErrReservationNotFound
+	}
+	delete(r.store.reservations, id)
+	r.store.recordUndo(ctx, func() { r.store.reservations[id] = reservation })
+	return nil
+}
+
+// cloneStage はポインタフィールドを含めてステージを複製します
+func cloneStage(stage domainbattlestage.Stage) domainbattlestage.Stage {
+	if stage.RadiusMeters != nil {
+		radius := *stage.RadiusMeters
+		stage.RadiusMeters = &radius
+	}
+	if stage.Description != nil {
+		description := *stage.Description
+		stage.Description = &description
+	}
+	return stage
+}
@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"server/internal/domain/entities"
+)
+
+type idempotencyKey struct {
+	userID uuid.UUID
+	key    string
+}
+
+// IdempotencyRepository はインメモリの Idempotency-Key リポジトリです
+type IdempotencyRepository struct {
+	store *Store
+}
+
+// Reserve は record を処理中として保存します。有効期限内の同じキーがあればそれを返します。
+func (r *IdempotencyRepository) Reserve(ctx context.Context, record *entities.IdempotencyRecord, staleBefore time.Time) (*entities.IdempotencyRecord, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	id := idempotencyKey{userID: record.UserID, key: record.Key}
+	if existing, exists := r.store.idempotency[id]; exists {
+		expired := !existing.ExpiresAt.After(record.CreatedAt)
+		stale := !existing.Completed() && existing.CreatedAt.Before(staleBefore)
+		if !expired && !stale {
+			return &existing, nil
+		}
+	}
+
+	r.store.idempotency[id] = *record
+	return nil, nil
+}
+
+// Complete は処理中のレコードにレスポンスを保存します
+func (r *IdempotencyRepository) Complete(ctx context.Context, record *entities.IdempotencyRecord) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.idempotency[idempotencyKey{userID: record.UserID, key: record.Key}] = *record
+	return nil
+}
+
+// Release は処理中のレコードを削除します
+func (r *IdempotencyRepository) Release(ctx context.Context, userID uuid.UUID, key string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	id := idempotencyKey{userID: userID, key: key}
+	if existing, exists := r.store.idempotency[id]; exists && !existing.Completed() {
+		delete(r.store.idempotency, id)
+	}
+	return nil
+}
+
+// DeleteExpired は有効期限を過ぎたレコードを削除します
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, record := range r.store.idempotency {
+		if !record.ExpiresAt.After(now) {
+			delete(r.store.idempotency, id)
+		}
+	}
+	return nil
+}
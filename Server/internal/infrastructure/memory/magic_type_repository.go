@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	domainmagic "server/internal/domain/magic"
+)
+
+// MagicTypeRepository はインメモリの魔法の定義のリポジトリです
+type MagicTypeRepository struct {
+	store *Store
+}
+
+// List はすべての魔法を ID 順に返します
+func (r *MagicTypeRepository) List(ctx context.Context) ([]domainmagic.MagicType, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	list := make([]domainmagic.MagicType, 0, len(r.store.magicTypes))
+	for _, magicType := range r.store.magicTypes {
+		list = append(list, magicType)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list, nil
+}
+
+// Create は魔法を追加します
+func (r *MagicTypeRepository) Create(ctx context.Context, magicType *domainmagic.MagicType) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.magicTypes[magicType.ID]; exists {
+		return domainmagic.ErrMagicTypeExists
+	}
+	r.store.magicTypes[magicType.ID] = *magicType
+	return nil
+}
+
+// Update は魔法を更新します
+func (r *MagicTypeRepository) Update(ctx context.Context, magicType *domainmagic.MagicType) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.magicTypes[magicType.ID]; !exists {
+		return domainmagic.ErrMagicTypeNotFound
+	}
+	r.store.magicTypes[magicType.ID] = *magicType
+	return nil
+}
+
+// Delete は魔法を削除します
+func (r *MagicTypeRepository) Delete(ctx context.Context, id string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.magicTypes[id]; !exists {
+		return domainmagic.ErrMagicTypeNotFound
+	}
+	delete(r.store.magicTypes, id)
+	return nil
+}
@@ -0,0 +1,149 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"server/internal/domain/entities"
+
+	"github.com/google/uuid"
+)
+
+// PlayerRepository はインメモリのプレイヤーリポジトリです
+type PlayerRepository struct {
+	store *Store
+}
+
+// CreatePlayer は新しいプレイヤーを作成します。user_id は一意です。
+func (r *PlayerRepository) CreatePlayer(ctx context.Context, player *entities.Player) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.players[player.ID]; exists {
+		return fmt.Errorf("failed to create player: duplicate id %s", player.ID)
+	}
+	if player.UserID != nil {
+		if _, exists := r.store.users[*player.UserID]; !exists {
+			return fmt.Errorf("failed to create player: user %s not found", *player.UserID)
+		}
+		for _, existing := range r.store.players {
+			if existing.UserID != nil && *existing.UserID == *player.UserID {
+				return fmt.Errorf("failed to create player: user %s already has a player", *player.UserID)
+			}
+		}
+	}
+
+	r.store.players[player.ID] = clonePlayer(*player)
+	id := player.ID
+	r.store.recordUndo(ctx, func() { delete(r.store.players, id) })
+	return nil
+}
+
+// GetPlayerByUserID はユーザーIDでプレイヤーを取得します
+func (r *PlayerRepository) GetPlayerByUserID(ctx context.Context, userID uuid.UUID) (*entities.Player, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, player := range r.store.players {
+		if player.UserID != nil && *player.UserID == userID {
+			found := clonePlayer(player)
+			return &found, nil
+		}
+	}
+	return nil, fmt.Errorf("player not found")
+}
+
+// GetPlayerByID はIDでプレイヤーを取得します
+func (r *PlayerRepository) GetPlayerByID(ctx context.Context, id uuid.UUID) (*entities.Player, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	player, exists := r.store.players[id]
+	if !exists {
+		return nil, fmt.Errorf("player not found")
+	}
+	found := clonePlayer(player)
+	return &found, nil
+}
+
+// UpdatePlayer はプレイヤー情報を更新します
+func (r *PlayerRepository) UpdatePlayer(ctx context.Context, player *entities.Player) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, exists := r.store.players[player.ID]
+	if !exists {
+		return fmt.Errorf("player not found")
+	}
+
+	previous := existing
+	r.store.recordUndo(ctx, func() { r.store.players[previous.ID] = previous })
+
+	existing.DisplayName = player.DisplayName
+	existing.HP = player.HP
+	existing.MaxHP = player.MaxHP
+	existing.HPUpdatedAt = player.HPUpdatedAt
+	existing.MP = player.MP
+	existing.Rank = player.Rank
+	existing.AvatarURL = player.AvatarURL
+	existing.UpdatedAt = player.UpdatedAt
+	r.store.players[player.ID] = clonePlayer(existing)
+	return nil
+}
+
+// UpdatePlayerHP はHPを更新し、自然回復の起点をリセットします
+func (r *PlayerRepository) UpdatePlayerHP(ctx context.Context, playerID uuid.UUID, hp int) error {
+	return r.update(ctx, playerID, func(player *entities.Player) {
+		player.HP = hp
+		player.HPUpdatedAt = time.Now()
+	})
+}
+
+// UpdatePlayerMP はMPを更新します
+func (r *PlayerRepository) UpdatePlayerMP(ctx context.Context, playerID uuid.UUID, mp int) error {
+	return r.update(ctx, playerID, func(player *entities.Player) { player.MP = mp })
+}
+
+// SaveBattleResult は対戦を終えたプレイヤーの HP / MP を保存し、自然回復の起点を対戦の終了時刻 at にします
+func (r *PlayerRepository) SaveBattleResult(ctx context.Context, userID uuid.UUID, hp, mp int, at time.Time) error {
+	player, err := r.GetPlayerByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return r.update(ctx, player.ID, func(player *entities.Player) {
+		player.HP = min(hp, player.MaxHP)
+		player.MP = mp
+		player.HPUpdatedAt = at
+	})
+}
+
+func (r *PlayerRepository) update(ctx context.Context, playerID uuid.UUID, apply func(player *entities.Player)) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	player, exists := r.store.players[playerID]
+	if !exists {
+		return fmt.Errorf("player not found")
+	}
+	previous := player
+	r.store.recordUndo(ctx, func() { r.store.players[playerID] = previous })
+
+	apply(&player)
+	player.UpdatedAt = time.Now()
+	r.store.players[playerID] = player
+	return nil
+}
+
+// clonePlayer はポインタフィールドを含めてプレイヤーを複製します
+func clonePlayer(player entities.Player) entities.Player {
+	if player.UserID != nil {
+		userID := *player.UserID
+		player.UserID = &userID
+	}
+	if player.AvatarURL != nil {
+		avatarURL := *player.AvatarURL
+		player.AvatarURL = &avatarURL
+	}
+	return player
+}
@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	domainbattlestage "server/internal/domain/battlestage"
+	"server/internal/domain/entities"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// defaultSeed はオフライン開発用の初期データです
+//
+//go:embed seed.json
+var defaultSeed []byte
+
+// SeedData はストアへ投入する初期データです
+type SeedData struct {
+	Users  []SeedUser  `json:"users"`
+	Stages []SeedStage `json:"stages"`
+}
+
+// SeedUser はシード用のユーザーです。パスワードは投入時に bcrypt でハッシュ化されます。
+type SeedUser struct {
+	ID          uuid.UUID `json:"id"`
+	Email       string    `json:"email"`
+	Password    string    `json:"password"`
+	FullName    string    `json:"fullName"`
+	DisplayName string    `json:"displayName"`
+}
+
+// SeedStage はシード用のバトルステージです
+type SeedStage struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Latitude     float64  `json:"latitude"`
+	Longitude    float64  `json:"longitude"`
+	RadiusMeters *float64 `json:"radiusMeters,omitempty"`
+	Description  *string  `json:"description,omitempty"`
+}
+
+// DefaultSeedData は埋め込みの初期データを返します
+func DefaultSeedData() (*SeedData, error) {
+	var seed SeedData
+	if err := json.Unmarshal(defaultSeed, &seed); err != nil {
+		return nil, fmt.Errorf("failed to decode seed data: %w", err)
+	}
+	return &seed, nil
+}
+
+// Seed は初期データをストアへ投入します。各ユーザーにはプレイヤーも 1 件作成します。
+func (s *Store) Seed(ctx context.Context, seed *SeedData) error {
+	users := s.Users()
+	players := s.Players()
+	for _, u := range seed.Users {
+		hashed, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return fmt.Errorf("failed to hash seed password for %s: %w", u.Email, err)
+		}
+
+		now := time.Now()
+		user := &entities.User{
+			ID:           u.ID,
+			Email:        u.Email,
+			PasswordHash: string(hashed),
+			FullName:     u.FullName,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		}
+		if err := users.CreateUser(ctx, user); err != nil {
+			return fmt.Errorf("failed to seed user %s: %w", u.Email, err)
+		}
+
+		displayName := u.DisplayName
+		if displayName == "" {
+			displayName = u.FullName
+		}
+		if err := players.CreatePlayer(ctx, entities.NewPlayer(&user.ID, displayName)); err != nil {
+			return fmt.Errorf("failed to seed player for %s: %w", u.Email, err)
+		}
+	}
+
+	stages := s.BattleStages()
+	for _, st := range seed.Stages {
+		stages.AddStage(domainbattlestage.Stage{
+			ID:           st.ID,
+			Name:         st.Name,
+			Location:     domainbattlestage.Location{Latitude: st.Latitude, Longitude: st.Longitude},
+			RadiusMeters: st.RadiusMeters,
+			Description:  st.Description,
+		})
+	}
+	return nil
+}
+
+// NewSeededStore は埋め込みの初期データを投入済みのストアを作成します
+func NewSeededStore(ctx context.Context) (*Store, error) {
+	seed, err := DefaultSeedData()
+	if err != nil {
+		return nil, err
+	}
+
+	store := NewStore()
+	if err := store.Seed(ctx, seed); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"server/internal/domain/entities"
+)
+
+// SessionRepository はインメモリのセッションリポジトリです
+type SessionRepository struct {
+	store *Store
+}
+
+// CreateSession は新しいセッションを作成します
+func (r *SessionRepository) CreateSession(ctx context.Context, session *entities.Session) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.users[session.UserID]; !exists {
+		return fmt.Errorf("failed to create session: user %s not found", session.UserID)
+	}
+	if _, exists := r.store.sessions[session.Token]; exists {
+		return fmt.Errorf("failed to create session: duplicate token")
+	}
+
+	r.store.sessions[session.Token] = *session
+	token := session.Token
+	r.store.recordUndo(ctx, func() { delete(r.store.sessions, token) })
+	return nil
+}
+
+// GetSessionByToken はトークンでセッションを取得します
+func (r *SessionRepository) GetSessionByToken(ctx context.Context, token string) (*entities.Session, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	session, exists := r.store.sessions[token]
+	if !exists {
+		return nil, fmt.Errorf("session not found")
+	}
+	return &session, nil
+}
+
+// DeleteSession はセッションを削除します
+func (r *SessionRepository) DeleteSession(ctx context.Context, token string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	session, exists := r.store.sessions[token]
+	if !exists {
+		return fmt.Errorf("session not found")
+	}
+	delete(r.store.sessions, token)
+	r.store.recordUndo(ctx, func() { r.store.sessions[token] = session })
+	return nil
+}
+
+// DeleteExpiredSessions は期限切れのセッションを削除します
+func (r *SessionRepository) DeleteExpiredSessions(ctx context.Context) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	now := time.Now()
+	for token, session := range r.store.sessions {
+		if now.After(session.ExpiresAt) {
+			delete(r.store.sessions, token)
+			r.store.recordUndo(ctx, func() { r.store.sessions[token] = session })
+		}
+	}
+	return nil
+}
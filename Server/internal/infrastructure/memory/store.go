@@ -0,0 +1,107 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	domainbattlestage "server/internal/domain/battlestage"
+	"server/internal/domain/entities"
+	domainmagic "server/internal/domain/magic"
+
+	"github.com/google/uuid"
+)
+
+// Store はオフライン開発用のインメモリデータストアです。
+// 各リポジトリは同じ Store を共有し、1 つのロックで整合性を保ちます。
+type Store struct {
+	mu           sync.RWMutex
+	users        map[uuid.UUID]entities.User
+	sessions     map[string]entities.Session // token -> session
+	players      map[uuid.UUID]entities.Player
+	stages       map[string]domainbattlestage.Stage
+	reservations map[uuid.UUID]domainbattlestage.Reservation
+	idempotency  map[idempotencyKey]entities.IdempotencyRecord
+	magicTypes   map[string]domainmagic.MagicType
+}
+
+// NewStore は空のストアを作成します
+func NewStore() *Store {
+	return &Store{
+		users:        make(map[uuid.UUID]entities.User),
+		sessions:     make(map[string]entities.Session),
+		players:      make(map[uuid.UUID]entities.Player),
+		stages:       make(map[string]domainbattlestage.Stage),
+		reservations: make(map[uuid.UUID]domainbattlestage.Reservation),
+		idempotency:  make(map[idempotencyKey]entities.IdempotencyRecord),
+		magicTypes:   make(map[string]domainmagic.MagicType),
+	}
+}
+
+type txKey struct{}
+
+// memoryTx は取り消し操作を記録するトランザクションです
+type memoryTx struct {
+	undo []func()
+}
+
+// WithTx は fn をトランザクションとして実行します。
+// fn がエラーを返した場合、fn 内で渡された context を使って行った変更を逆順に取り消します。
+// 分離レベルは提供せず、他のリクエストからは途中の状態が見えます。
+func (s *Store) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*memoryTx); ok {
+		return fn(ctx)
+	}
+
+	tx := &memoryTx{}
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		s.mu.Lock()
+		for i := len(tx.undo) - 1; i >= 0; i-- {
+			tx.undo[i]()
+		}
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// recordUndo はトランザクション中であれば取り消し操作を記録します。s.mu を保持した状態で呼び出します。
+func (s *Store) recordUndo(ctx context.Context, undo func()) {
+	if tx, ok := ctx.Value(txKey{}).(*memoryTx); ok {
+		tx.undo = append(tx.undo, undo)
+	}
+}
+
+// Users はユーザーリポジトリを返します
+func (s *Store) Users() *UserRepository {
+	return &UserRepository{store: s}
+}
+
+// Sessions はセッションリポジトリを返します
+func (s *Store) Sessions() *SessionRepository {
+	return &SessionRepository{store: s}
+}
+
+// Players はプレイヤーリポジトリを返します
+func (s *Store) Players() *PlayerRepository {
+	return &PlayerRepository{store: s}
+}
+
+// BattleStages はバトルステージリポジトリを返します
+func (s *Store) BattleStages() *BattleStageRepository {
+	return &BattleStageRepository{store: s}
+}
+
+// StageReservations はステージ予約リポジトリを返します
+func (s *Store) StageReservations() *StageReservationRepository {
+	return &StageReservationRepository{store: s}
+}
+
+// IdempotencyKeys は Idempotency-Key のリポジトリを返します
+func (s *Store) IdempotencyKeys() *IdempotencyRepository {
+	return &IdempotencyRepository{store: s}
+}
+
+// MagicTypes は魔法の定義のリポジトリを返します
+func (s *Store) MagicTypes() *MagicTypeRepository {
+	return &MagicTypeRepository{store: s}
+}
@@ -0,0 +1,137 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	domainbattlestage "server/internal/domain/battlestage"
+	"server/internal/domain/entities"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestNewSeededStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewSeededStore(ctx)
+	if err != nil {
+		t.Fatalf("NewSeededStore returned error: %v", err)
+	}
+
+	user, err := store.Users().GetUserByEmail(ctx, "ALICE@example.com")
+	if err != nil {
+		t.Fatalf("expected seeded user, got error: %v", err)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte("Passw0rd!")); err != nil {
+		t.Fatalf("expected seeded password to be hashed: %v", err)
+	}
+	if _, err := store.Players().GetPlayerByUserID(ctx, user.ID); err != nil {
+		t.Fatalf("expected seeded player for user: %v", err)
+	}
+}
+
+func TestBattleStageRepositoryFindNearbyPaging(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewSeededStore(ctx)
+	if err != nil {
+		t.Fatalf("NewSeededStore returned error: %v", err)
+	}
+	repo := store.BattleStages()
+
+	// 渋谷駅付近から 3.5km 以内（渋谷・代々木・新宿御苑）を 2 件ずつ取得する
+	query := domainbattlestage.NearbyQuery{
+		Origin:       domainbattlestage.Location{Latitude: 35.658, Longitude: 139.7016},
+		RadiusMeters: 3500,
+		Limit:        2,
+	}
+	first, err := repo.FindNearby(ctx, query)
+	if err != nil {
+		t.Fatalf("FindNearby returned error: %v", err)
+	}
+	if len(first) != 2 {
+		t.Fatalf("expected 2 stages, got %d", len(first))
+	}
+	if first[0].DistanceMeters > first[1].DistanceMeters {
+		t.Fatalf("expected ascending distance, got %v", first)
+	}
+
+	last := first[len(first)-1]
+	query.After = &domainbattlestage.Cursor{DistanceMeters: last.DistanceMeters, StageID: last.Stage.ID}
+	second, err := repo.FindNearby(ctx, query)
+	if err != nil {
+		t.Fatalf("FindNearby returned error: %v", err)
+	}
+	if len(second) != 1 {
+		t.Fatalf("expected 1 remaining stage, got %d", len(second))
+	}
+	if second[0].DistanceMeters < last.DistanceMeters {
+		t.Fatalf("expected next page to continue after cursor")
+	}
+}
+
+func TestStageReservationRepositoryConflict(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewSeededStore(ctx)
+	if err != nil {
+		t.Fatalf("NewSeededStore returned error: %v", err)
+	}
+	repo := store.StageReservations()
+
+	stageID := "a0000000-0000-4000-8000-000000000001"
+	start := time.Date(2030, 1, 1, 10, 0, 0, 0, time.UTC)
+	first := &domainbattlestage.Reservation{
+		ID: uuid.New(), StageID: stageID, UserID: uuid.New(),
+		StartsAt: start, EndsAt: start.Add(30 * time.Minute),
+	}
+	if err := repo.CreateReservation(ctx, first); err != nil {
+		t.Fatalf("CreateReservation returned error: %v", err)
+	}
+
+	overlapping := &domainbattlestage.Reservation{
+		ID: uuid.New(), StageID: stageID, UserID: uuid.New(),
+		StartsAt: start.Add(15 * time.Minute), EndsAt: start.Add(45 * time.Minute),
+	}
+	if err := repo.CreateReservation(ctx, overlapping); !errors.Is(err, domainbattlestage.ErrReservationConflict) {
+		t.Fatalf("expected ErrReservationConflict, got %v", err)
+	}
+
+	adjacent := &domainbattlestage.Reservation{
+		ID: uuid.New(), StageID: stageID, UserID: uuid.New(),
+		StartsAt: start.Add(30 * time.Minute), EndsAt: start.Add(time.Hour),
+	}
+	if err := repo.CreateReservation(ctx, adjacent); err != nil {
+		t.Fatalf("expected adjacent reservation to succeed, got %v", err)
+	}
+}
+
+func TestStoreWithTxRollsBack(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+
+	user := entities.NewUser("tx@example.com", "hash", "Tx")
+	errAbort := errors.New("abort")
+	err := store.WithTx(ctx, func(ctx context.Context) error {
+		if err := store.Users().CreateUser(ctx, user); err != nil {
+			return err
+		}
+		if err := store.Players().CreatePlayer(ctx, entities.NewPlayer(&user.ID, "Tx")); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("expected abort error, got %v", err)
+	}
+
+	if _, err := store.Users().GetUserByID(ctx, user.ID); err == nil {
+		t.Fatal("expected user creation to be rolled back")
+	}
+	if _, err := store.Players().GetPlayerByUserID(ctx, user.ID); err == nil {
+		t.Fatal("expected player creation to be rolled back")
+	}
+	if err := store.Users().CreateUser(ctx, user); err != nil {
+		t.Fatalf("expected retry to succeed after rollback: %v", err)
+	}
+}
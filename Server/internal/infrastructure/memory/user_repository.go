@@ -0,0 +1,82 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"server/internal/domain/entities"
+
+	"github.com/google/uuid"
+)
+
+// UserRepository はインメモリのユーザーリポジトリです
+type UserRepository struct {
+	store *Store
+}
+
+// CreateUser は新しいユーザーを作成します。メールアドレスは大文字小文字を区別せず一意です。
+func (r *UserRepository) CreateUser(ctx context.Context, user *entities.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.users[user.ID]; exists {
+		return fmt.Errorf("failed to create user: duplicate id %s", user.ID)
+	}
+	for _, existing := range r.store.users {
+		if strings.EqualFold(existing.Email, user.Email) {
+			return entities.ErrUserExists
+		}
+	}
+
+	r.store.users[user.ID] = *user
+	id := user.ID
+	r.store.recordUndo(ctx, func() { delete(r.store.users, id) })
+	return nil
+}
+
+// GetUserByEmail はメールアドレスでユーザーを取得します
+func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*entities.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, user := range r.store.users {
+		if strings.EqualFold(user.Email, email) {
+			found := user
+			return &found, nil
+		}
+	}
+	return nil, fmt.Errorf("user not found")
+}
+
+// GetUserByID はIDでユーザーを取得します
+func (r *UserRepository) GetUserByID(ctx context.Context, id uuid.UUID) (*entities.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	user, exists := r.store.users[id]
+	if !exists {
+		return nil, fmt.Errorf("user not found")
+	}
+	return &user, nil
+}
+
+// UpdateUser はユーザー情報を更新します
+func (r *UserRepository) UpdateUser(ctx context.Context, user *entities.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, exists := r.store.users[user.ID]
+	if !exists {
+		return fmt.Errorf("user not found")
+	}
+
+	previous := existing
+	r.store.recordUndo(ctx, func() { r.store.users[previous.ID] = previous })
+
+	existing.Email = user.Email
+	existing.FullName = user.FullName
+	existing.UpdatedAt = user.UpdatedAt
+	r.store.users[user.ID] = existing
+	return nil
+}
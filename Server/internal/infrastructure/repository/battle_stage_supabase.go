@@ -4,52 +4,160 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sync"
 
 	appdomain "server/internal/domain/battlestage"
-	"server/internal/supabase"
-)
-
-// BattleStageSupabaseRepository は Supabase Postgres を利用したバトルステージリポジトリです。
-type BattleStageSupabaseRepository struct {
-	client supabase.Client
-}
+	"server/internal/infrastructure/database"
+	"server/internal/tracing"
 
-// NewBattleStageSupabaseRepository は Supabase クライアントを用いたリポジトリを生成します。
-func NewBattleStageSupabaseRepository(client supabase.Client) *BattleStageSupabaseRepository {
-	return &BattleStageSupabaseRepository{client: client}
-}
-
-// FindNearby はハーサイン式を用いて半径内のステージを検索します。
-func (r *BattleStageSupabaseRepository) FindNearby(ctx context.Context, origin appdomain.Location, radiusMeters float64) ([]appdomain.StageWithDistance, error) {
-	if r.client == nil || !r.client.Ready() {
-		return nil, fmt.Errorf("supabase client not ready")
-	}
+	"go.opentelemetry.io/otel/attribute"
+)
 
-	const query = `
+// nearbyQueryTemplate は周辺ステージ検索の共通 SQL です。
+// %s には battle_stages (bs) に対する事前絞り込み条件が入ります。
+// 距離計算と並び順はどの経路でも同じハーサイン式を使用します。
+const nearbyQueryTemplate = `
 WITH stage_distance AS (
     SELECT
-        id::text AS id,
-        name,
-        latitude,
-        longitude,
-        radius_m,
-        description,
+        bs.id::text AS id,
+        bs.name,
+        bs.latitude,
+        bs.longitude,
+        bs.radius_m,
+        bs.description,
         6371000 * acos(
             LEAST(1, GREATEST(-1,
-                cos(radians($1)) * cos(radians(latitude)) * cos(radians(longitude) - radians($2)) +
-                sin(radians($1)) * sin(radians(latitude))
+                cos(radians($1)) * cos(radians(bs.latitude)) * cos(radians(bs.longitude) - radians($2)) +
+                sin(radians($1)) * sin(radians(bs.latitude))
             ))
         ) AS distance_m
-    FROM public.battle_stages
+    FROM public.battle_stages bs
+    WHERE %s
 )
 SELECT id, name, latitude, longitude, radius_m, description, distance_m
 FROM stage_distance
 WHERE distance_m <= $3
-ORDER BY distance_m ASC
-LIMIT 100;
+  AND ($4::double precision IS NULL OR (distance_m, id) > ($4::double precision, $5::text))
+ORDER BY distance_m ASC, id ASC
+LIMIT $6;
 `
 
-	rows, err := r.client.Query(ctx, query, origin.Latitude, origin.Longitude, radiusMeters)
+// indexedPrefilter は location (geography) の GiST インデックスを使う境界ボックス絞り込みです。
+// ST_DWithin は内部で半径分拡張した境界ボックスによるインデックス検索を行います。
+// PostGIS の球面半径 (6371008.8m) とハーサイン式の半径 (6371000m) の差や丸め誤差で
+// 境界上のステージを取りこぼさないよう、わずかに広い半径で絞り込みます。
+const indexedPrefilter = `ST_DWithin(
+        bs.location,
+        ST_SetSRID(ST_MakePoint($2, $1), 4326)::geography,
+        $3 * 1.001 + 1,
+        false
+    )`
+
+// fullScanPrefilter は location 列が存在しない環境向けの全件走査です。
+const fullScanPrefilter = `TRUE`
+
+var (
+	nearbyIndexedQuery  = fmt.Sprintf(nearbyQueryTemplate, indexedPrefilter)
+	nearbyFullScanQuery = fmt.Sprintf(nearbyQueryTemplate, fullScanPrefilter)
+)
+
+// BattleStageSupabaseRepository は PostgreSQL (Supabase) を利用したバトルステージリポジトリです。
+type BattleStageSupabaseRepository struct {
+	db *database.DB
+
+	mu             sync.Mutex
+	indexedChecked bool
+	indexed        bool
+}
+
+// NewBattleStageSupabaseRepository は共有の接続プールを用いたリポジトリを生成します。
+func NewBattleStageSupabaseRepository(db *database.DB) *BattleStageSupabaseRepository {
+	return &BattleStageSupabaseRepository{db: db}
+}
+
+// FindNearby はハーサイン式を用いて半径内のステージを距離順に検索します。
+// battle_stages.location 列があれば空間インデックスで候補を絞り込み、なければ全件走査します。
+// query.After が指定された場合は (distance_m, id) のキーセットで続きから取得します。
+func (r *BattleStageSupabaseRepository) FindNearby(ctx context.Context, query appdomain.NearbyQuery) ([]appdomain.StageWithDistance, error) {
+	ctx, span := tracing.Start(ctx, "BattleStageRepository.FindNearby")
+	defer span.End()
+
+	if !r.db.Ready() {
+		return nil, database.ErrNotConfigured
+	}
+
+	indexed := r.spatialIndexAvailable(ctx)
+	sqlQuery := nearbyFullScanQuery
+	if indexed {
+		sqlQuery = nearbyIndexedQuery
+	}
+	span.SetAttributes(
+		attribute.Float64("stage.radius_m", query.RadiusMeters),
+		attribute.Int("stage.limit", query.Limit),
+		attribute.Bool("stage.spatial_index", indexed),
+	)
+
+	return r.findNearby(ctx, sqlQuery, query)
+}
+
+// spatialIndexAvailable は location 列（004 マイグレーション）が適用済みかを確認します。
+// 確認に失敗した場合は結果をキャッシュせず、次回の呼び出しで再確認します。
+func (r *BattleStageSupabaseRepository) spatialIndexAvailable(ctx context.Context) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.indexedChecked {
+		return r.indexed
+	}
+
+	const probe = `
+SELECT EXISTS (
+    SELECT 1
+    FROM information_schema.columns
+    WHERE table_schema = 'public'
+      AND table_name = 'battle_stages'
+      AND column_name = 'location'
+)`
+
+	rows, err := r.db.Query(ctx, probe)
+	if err != nil {
+		return false
+	}
+	defer rows.Close()
+
+	var exists bool
+	if rows.Next() {
+		if err := rows.Scan(&exists); err != nil {
+			return false
+		}
+	}
+	if rows.Err() != nil {
+		return false
+	}
+
+	r.indexed = exists
+	r.indexedChecked = true
+	return exists
+}
+
+func (r *BattleStageSupabaseRepository) findNearby(ctx context.Context, sqlQuery string, query appdomain.NearbyQuery) ([]appdomain.StageWithDistance, error) {
+	var (
+		afterDistance *float64
+		afterID       *string
+	)
+	if query.After != nil {
+		afterDistance = &query.After.DistanceMeters
+		afterID = &query.After.StageID
+	}
+
+	rows, err := r.db.Query(ctx, sqlQuery,
+		query.Origin.Latitude,
+		query.Origin.Longitude,
+		query.RadiusMeters,
+		afterDistance,
+		afterID,
+		query.Limit,
+	)
 	if err != nil {
 		return nil, fmt.Errorf("query nearby battle stages: %w", err)
 	}
@@ -97,3 +205,66 @@ LIMIT 100;
 
 	return stages, nil
 }
+
+// FindByID は ID を指定してステージを取得します。
+func (r *BattleStageSupabaseRepository) FindByID(ctx context.Context, id string) (*appdomain.Stage, error) {
+	ctx, span := tracing.Start(ctx, "BattleStageRepository.FindByID")
+	defer span.End()
+
+	if !r.db.Ready() {
+		return nil, database.ErrNotConfigured
+	}
+
+	const sqlQuery = `
+SELECT
+    bs.id::text,
+    bs.name,
+    bs.latitude,
+    bs.longitude,
+    bs.radius_m,
+    bs.description
+FROM public.battle_stages bs
+WHERE bs.id::text = $1
+`
+
+	rows, err := r.db.Query(ctx, sqlQuery, id)
+	if err != nil {
+		return nil, fmt.Errorf("query battle stage: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("query battle stage: %w", err)
+		}
+		return nil, appdomain.ErrStageNotFound
+	}
+
+	var (
+		stage            appdomain.Stage
+		radiusValue      sql.NullFloat64
+		descriptionValue sql.NullString
+	)
+	if err := rows.Scan(
+		&stage.ID,
+		&stage.Name,
+		&stage.Location.Latitude,
+		&stage.Location.Longitude,
+		&radiusValue,
+		&descriptionValue,
+	); err != nil {
+		return nil, fmt.Errorf("scan battle stage: %w", err)
+	}
+
+	if radiusValue.Valid {
+		value := radiusValue.Float64
+		stage.RadiusMeters = &value
+	}
+
+	if descriptionValue.Valid {
+		value := descriptionValue.String
+		stage.Description = &value
+	}
+
+	return &stage, nil
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	appdomain "server/internal/domain/battlestage"
+	"server/internal/infrastructure/database"
+)
+
+// TestNearbyQueries_DifferOnlyInPrefilter はインデックス経由と全件走査の SQL が事前絞り込みの条件だけ異なり、
+// 半径の判定と並び順は同じハーサイン式の結果で行うことをデータベースなしで確認します。
+func TestNearbyQueries_DifferOnlyInPrefilter(t *testing.T) {
+	if got := strings.Replace(nearbyIndexedQuery, indexedPrefilter, fullScanPrefilter, 1); got != nearbyFullScanQuery {
+		t.Fatalf("queries differ outside the prefilter:\nindexed:\n%s\nfull scan:\n%s", nearbyIndexedQuery, nearbyFullScanQuery)
+	}
+
+	for _, want := range []string{"WHERE distance_m <= $3", "ORDER BY distance_m ASC, id ASC"} {
+		if !strings.Contains(nearbyIndexedQuery, want) {
+			t.Errorf("indexed query does not contain %q", want)
+		}
+	}
+
+	// 事前絞り込みはハーサイン式と同じ引数（$1: 緯度, $2: 経度, $3: 半径）を使い、半径より広く取る
+	for _, want := range []string{"ST_MakePoint($2, $1)", "$3 * 1.001 + 1"} {
+		if !strings.Contains(indexedPrefilter, want) {
+			t.Errorf("indexed prefilter does not contain %q", want)
+		}
+	}
+	if margin := 6371008.8 / 6371000; margin >= 1.001 {
+		t.Errorf("prefilter margin 1.001 does not cover the PostGIS sphere radius ratio %f", margin)
+	}
+}
+
+// TestBattleStageSupabaseRepository_IndexedMatchesFullScan は空間インデックス経由の検索結果が
+// 従来の全件走査クエリと同じ内容・同じハーサイン距離順になることを実データベースで確認します。
+// TEST_SUPABASE_DB_URL が設定されていない場合はスキップします。
+func TestBattleStageSupabaseRepository_IndexedMatchesFullScan(t *testing.T) {
+	connString := os.Getenv("TEST_SUPABASE_DB_URL")
+	if connString == "" {
+		t.Skip("TEST_SUPABASE_DB_URL is not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	db, err := database.Open(ctx, database.Options{URL: connString})
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewBattleStageSupabaseRepository(db)
+	if !repo.spatialIndexAvailable(ctx) {
+		t.Skip("battle_stages.location is missing; apply migrations/003 first")
+	}
+
+	origins := []appdomain.Location{
+		{Latitude: 35.681236, Longitude: 139.767125}, // 東京駅
+		{Latitude: 34.702485, Longitude: 135.495951}, // 大阪駅
+		{Latitude: 43.068661, Longitude: 141.350755}, // 札幌駅
+	}
+
+	rows, err := db.Query(ctx, `SELECT latitude, longitude FROM public.battle_stages LIMIT 5`)
+	if err != nil {
+		t.Fatalf("failed to load stage origins: %v", err)
+	}
+	for rows.Next() {
+		var origin appdomain.Location
+		if err := rows.Scan(&origin.Latitude, &origin.Longitude); err != nil {
+			rows.Close()
+			t.Fatalf("failed to scan stage origin: %v", err)
+		}
+		origins = append(origins, origin)
+	}
+	rows.Close()
+
+	for _, origin := range origins {
+		for _, radius := range []float64{100, 1000, 10000, 200000} {
+			query := appdomain.NearbyQuery{Origin: origin, RadiusMeters: radius, Limit: 1000}
+
+			expected, err := repo.findNearby(ctx, nearbyFullScanQuery, query)
+			if err != nil {
+				t.Fatalf("full scan query failed: %v", err)
+			}
+			actual, err := repo.findNearby(ctx, nearbyIndexedQuery, query)
+			if err != nil {
+				t.Fatalf("indexed query failed: %v", err)
+			}
+
+			if len(actual) != len(expected) {
+				t.Fatalf("origin=%+v radius=%.0f: expected %d stages, got %d", origin, radius, len(expected), len(actual))
+			}
+			for i := range expected {
+				if actual[i].Stage.ID != expected[i].Stage.ID || actual[i].DistanceMeters != expected[i].DistanceMeters {
+					t.Errorf("origin=%+v radius=%.0f index %d: expected %s (%.3fm), got %s (%.3fm)",
+						origin, radius, i,
+						expected[i].Stage.ID, expected[i].DistanceMeters,
+						actual[i].Stage.ID, actual[i].DistanceMeters)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"server/internal/game/battle"
+	"server/internal/infrastructure/database"
+	"server/internal/tracing"
+
+	"github.com/google/uuid"
+)
+
+// GameEventRepository は対戦の経過（game_sessions / game_events）を保存するリポジトリです
+type GameEventRepository struct {
+	db *database.DB
+}
+
+// NewGameEventRepository は新しい対戦記録リポジトリを作成します
+func NewGameEventRepository(db *database.DB) *GameEventRepository {
+	return &GameEventRepository{db: db}
+}
+
+// RecordBattle はセッションの行を作成（作成済みなら状態を更新）し、イベントを追加します。
+// 記録は配信と並行に保存されるため、終了済みの状態と終了時刻は後から届いた記録で戻しません。
+func (r *GameEventRepository) RecordBattle(ctx context.Context, record battle.Record) error {
+	ctx, span := tracing.Start(ctx, "GameEventRepository.RecordBattle")
+	defer span.End()
+
+	return r.db.WithTx(ctx, func(ctx context.Context) error {
+		// ステージが battle_stages にない場合（外部のステージ ID など）は battle_stage_id を空にする
+		_, err := r.db.Exec(ctx, `
+			INSERT INTO public.game_sessions (id, mode, status, battle_stage_id, started_at, ended_at)
+			VALUES ($1, 'duel', $2, (SELECT id FROM public.battle_stages WHERE id::text = $3), $4, $5)
+			ON CONFLICT (id) DO UPDATE SET
+				status = CASE WHEN game_sessions.status = 'finished' THEN game_sessions.status ELSE EXCLUDED.status END,
+				started_at = COALESCE(game_sessions.started_at, EXCLUDED.started_at),
+				ended_at = COALESCE(game_sessions.ended_at, EXCLUDED.ended_at)
+		`, record.SessionID, string(record.Status), record.StageID, record.StartedAt, record.EndedAt)
+		if err != nil {
+			return fmt.Errorf("failed to save game session: %w", err)
+		}
+
+		for _, event := range record.Events {
+			result, err := r.db.Exec(ctx, `
+				INSERT INTO public.game_events (id, session_id, trigger_id, target_id, trigger_hp, target_hp, category, type, magic_type_id, amount, mitigated, created_at)
+				SELECT $1::uuid, $2::uuid, p.id, (SELECT id FROM public.players WHERE user_id = $4::uuid), $5::smallint, $6::smallint,
+					$7::public.game_event_category, $8::public.game_event_type, NULLIF($9::text, ''), $10::integer, $11::integer, $12::timestamptz
+				FROM public.players p
+				WHERE p.user_id = $3::uuid
+			`,
+				uuid.New(),
+				record.SessionID,
+				event.TriggerID,
+				event.TargetID,
+				event.TriggerHP,
+				event.TargetHP,
+				string(event.Category),
+				string(event.Type),
+				event.MagicTypeID,
+				event.Amount,
+				event.Mitigated,
+				event.At,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to save game event: %w", err)
+			}
+			if result.RowsAffected() == 0 {
+				return fmt.Errorf("player not found")
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"server/internal/game/battle"
+	"server/internal/infrastructure/database"
+)
+
+// TestGameEventRepository_RecordBattle は防御と魔法のイベントがプレイヤーの ID で game_events に保存され、
+// 後から届いた対戦中の記録で終了済みの状態が戻らないことを実データベースで確認します。
+// TEST_SUPABASE_DB_URL が設定されていない場合はスキップします。
+func TestGameEventRepository_RecordBattle(t *testing.T) {
+	connString := os.Getenv("TEST_SUPABASE_DB_URL")
+	if connString == "" {
+		t.Skip("TEST_SUPABASE_DB_URL is not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	db, err := database.Open(ctx, database.Options{URL: connString})
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer db.Close()
+
+	caster, defender := uuid.New(), uuid.New()
+	for _, userID := range []uuid.UUID{caster, defender} {
+		if _, err := db.Exec(ctx, `INSERT INTO users (id, email, password_hash) VALUES ($1, $2, 'x')`, userID, userID.String()+"@example.com"); err != nil {
+			t.Fatalf("failed to create user: %v", err)
+		}
+		if _, err := db.Exec(ctx, `INSERT INTO players (user_id, display_name) VALUES ($1, 'player')`, userID); err != nil {
+			t.Fatalf("failed to create player: %v", err)
+		}
+	}
+	sessionID := uuid.New()
+	defer func() {
+		db.Exec(context.Background(), `DELETE FROM public.game_sessions WHERE id = $1`, sessionID)
+		db.Exec(context.Background(), `DELETE FROM users WHERE id = ANY($1)`, []uuid.UUID{caster, defender})
+	}()
+
+	repo := NewGameEventRepository(db)
+	startedAt := time.Now().UTC().Truncate(time.Millisecond)
+	endedAt := startedAt.Add(time.Minute)
+	intPtr := func(v int) *int { return &v }
+
+	finished := battle.Record{SessionID: sessionID, StageID: "not-a-stage", Status: battle.StatusFinished, StartedAt: &startedAt, EndedAt: &endedAt}
+	active := battle.Record{
+		SessionID: sessionID,
+		StageID:   "not-a-stage",
+		Status:    battle.StatusActive,
+		StartedAt: &startedAt,
+		Events: []battle.RecordedEvent{
+			{TriggerID: defender, TriggerHP: intPtr(100), Category: battle.CategoryShield, Type: battle.RecordDefend, Amount: intPtr(40), At: startedAt},
+			{TriggerID: caster, TargetID: &defender, TriggerHP: intPtr(100), TargetHP: intPtr(80), Category: battle.CategoryAttack, Type: battle.RecordFire, MagicTypeID: "fireball", Amount: intPtr(20), Mitigated: intPtr(40), At: startedAt},
+			{TriggerID: defender, TriggerHP: intPtr(80), Category: battle.CategoryShield, Type: battle.RecordBreak, At: startedAt},
+		},
+	}
+	if err := repo.RecordBattle(ctx, finished); err != nil {
+		t.Fatalf("record finished: %v", err)
+	}
+	if err := repo.RecordBattle(ctx, active); err != nil {
+		t.Fatalf("record events: %v", err)
+	}
+
+	var status string
+	var storedEndedAt *time.Time
+	if err := db.QueryRow(ctx, `SELECT status, ended_at FROM public.game_sessions WHERE id = $1`, sessionID).Scan(&status, &storedEndedAt); err != nil {
+		t.Fatalf("failed to load session: %v", err)
+	}
+	if status != string(battle.StatusFinished) || storedEndedAt == nil || !storedEndedAt.Equal(endedAt) {
+		t.Fatalf("session status = %s, ended_at = %v", status, storedEndedAt)
+	}
+
+	var attacks, defenses, breaks int
+	err = db.QueryRow(ctx, `
+		SELECT
+			COUNT(*) FILTER (WHERE e.category = 'attack' AND e.type = 'fire' AND e.target_id = target.id AND e.amount = 20 AND e.mitigated = 40 AND e.magic_type_id = 'fireball'),
+			COUNT(*) FILTER (WHERE e.category = 'shield' AND e.type = 'defend' AND e.amount = 40 AND e.target_id IS NULL),
+			COUNT(*) FILTER (WHERE e.category = 'shield' AND e.type = 'break' AND e.trigger_id = target.id)
+		FROM public.game_events e, public.players target
+		WHERE e.session_id = $1 AND target.user_id = $2
+	`, sessionID, defender).Scan(&attacks, &defenses, &breaks)
+	if err != nil {
+		t.Fatalf("failed to load events: %v", err)
+	}
+	if attacks != 1 || defenses != 1 || breaks != 1 {
+		t.Fatalf("events: attack = %d, defend = %d, break = %d", attacks, defenses, breaks)
+	}
+
+	unknown := battle.Record{SessionID: sessionID, Status: battle.StatusActive, Events: []battle.RecordedEvent{{TriggerID: uuid.New(), Category: battle.CategoryGuard, Type: battle.RecordDefend, At: startedAt}}}
+	if err := repo.RecordBattle(ctx, unknown); err == nil {
+		t.Fatal("recorded an event for a user without a player")
+	}
+}
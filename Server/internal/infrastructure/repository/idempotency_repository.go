@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"server/internal/domain/entities"
+	"server/internal/infrastructure/database"
+	"server/internal/tracing"
+)
+
+// IdempotencyRepository は idempotency_keys テーブルを利用した Idempotency-Key リポジトリです
+type IdempotencyRepository struct {
+	db *database.DB
+}
+
+// NewIdempotencyRepository は共有の接続プールを用いたリポジトリを生成します。
+func NewIdempotencyRepository(db *database.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Reserve は record を処理中として保存します。
+// 有効期限内の同じキーがあれば保存せずにそれを返します。staleBefore より前から処理中のものは置き換えます。
+func (r *IdempotencyRepository) Reserve(ctx context.Context, record *entities.IdempotencyRecord, staleBefore time.Time) (*entities.IdempotencyRecord, error) {
+	ctx, span := tracing.Start(ctx, "IdempotencyRepository.Reserve")
+	defer span.End()
+
+	if !r.db.Ready() {
+		return nil, database.ErrNotConfigured
+	}
+
+	// 期限切れか中断されたレコードだけを置き換える。置き換えなかった場合は行が返らない
+	const reserve = `
+INSERT INTO public.idempotency_keys AS k (user_id, key, fingerprint, created_at, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id, key) DO UPDATE SET
+    fingerprint = EXCLUDED.fingerprint,
+    status_code = NULL,
+    response_headers = NULL,
+    response_body = NULL,
+    created_at = EXCLUDED.created_at,
+    expires_at = EXCLUDED.expires_at
+WHERE k.expires_at <= EXCLUDED.created_at
+   OR (k.status_code IS NULL AND k.created_at < $6)
+RETURNING true
+`
+	const existing = `
+SELECT fingerprint, COALESCE(status_code, 0), response_headers, response_body, created_at, expires_at
+FROM public.idempotency_keys
+WHERE user_id = $1 AND key = $2
+`
+
+	// 既存の行が確認までの間に削除された場合に備えて 1 回だけやり直す
+	for attempt := 0; attempt < 2; attempt++ {
+		var reserved bool
+		err := r.db.QueryRow(ctx, reserve,
+			record.UserID, record.Key, record.Fingerprint, record.CreatedAt, record.ExpiresAt, staleBefore,
+		).Scan(&reserved)
+		if err == nil {
+			return nil, nil
+		}
+		if !errors.Is(err, database.ErrNoRows) {
+			return nil, fmt.Errorf("reserve idempotency key: %w", err)
+		}
+
+		found := entities.IdempotencyRecord{UserID: record.UserID, Key: record.Key}
+		var header []byte
+		err = r.db.QueryRow(ctx, existing, record.UserID, record.Key).Scan(
+			&found.Fingerprint, &found.StatusCode, &header, &found.Body, &found.CreatedAt, &found.ExpiresAt,
+		)
+		if errors.Is(err, database.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("get idempotency key: %w", err)
+		}
+		if len(header) > 0 {
+			if err := json.Unmarshal(header, &found.Header); err != nil {
+				return nil, fmt.Errorf("decode idempotent response headers: %w", err)
+			}
+		}
+		return &found, nil
+	}
+	return nil, fmt.Errorf("reserve idempotency key: concurrent modification")
+}
+
+// Complete は処理中のレコードにレスポンスを保存します
+func (r *IdempotencyRepository) Complete(ctx context.Context, record *entities.IdempotencyRecord) error {
+	ctx, span := tracing.Start(ctx, "IdempotencyRepository.Complete")
+	defer span.End()
+
+	if !r.db.Ready() {
+		return database.ErrNotConfigured
+	}
+
+	header, err := json.Marshal(record.Header)
+	if err != nil {
+		return fmt.Errorf("encode idempotent response headers: %w", err)
+	}
+
+	const query = `
+UPDATE public.idempotency_keys
+SET status_code = $3, response_headers = $4::jsonb, response_body = $5
+WHERE user_id = $1 AND key = $2 AND fingerprint = $6
+`
+	if _, err := r.db.Exec(ctx, query, record.UserID, record.Key, record.StatusCode, string(header), record.Body, record.Fingerprint); err != nil {
+		return fmt.Errorf("complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+// Release は処理中のレコードを削除します
+func (r *IdempotencyRepository) Release(ctx context.Context, userID uuid.UUID, key string) error {
+	ctx, span := tracing.Start(ctx, "IdempotencyRepository.Release")
+	defer span.End()
+
+	if !r.db.Ready() {
+		return database.ErrNotConfigured
+	}
+
+	const query = `DELETE FROM public.idempotency_keys WHERE user_id = $1 AND key = $2 AND status_code IS NULL`
+	if _, err := r.db.Exec(ctx, query, userID, key); err != nil {
+		return fmt.Errorf("release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired は有効期限を過ぎたレコードを削除します
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) error {
+	ctx, span := tracing.Start(ctx, "IdempotencyRepository.DeleteExpired")
+	defer span.End()
+
+	if !r.db.Ready() {
+		return database.ErrNotConfigured
+	}
+
+	if _, err := r.db.Exec(ctx, `DELETE FROM public.idempotency_keys WHERE expires_at <= $1`, now); err != nil {
+		return fmt.Errorf("delete expired idempotency keys: %w", err)
+	}
+	return nil
+}
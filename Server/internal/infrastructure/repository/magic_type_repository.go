@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	domainmagic "server/internal/domain/magic"
+	"server/internal/infrastructure/database"
+	"server/internal/tracing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// MagicTypeRepository は magic_types テーブルを利用した魔法の定義のリポジトリです
+type MagicTypeRepository struct {
+	db *database.DB
+}
+
+// NewMagicTypeRepository は共有の接続プールを用いたリポジトリを生成します。
+func NewMagicTypeRepository(db *database.DB) *MagicTypeRepository {
+	return &MagicTypeRepository{db: db}
+}
+
+// List はすべての魔法を ID 順に返します
+func (r *MagicTypeRepository) List(ctx context.Context) ([]domainmagic.MagicType, error) {
+	ctx, span := tracing.Start(ctx, "MagicTypeRepository.List")
+	defer span.End()
+
+	if !r.db.Ready() {
+		return nil, database.ErrNotConfigured
+	}
+
+	const query = `
+SELECT id, name, kind, target, mp_cost, description, damage, heal, sound, chant, chant_match, cooldown_ms, cast_time_ms, stun_ms
+FROM public.magic_types
+ORDER BY id ASC
+`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query magic types: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]domainmagic.MagicType, 0)
+	for rows.Next() {
+		var magicType domainmagic.MagicType
+		var cooldownMS, castTimeMS, stunMS int64
+		if err := rows.Scan(
+			&magicType.ID,
+			&magicType.Name,
+			&magicType.Kind,
+			&magicType.Target,
+			&magicType.MPCost,
+			&magicType.Description,
+			&magicType.Damage,
+			&magicType.Heal,
+			&magicType.Sound,
+			&magicType.Chant,
+			&magicType.ChantMatch,
+			&cooldownMS,
+			&castTimeMS,
+			&stunMS,
+		); err != nil {
+			return nil, fmt.Errorf("scan magic type: %w", err)
+		}
+		magicType.Cooldown = time.Duration(cooldownMS) * time.Millisecond
+		magicType.CastTime = time.Duration(castTimeMS) * time.Millisecond
+		magicType.Stun = time.Duration(stunMS) * time.Millisecond
+		list = append(list, magicType)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate magic types: %w", err)
+	}
+
+	return list, nil
+}
+
+// Create は魔法を追加します。ID の重複は主キー制約で検出し、ErrMagicTypeExists に変換します。
+func (r *MagicTypeRepository) Create(ctx context.Context, magicType *domainmagic.MagicType) error {
+	ctx, span := tracing.Start(ctx, "MagicTypeRepository.Create")
+	defer span.End()
+
+	if !r.db.Ready() {
+		return database.ErrNotConfigured
+	}
+
+	const query = `
+INSERT INTO public.magic_types (id, name, kind, target, mp_cost, description, damage, heal, sound, chant, chant_match, cooldown_ms, cast_time_ms, stun_ms)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+`
+
+	_, err := r.db.Exec(ctx, query,
+		magicType.ID,
+		magicType.Name,
+		magicType.Kind,
+		magicType.Target,
+		magicType.MPCost,
+		magicType.Description,
+		magicType.Damage,
+		magicType.Heal,
+		magicType.Sound,
+		magicType.Chant,
+		magicType.ChantMatch,
+		magicType.Cooldown.Milliseconds(),
+		magicType.CastTime.Milliseconds(),
+		magicType.Stun.Milliseconds(),
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return domainmagic.ErrMagicTypeExists
+		}
+		return fmt.Errorf("insert magic type: %w", err)
+	}
+
+	return nil
+}
+
+// Update は魔法を更新します
+func (r *MagicTypeRepository) Update(ctx context.Context, magicType *domainmagic.MagicType) error {
+	ctx, span := tracing.Start(ctx, "MagicTypeRepository.Update")
+	defer span.End()
+
+	if !r.db.Ready() {
+		return database.ErrNotConfigured
+	}
+
+	const query = `
+UPDATE public.magic_types
+SET name = $2, kind = $3, target = $4, mp_cost = $5, description = $6, damage = $7, heal = $8, sound = $9,
+    chant = $10, chant_match = $11, cooldown_ms = $12, cast_time_ms = $13, stun_ms = $14, updated_at = now()
+WHERE id = $1
+`
+
+	tag, err := r.db.Exec(ctx, query,
+		magicType.ID,
+		magicType.Name,
+		magicType.Kind,
+		magicType.Target,
+		magicType.MPCost,
+		magicType.Description,
+		magicType.Damage,
+		magicType.Heal,
+		magicType.Sound,
+		magicType.Chant,
+		magicType.ChantMatch,
+		magicType.Cooldown.Milliseconds(),
+		magicType.CastTime.Milliseconds(),
+		magicType.Stun.Milliseconds(),
+	)
+	if err != nil {
+		return fmt.Errorf("update magic type: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return domainmagic.ErrMagicTypeNotFound
+	}
+
+	return nil
+}
+
+// Delete は魔法を削除します
+func (r *MagicTypeRepository) Delete(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "MagicTypeRepository.Delete")
+	defer span.End()
+
+	if !r.db.Ready() {
+		return database.ErrNotConfigured
+	}
+
+	tag, err := r.db.Exec(ctx, `DELETE FROM public.magic_types WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete magic type: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return domainmagic.ErrMagicTypeNotFound
+	}
+
+	return nil
+}
@@ -2,37 +2,44 @@ package repository
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
 	"server/internal/domain/entities"
+	"server/internal/infrastructure/database"
+	"server/internal/tracing"
 
 	"github.com/google/uuid"
 )
 
 // PlayerRepositoryImpl はプレイヤーリポジトリの実装です
 type PlayerRepositoryImpl struct {
-	db *sql.DB
+	db *database.DB
 }
 
 // NewPlayerRepository は新しいプレイヤーリポジトリを作成します
-func NewPlayerRepository(db *sql.DB) *PlayerRepositoryImpl {
+func NewPlayerRepository(db *database.DB) *PlayerRepositoryImpl {
 	return &PlayerRepositoryImpl{db: db}
 }
 
 // CreatePlayer は新しいプレイヤーを作成します
 func (r *PlayerRepositoryImpl) CreatePlayer(ctx context.Context, player *entities.Player) error {
+	ctx, span := tracing.Start(ctx, "PlayerRepository.CreatePlayer")
+	defer span.End()
+
 	query := `
-		INSERT INTO players (id, user_id, display_name, hp, mp, rank, avatar_url, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO players (id, user_id, display_name, hp, max_hp, hp_updated_at, mp, rank, avatar_url, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := r.db.Exec(ctx, query,
 		player.ID,
 		player.UserID,
 		player.DisplayName,
 		player.HP,
+		player.MaxHP,
+		player.HPUpdatedAt,
 		player.MP,
 		player.Rank,
 		player.AvatarURL,
@@ -49,18 +56,23 @@ func (r *PlayerRepositoryImpl) CreatePlayer(ctx context.Context, player *entitie
 
 // GetPlayerByUserID はユーザーIDでプレイヤーを取得します
 func (r *PlayerRepositoryImpl) GetPlayerByUserID(ctx context.Context, userID uuid.UUID) (*entities.Player, error) {
+	ctx, span := tracing.Start(ctx, "PlayerRepository.GetPlayerByUserID")
+	defer span.End()
+
 	query := `
-		SELECT id, user_id, display_name, hp, mp, rank, avatar_url, created_at, updated_at
+		SELECT id, user_id, display_name, hp, max_hp, hp_updated_at, mp, rank, avatar_url, created_at, updated_at
 		FROM players
 		WHERE user_id = $1
 	`
 
 	var player entities.Player
-	err := r.db.QueryRowContext(ctx, query, userID).Scan(
+	err := r.db.QueryRow(ctx, query, userID).Scan(
 		&player.ID,
 		&player.UserID,
 		&player.DisplayName,
 		&player.HP,
+		&player.MaxHP,
+		&player.HPUpdatedAt,
 		&player.MP,
 		&player.Rank,
 		&player.AvatarURL,
@@ -69,7 +81,7 @@ func (r *PlayerRepositoryImpl) GetPlayerByUserID(ctx context.Context, userID uui
 	)
 
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, database.ErrNoRows) {
 			return nil, fmt.Errorf("player not found")
 		}
 		return nil, fmt.Errorf("failed to get player by user id: %w", err)
@@ -80,18 +92,23 @@ func (r *PlayerRepositoryImpl) GetPlayerByUserID(ctx context.Context, userID uui
 
 // GetPlayerByID はIDでプレイヤーを取得します
 func (r *PlayerRepositoryImpl) GetPlayerByID(ctx context.Context, id uuid.UUID) (*entities.Player, error) {
+	ctx, span := tracing.Start(ctx, "PlayerRepository.GetPlayerByID")
+	defer span.End()
+
 	query := `
-		SELECT id, user_id, display_name, hp, mp, rank, avatar_url, created_at, updated_at
+		SELECT id, user_id, display_name, hp, max_hp, hp_updated_at, mp, rank, avatar_url, created_at, updated_at
 		FROM players
 		WHERE id = $1
 	`
 
 	var player entities.Player
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := r.db.QueryRow(ctx, query, id).Scan(
 		&player.ID,
 		&player.UserID,
 		&player.DisplayName,
 		&player.HP,
+		&player.MaxHP,
+		&player.HPUpdatedAt,
 		&player.MP,
 		&player.Rank,
 		&player.AvatarURL,
@@ -100,7 +117,7 @@ func (r *PlayerRepositoryImpl) GetPlayerByID(ctx context.Context, id uuid.UUID)
 	)
 
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, database.ErrNoRows) {
 			return nil, fmt.Errorf("player not found")
 		}
 		return nil, fmt.Errorf("failed to get player by id: %w", err)
@@ -111,16 +128,21 @@ func (r *PlayerRepositoryImpl) GetPlayerByID(ctx context.Context, id uuid.UUID)
 
 // UpdatePlayer はプレイヤー情報を更新します
 func (r *PlayerRepositoryImpl) UpdatePlayer(ctx context.Context, player *entities.Player) error {
+	ctx, span := tracing.Start(ctx, "PlayerRepository.UpdatePlayer")
+	defer span.End()
+
 	query := `
 		UPDATE players
-		SET display_name = $2, hp = $3, mp = $4, rank = $5, avatar_url = $6, updated_at = $7
+		SET display_name = $2, hp = $3, max_hp = $4, hp_updated_at = $5, mp = $6, rank = $7, avatar_url = $8, updated_at = $9
 		WHERE id = $1
 	`
 
-	result, err := r.db.ExecContext(ctx, query,
+	result, err := r.db.Exec(ctx, query,
 		player.ID,
 		player.DisplayName,
 		player.HP,
+		player.MaxHP,
+		player.HPUpdatedAt,
 		player.MP,
 		player.Rank,
 		player.AvatarURL,
@@ -131,37 +153,30 @@ func (r *PlayerRepositoryImpl) UpdatePlayer(ctx context.Context, player *entitie
 		return fmt.Errorf("failed to update player: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
-
-	if rowsAffected == 0 {
+	if result.RowsAffected() == 0 {
 		return fmt.Errorf("player not found")
 	}
 
 	return nil
 }
 
-// UpdatePlayerHP はHPを更新します
+// UpdatePlayerHP はHPを更新し、自然回復の起点をリセットします
 func (r *PlayerRepositoryImpl) UpdatePlayerHP(ctx context.Context, playerID uuid.UUID, hp int) error {
+	ctx, span := tracing.Start(ctx, "PlayerRepository.UpdatePlayerHP")
+	defer span.End()
+
 	query := `
 		UPDATE players
-		SET hp = $2, updated_at = $3
+		SET hp = $2, hp_updated_at = $3, updated_at = $3
 		WHERE id = $1
 	`
 
-	result, err := r.db.ExecContext(ctx, query, playerID, hp, time.Now())
+	result, err := r.db.Exec(ctx, query, playerID, hp, time.Now())
 	if err != nil {
 		return fmt.Errorf("failed to update player hp: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
-
-	if rowsAffected == 0 {
+	if result.RowsAffected() == 0 {
 		return fmt.Errorf("player not found")
 	}
 
@@ -170,23 +185,44 @@ func (r *PlayerRepositoryImpl) UpdatePlayerHP(ctx context.Context, playerID uuid
 
 // UpdatePlayerMP はMPを更新します
 func (r *PlayerRepositoryImpl) UpdatePlayerMP(ctx context.Context, playerID uuid.UUID, mp int) error {
+	ctx, span := tracing.Start(ctx, "PlayerRepository.UpdatePlayerMP")
+	defer span.End()
+
 	query := `
 		UPDATE players
 		SET mp = $2, updated_at = $3
 		WHERE id = $1
 	`
 
-	result, err := r.db.ExecContext(ctx, query, playerID, mp, time.Now())
+	result, err := r.db.Exec(ctx, query, playerID, mp, time.Now())
 	if err != nil {
 		return fmt.Errorf("failed to update player mp: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("player not found")
+	}
+
+	return nil
+}
+
+// SaveBattleResult は対戦を終えたプレイヤーの HP / MP を保存し、自然回復の起点を対戦の終了時刻 at にします
+func (r *PlayerRepositoryImpl) SaveBattleResult(ctx context.Context, userID uuid.UUID, hp, mp int, at time.Time) error {
+	ctx, span := tracing.Start(ctx, "PlayerRepository.SaveBattleResult")
+	defer span.End()
+
+	query := `
+		UPDATE players
+		SET hp = LEAST($2, max_hp), mp = $3, hp_updated_at = $4, updated_at = $4
+		WHERE user_id = $1
+	`
+
+	result, err := r.db.Exec(ctx, query, userID, hp, mp, at)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return fmt.Errorf("failed to save battle result: %w", err)
 	}
 
-	if rowsAffected == 0 {
+	if result.RowsAffected() == 0 {
 		return fmt.Errorf("player not found")
 	}
 
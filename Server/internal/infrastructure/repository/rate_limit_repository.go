@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"server/internal/infrastructure/database"
+	"server/internal/tracing"
+)
+
+// RateLimitRepository は rate_limit_buckets テーブルでトークンバケットを共有するレート制限のバックエンドです。
+// 複数のインスタンスが同じバケットを使うため、インスタンス数に関係なく制限が一定になります。
+type RateLimitRepository struct {
+	db *database.DB
+}
+
+// NewRateLimitRepository は共有の接続プールを用いたリポジトリを生成します。
+func NewRateLimitRepository(db *database.DB) *RateLimitRepository {
+	return &RateLimitRepository{db: db}
+}
+
+// Take はバケットを補充してからトークンを 1 つ消費できれば消費します。
+// 補充と消費は 1 つの UPSERT で行い、行ロックにより同じキーへの同時リクエストも正しく数えます。
+// 時刻はインスタンス間の時計のずれを避けるためデータベースの clock_timestamp() を使います。
+func (r *RateLimitRepository) Take(ctx context.Context, key string, limit int, period time.Duration) (float64, bool, error) {
+	ctx, span := tracing.Start(ctx, "RateLimitRepository.Take")
+	defer span.End()
+
+	if !r.db.Ready() {
+		return 0, false, database.ErrNotConfigured
+	}
+
+	// $2: 容量, $3: 1 秒あたりの補充量。SET の右辺はすべて更新前の行を参照する
+	const refilled = `LEAST($2::float8, b.tokens + GREATEST(EXTRACT(EPOCH FROM clock_timestamp() - b.updated_at), 0) * $3::float8)`
+	const query = `
+INSERT INTO public.rate_limit_buckets AS b (key, tokens, allowed, updated_at)
+VALUES ($1, $2::float8 - 1, true, clock_timestamp())
+ON CONFLICT (key) DO UPDATE SET
+    tokens = CASE WHEN ` + refilled + ` >= 1 THEN ` + refilled + ` - 1 ELSE ` + refilled + ` END,
+    allowed = ` + refilled + ` >= 1,
+    updated_at = clock_timestamp()
+RETURNING tokens, allowed
+`
+
+	var (
+		tokens  float64
+		allowed bool
+	)
+	if err := r.db.QueryRow(ctx, query, key, limit, float64(limit)/period.Seconds()).Scan(&tokens, &allowed); err != nil {
+		return 0, false, fmt.Errorf("take rate limit token: %w", err)
+	}
+	return tokens, allowed, nil
+}
+
+// Sweep は before より前から更新のないバケットを削除します
+func (r *RateLimitRepository) Sweep(ctx context.Context, before time.Time) error {
+	ctx, span := tracing.Start(ctx, "RateLimitRepository.Sweep")
+	defer span.End()
+
+	if !r.db.Ready() {
+		return database.ErrNotConfigured
+	}
+
+	if _, err := r.db.Exec(ctx, `DELETE FROM public.rate_limit_buckets WHERE updated_at < $1`, before); err != nil {
+		return fmt.Errorf("sweep rate limit buckets: %w", err)
+	}
+	return nil
+}
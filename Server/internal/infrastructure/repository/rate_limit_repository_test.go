@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"server/internal/infrastructure/database"
+)
+
+// TestRateLimitRepository_Take はバケットの容量まで受け付けた後に拒否することを実データベースで確認します。
+// TEST_SUPABASE_DB_URL が設定されていない場合はスキップします。
+func TestRateLimitRepository_Take(t *testing.T) {
+	connString := os.Getenv("TEST_SUPABASE_DB_URL")
+	if connString == "" {
+		t.Skip("TEST_SUPABASE_DB_URL is not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	db, err := database.Open(ctx, database.Options{URL: connString})
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewRateLimitRepository(db)
+	key := "test:" + uuid.NewString()
+	defer db.Exec(context.Background(), `DELETE FROM public.rate_limit_buckets WHERE key = $1`, key)
+
+	for i := 0; i < 3; i++ {
+		tokens, allowed, err := repo.Take(ctx, key, 3, time.Hour)
+		if err != nil {
+			t.Fatalf("take %d: %v", i, err)
+		}
+		if !allowed || tokens < float64(2-i) || tokens >= float64(3-i) {
+			t.Fatalf("take %d: tokens = %v, allowed = %v", i, tokens, allowed)
+		}
+	}
+	if _, allowed, err := repo.Take(ctx, key, 3, time.Hour); err != nil || allowed {
+		t.Fatalf("take over limit: allowed = %v, err = %v", allowed, err)
+	}
+}
@@ -2,30 +2,35 @@ package repository
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 	"fmt"
 
 	"server/internal/domain/entities"
+	"server/internal/infrastructure/database"
+	"server/internal/tracing"
 )
 
 // SessionRepositoryImpl はセッションリポジトリの実装です
 type SessionRepositoryImpl struct {
-	db *sql.DB
+	db *database.DB
 }
 
 // NewSessionRepository は新しいセッションリポジトリを作成します
-func NewSessionRepository(db *sql.DB) *SessionRepositoryImpl {
+func NewSessionRepository(db *database.DB) *SessionRepositoryImpl {
 	return &SessionRepositoryImpl{db: db}
 }
 
 // CreateSession は新しいセッションを作成します
 func (r *SessionRepositoryImpl) CreateSession(ctx context.Context, session *entities.Session) error {
+	ctx, span := tracing.Start(ctx, "SessionRepository.CreateSession")
+	defer span.End()
+
 	query := `
 		INSERT INTO sessions (id, user_id, token, expires_at, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6)
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := r.db.Exec(ctx, query,
 		session.ID,
 		session.UserID,
 		session.Token,
@@ -43,6 +48,9 @@ func (r *SessionRepositoryImpl) CreateSession(ctx context.Context, session *enti
 
 // GetSessionByToken はトークンでセッションを取得します
 func (r *SessionRepositoryImpl) GetSessionByToken(ctx context.Context, token string) (*entities.Session, error) {
+	ctx, span := tracing.Start(ctx, "SessionRepository.GetSessionByToken")
+	defer span.End()
+
 	query := `
 		SELECT id, user_id, token, expires_at, created_at, updated_at
 		FROM sessions
@@ -50,7 +58,7 @@ func (r *SessionRepositoryImpl) GetSessionByToken(ctx context.Context, token str
 	`
 
 	var session entities.Session
-	err := r.db.QueryRowContext(ctx, query, token).Scan(
+	err := r.db.QueryRow(ctx, query, token).Scan(
 		&session.ID,
 		&session.UserID,
 		&session.Token,
@@ -60,7 +68,7 @@ func (r *SessionRepositoryImpl) GetSessionByToken(ctx context.Context, token str
 	)
 
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, database.ErrNoRows) {
 			return nil, fmt.Errorf("session not found")
 		}
 		return nil, fmt.Errorf("failed to get session by token: %w", err)
@@ -71,19 +79,17 @@ func (r *SessionRepositoryImpl) GetSessionByToken(ctx context.Context, token str
 
 // DeleteSession はセッションを削除します
 func (r *SessionRepositoryImpl) DeleteSession(ctx context.Context, token string) error {
+	ctx, span := tracing.Start(ctx, "SessionRepository.DeleteSession")
+	defer span.End()
+
 	query := `DELETE FROM sessions WHERE token = $1`
 
-	result, err := r.db.ExecContext(ctx, query, token)
+	result, err := r.db.Exec(ctx, query, token)
 	if err != nil {
 		return fmt.Errorf("failed to delete session: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
-
-	if rowsAffected == 0 {
+	if result.RowsAffected() == 0 {
 		return fmt.Errorf("session not found")
 	}
 
@@ -92,9 +98,12 @@ func (r *SessionRepositoryImpl) DeleteSession(ctx context.Context, token string)
 
 // DeleteExpiredSessions は期限切れのセッションを削除します
 func (r *SessionRepositoryImpl) DeleteExpiredSessions(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "SessionRepository.DeleteExpiredSessions")
+	defer span.End()
+
 	query := `DELETE FROM sessions WHERE expires_at < NOW()`
 
-	_, err := r.db.ExecContext(ctx, query)
+	_, err := r.db.Exec(ctx, query)
 	if err != nil {
 		return fmt.Errorf("failed to delete expired sessions: %w", err)
 	}
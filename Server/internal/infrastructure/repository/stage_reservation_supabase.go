@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	appdomain "server/internal/domain/battlestage"
+	"server/internal/infrastructure/database"
+	"server/internal/tracing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// exclusionViolation は排他制約違反の SQLSTATE です
+const exclusionViolation = "23P01"
+
+// StageReservationSupabaseRepository は PostgreSQL (Supabase) を利用したステージ予約リポジトリです。
+type StageReservationSupabaseRepository struct {
+	db *database.DB
+}
+
+// NewStageReservationSupabaseRepository は共有の接続プールを用いたリポジトリを生成します。
+func NewStageReservationSupabaseRepository(db *database.DB) *StageReservationSupabaseRepository {
+	return &StageReservationSupabaseRepository{db: db}
+}
+
+// CreateReservation は予約を作成します。
+// 枠の重複は stage_reservations の排他制約で検出し、ErrReservationConflict に変換します。
+func (r *StageReservationSupabaseRepository) CreateReservation(ctx context.Context, reservation *appdomain.Reservation) error {
+	ctx, span := tracing.Start(ctx, "StageReservationRepository.CreateReservation")
+	defer span.End()
+
+	if !r.db.Ready() {
+		return database.ErrNotConfigured
+	}
+
+	const query = `
+INSERT INTO public.stage_reservations (id, battle_stage_id, user_id, starts_at, ends_at, created_at)
+VALUES ($1, $2::uuid, $3, $4, $5, $6)
+`
+
+	_, err := r.db.Exec(ctx, query,
+		reservation.ID,
+		reservation.StageID,
+		reservation.UserID,
+		reservation.StartsAt,
+		reservation.EndsAt,
+		reservation.CreatedAt,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == exclusionViolation {
+			return appdomain.ErrReservationConflict
+		}
+		return fmt.Errorf("insert stage reservation: %w", err)
+	}
+
+	return nil
+}
+
+// ListReservations は指定ステージの [from, to) と重なる予約を開始時刻順に返します。
+func (r *StageReservationSupabaseRepository) ListReservations(ctx context.Context, stageIDs []string, from, to time.Time) ([]appdomain.Reservation, error) {
+	ctx, span := tracing.Start(ctx, "StageReservationRepository.ListReservations")
+	defer span.End()
+
+	if !r.db.Ready() {
+		return nil, database.ErrNotConfigured
+	}
+
+	const query = `
+SELECT id, battle_stage_id::text, user_id, starts_at, ends_at, created_at
+FROM public.stage_reservations
+WHERE battle_stage_id::text = ANY($1)
+  AND starts_at < $3
+  AND ends_at > $2
+ORDER BY starts_at ASC
+`
+
+	rows, err := r.db.Query(ctx, query, stageIDs, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("query stage reservations: %w", err)
+	}
+	defer rows.Close()
+
+	reservations := make([]appdomain.Reservation, 0)
+	for rows.Next() {
+		var reservation appdomain.Reservation
+		if err := rows.Scan(
+			&reservation.ID,
+			&reservation.StageID,
+			&reservation.UserID,
+			&reservation.StartsAt,
+			&reservation.EndsAt,
+			&reservation.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan stage reservation: %w", err)
+		}
+		reservations = append(reservations, reservation)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate stage reservations: %w", err)
+	}
+
+	return reservations, nil
+}
+
+// DeleteReservation は userID が所有する予約を削除します。
+func (r *StageReservationSupabaseRepository) DeleteReservation(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "StageReservationRepository.DeleteReservation")
+	defer span.End()
+
+	if !r.db.Ready() {
+		return database.ErrNotConfigured
+	}
+
+	const query = `
+DELETE FROM public.stage_reservations
+WHERE id = $1 AND user_id = $2
+`
+
+	result, err := r.db.Exec(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("delete stage reservation: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return appdomain.ErrReservationNotFound
+	}
+
+	return nil
+}
@@ -2,32 +2,42 @@ package repository
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 	"fmt"
 
 	"server/internal/domain/entities"
+	"server/internal/infrastructure/database"
+	"server/internal/tracing"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
+// uniqueViolation は一意制約違反の SQLSTATE です
+const uniqueViolation = "23505"
+
 // UserRepositoryImpl はユーザーリポジトリの実装です
 type UserRepositoryImpl struct {
-	db *sql.DB
+	db *database.DB
 }
 
 // NewUserRepository は新しいユーザーリポジトリを作成します
-func NewUserRepository(db *sql.DB) *UserRepositoryImpl {
+func NewUserRepository(db *database.DB) *UserRepositoryImpl {
 	return &UserRepositoryImpl{db: db}
 }
 
-// CreateUser は新しいユーザーを作成します
+// CreateUser は新しいユーザーを作成します。
+// メールアドレスの重複は users.email の一意制約で検出し、ErrUserExists に変換します。
 func (r *UserRepositoryImpl) CreateUser(ctx context.Context, user *entities.User) error {
+	ctx, span := tracing.Start(ctx, "UserRepository.CreateUser")
+	defer span.End()
+
 	query := `
 		INSERT INTO users (id, email, password_hash, full_name, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6)
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := r.db.Exec(ctx, query,
 		user.ID,
 		user.Email,
 		user.PasswordHash,
@@ -37,6 +47,10 @@ func (r *UserRepositoryImpl) CreateUser(ctx context.Context, user *entities.User
 	)
 
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return entities.ErrUserExists
+		}
 		return fmt.Errorf("failed to create user: %w", err)
 	}
 
@@ -45,6 +59,9 @@ func (r *UserRepositoryImpl) CreateUser(ctx context.Context, user *entities.User
 
 // GetUserByEmail はメールアドレスでユーザーを取得します
 func (r *UserRepositoryImpl) GetUserByEmail(ctx context.Context, email string) (*entities.User, error) {
+	ctx, span := tracing.Start(ctx, "UserRepository.GetUserByEmail")
+	defer span.End()
+
 	query := `
 		SELECT id, email, password_hash, full_name, created_at, updated_at
 		FROM users
@@ -52,7 +69,7 @@ func (r *UserRepositoryImpl) GetUserByEmail(ctx context.Context, email string) (
 	`
 
 	var user entities.User
-	err := r.db.QueryRowContext(ctx, query, email).Scan(
+	err := r.db.QueryRow(ctx, query, email).Scan(
 		&user.ID,
 		&user.Email,
 		&user.PasswordHash,
@@ -62,7 +79,7 @@ func (r *UserRepositoryImpl) GetUserByEmail(ctx context.Context, email string) (
 	)
 
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, database.ErrNoRows) {
 			return nil, fmt.Errorf("user not found")
 		}
 		return nil, fmt.Errorf("failed to get user by email: %w", err)
@@ -73,6 +90,9 @@ func (r *UserRepositoryImpl) GetUserByEmail(ctx context.Context, email string) (
 
 // GetUserByID はIDでユーザーを取得します
 func (r *UserRepositoryImpl) GetUserByID(ctx context.Context, id uuid.UUID) (*entities.User, error) {
+	ctx, span := tracing.Start(ctx, "UserRepository.GetUserByID")
+	defer span.End()
+
 	query := `
 		SELECT id, email, password_hash, full_name, created_at, updated_at
 		FROM users
@@ -80,7 +100,7 @@ func (r *UserRepositoryImpl) GetUserByID(ctx context.Context, id uuid.UUID) (*en
 	`
 
 	var user entities.User
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := r.db.QueryRow(ctx, query, id).Scan(
 		&user.ID,
 		&user.Email,
 		&user.PasswordHash,
@@ -90,7 +110,7 @@ func (r *UserRepositoryImpl) GetUserByID(ctx context.Context, id uuid.UUID) (*en
 	)
 
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, database.ErrNoRows) {
 			return nil, fmt.Errorf("user not found")
 		}
 		return nil, fmt.Errorf("failed to get user by id: %w", err)
@@ -101,13 +121,16 @@ func (r *UserRepositoryImpl) GetUserByID(ctx context.Context, id uuid.UUID) (*en
 
 // UpdateUser はユーザー情報を更新します
 func (r *UserRepositoryImpl) UpdateUser(ctx context.Context, user *entities.User) error {
+	ctx, span := tracing.Start(ctx, "UserRepository.UpdateUser")
+	defer span.End()
+
 	query := `
 		UPDATE users
 		SET email = $2, full_name = $3, updated_at = $4
 		WHERE id = $1
 	`
 
-	result, err := r.db.ExecContext(ctx, query,
+	result, err := r.db.Exec(ctx, query,
 		user.ID,
 		user.Email,
 		user.FullName,
@@ -118,12 +141,7 @@ func (r *UserRepositoryImpl) UpdateUser(ctx context.Context, user *entities.User
 		return fmt.Errorf("failed to update user: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
-
-	if rowsAffected == 0 {
+	if result.RowsAffected() == 0 {
 		return fmt.Errorf("user not found")
 	}
 
@@ -0,0 +1,112 @@
+// Package logging は slog による構造化ログと、リクエストごとの context ロガーを提供します。
+//
+// JSON 出力は Cloud Logging のフィールド名（severity, message, logging.googleapis.com/trace）に合わせています。
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// 出力形式
+const (
+	FormatJSON = "json" // Cloud Run 向け（既定）
+	FormatText = "text" // ローカル開発向け
+)
+
+// Cloud Logging がトレースと紐付けるフィールド名
+const (
+	traceKey        = "logging.googleapis.com/trace"
+	spanKey         = "logging.googleapis.com/spanId"
+	traceSampledKey = "logging.googleapis.com/trace_sampled"
+)
+
+// Options はロガーの設定です
+type Options struct {
+	Level     string // debug, info, warn, error
+	Format    string // json または text
+	ProjectID string // トレースを Cloud Trace と紐付ける GCP プロジェクト（空なら紐付けない）
+}
+
+// ParseLevel はログレベルの文字列を slog.Level に変換します
+func ParseLevel(level string) (slog.Level, error) {
+	var parsed slog.Level
+	if err := parsed.UnmarshalText([]byte(strings.TrimSpace(level))); err != nil {
+		return slog.LevelInfo, fmt.Errorf("invalid log level %q", level)
+	}
+	return parsed, nil
+}
+
+// New は w に出力するロガーを作成します
+func New(w io.Writer, opts Options) *slog.Logger {
+	level, err := ParseLevel(opts.Level)
+	if err != nil {
+		level = slog.LevelInfo
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: level}
+	if opts.Format == FormatText {
+		return slog.New(slog.NewTextHandler(w, handlerOpts))
+	}
+
+	handlerOpts.ReplaceAttr = cloudLoggingAttr
+	return slog.New(slog.NewJSONHandler(w, handlerOpts))
+}
+
+// cloudLoggingAttr はトップレベルのキーを Cloud Logging の構造化ログの形式に変換します
+func cloudLoggingAttr(groups []string, attr slog.Attr) slog.Attr {
+	if len(groups) > 0 {
+		return attr
+	}
+
+	switch attr.Key {
+	case slog.LevelKey:
+		level, _ := attr.Value.Any().(slog.Level)
+		return slog.String("severity", severity(level))
+	case slog.MessageKey:
+		attr.Key = "message"
+	}
+	return attr
+}
+
+func severity(level slog.Level) string {
+	switch {
+	case level >= slog.LevelError:
+		return "ERROR"
+	case level >= slog.LevelWarn:
+		return "WARNING"
+	case level >= slog.LevelInfo:
+		return "INFO"
+	default:
+		return "DEBUG"
+	}
+}
+
+type loggerKey struct{}
+
+// WithLogger は context にロガーを設定します
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext は context のロガーを返します。未設定の場合は slog.Default() です。
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+			return logger
+		}
+	}
+	return slog.Default()
+}
+
+// With は context のロガーに属性を追加します。
+// Middleware の中で呼ばれた場合、属性はリクエストのアクセスログにも出力されます。
+func With(ctx context.Context, args ...any) context.Context {
+	if state, ok := ctx.Value(requestStateKey{}).(*requestState); ok {
+		state.add(args...)
+	}
+	return WithLogger(ctx, FromContext(ctx).With(args...))
+}
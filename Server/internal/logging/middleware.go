@@ -0,0 +1,195 @@
+package logging
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"server/internal/requestid"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceparent は W3C Trace Context のヘッダー形式です（version-traceid-spanid-flags）
+var traceparent = regexp.MustCompile(`^[0-9a-f]{2}-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})$`)
+
+// cloudTraceContext は Cloud Run が付与する X-Cloud-Trace-Context の形式です（TRACE_ID/SPAN_ID;o=1）
+var cloudTraceContext = regexp.MustCompile(`^([0-9a-fA-F]{32})(?:/(\d+))?(?:;o=([01]))?$`)
+
+type requestStateKey struct{}
+
+// requestState はハンドラーの中で追加された属性をアクセスログへ渡します
+type requestState struct {
+	mu    sync.Mutex
+	attrs []any
+}
+
+func (s *requestState) add(args ...any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs = append(s.attrs, args...)
+}
+
+func (s *requestState) snapshot() []any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]any(nil), s.attrs...)
+}
+
+// Middleware はリクエスト ID とトレースを付与したロガーを context に設定し、
+// 完了時にステータス・バイト数・ユーザー ID・レイテンシを 1 行のアクセスログとして出力します。
+// requestid.Middleware の内側で使います。
+func Middleware(logger *slog.Logger, projectID string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		attrs := []any{slog.String("request_id", requestid.FromContext(r.Context()))}
+		attrs = append(attrs, traceAttrs(r.Context(), r.Header, projectID)...)
+		requestLogger := logger.With(attrs...)
+
+		state := &requestState{}
+		ctx := context.WithValue(r.Context(), requestStateKey{}, state)
+		ctx = WithLogger(ctx, requestLogger)
+
+		recorder := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		status := recorder.statusCode()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		fields := []any{
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", status),
+			slog.Int64("bytes", recorder.bytes),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("remote_ip", clientIP(r)),
+			slog.String("user_agent", r.UserAgent()),
+		}
+		fields = append(fields, state.snapshot()...)
+		requestLogger.Log(ctx, level, "request completed", fields...)
+	})
+}
+
+// traceAttrs はトレースの属性を作ります。
+// context にスパンがあればそれを使い、なければ traceparent または X-Cloud-Trace-Context を読みます。
+func traceAttrs(ctx context.Context, header http.Header, projectID string) []any {
+	var traceID, spanID string
+	var sampled bool
+
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		traceID, spanID = spanContext.TraceID().String(), spanContext.SpanID().String()
+		sampled = spanContext.IsSampled()
+	} else if match := traceparent.FindStringSubmatch(header.Get("traceparent")); match != nil {
+		traceID, spanID = match[1], match[2]
+		sampled = match[3] == "01"
+	} else if match := cloudTraceContext.FindStringSubmatch(header.Get("X-Cloud-Trace-Context")); match != nil {
+		traceID = strings.ToLower(match[1])
+		if match[2] != "" {
+			// Cloud Logging の spanId は 16 桁の 16 進数
+			var id uint64
+			fmt.Sscan(match[2], &id)
+			spanID = fmt.Sprintf("%016x", id)
+		}
+		sampled = match[3] == "1"
+	}
+
+	if traceID == "" {
+		return nil
+	}
+
+	traceName := traceID
+	if projectID != "" {
+		traceName = "projects/" + projectID + "/traces/" + traceID
+	}
+	attrs := []any{slog.String(traceKey, traceName), slog.Bool(traceSampledKey, sampled)}
+	if spanID != "" {
+		attrs = append(attrs, slog.String(spanKey, spanID))
+	}
+	return attrs
+}
+
+func clientIP(r *http.Request) string {
+	// Cloud Run ではロードバランサーが X-Forwarded-For の先頭にクライアントを設定する
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		first, _, _ := strings.Cut(forwarded, ",")
+		return strings.TrimSpace(first)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// responseRecorder はステータスコードと書き込んだバイト数を記録します
+type responseRecorder struct {
+	http.ResponseWriter
+	status   int
+	bytes    int64
+	hijacked bool
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Hijack は WebSocket のアップグレードのために元の接続を引き渡します
+func (r *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil {
+		r.hijacked = true
+	}
+	return conn, rw, err
+}
+
+func (r *responseRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap は http.ResponseController から元の ResponseWriter を参照できるようにします
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (r *responseRecorder) statusCode() int {
+	switch {
+	case r.hijacked:
+		return http.StatusSwitchingProtocols
+	case r.status == 0:
+		return http.StatusOK
+	default:
+		return r.status
+	}
+}
@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"server/internal/requestid"
+)
+
+func TestMiddleware_LogsRequestFields(t *testing.T) {
+	var out bytes.Buffer
+	logger := New(&out, Options{Level: "info", Format: FormatJSON, ProjectID: "demo"})
+
+	handler := requestid.Middleware(Middleware(logger, "demo", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 認証ミドルウェア相当: 内側で追加した属性がアクセスログに出る
+		With(r.Context(), "user_id", "user-1")
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("hello"))
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/game", nil)
+	req.Header.Set(requestid.Header, "req-42")
+	req.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b12000100000/1;o=1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var entry map[string]any
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("decode log %q: %v", out.String(), err)
+	}
+
+	want := map[string]any{
+		"severity":                      "WARNING",
+		"message":                       "request completed",
+		"request_id":                    "req-42",
+		"user_id":                       "user-1",
+		"status":                        float64(http.StatusTeapot),
+		"bytes":                         float64(5),
+		"path":                          "/game",
+		"logging.googleapis.com/trace":  "projects/demo/traces/105445aa7843bc8bf206b12000100000",
+		"logging.googleapis.com/spanId": "0000000000000001",
+	}
+	for key, value := range want {
+		if entry[key] != value {
+			t.Errorf("%s = %v, want %v", key, entry[key], value)
+		}
+	}
+	if _, ok := entry["latency_ms"]; !ok {
+		t.Errorf("latency_ms is missing")
+	}
+}
+
+func TestMiddleware_SupportsHijack(t *testing.T) {
+	logger := New(&bytes.Buffer{}, Options{Level: "info"})
+
+	var hijackable bool
+	handler := Middleware(logger, "", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, hijackable = w.(http.Hijacker)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ws/battle", nil))
+
+	if !hijackable {
+		t.Fatal("wrapped ResponseWriter must implement http.Hijacker for WebSocket upgrades")
+	}
+}
@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PoolStatter は接続プールの統計を返すインターフェースです（database.DB が実装）
+type PoolStatter interface {
+	Stats() *pgxpool.Stat
+}
+
+// RegisterDBPool は接続プールを name のラベルで公開します。同じ name で再登録すると置き換えます。
+func RegisterDBPool(name string, pool PoolStatter) {
+	dbPools.mu.Lock()
+	defer dbPools.mu.Unlock()
+
+	dbPools.pools[name] = pool
+}
+
+var dbPools = &dbPoolCollector{pools: make(map[string]PoolStatter)}
+
+func init() {
+	Registry.MustRegister(dbPools)
+}
+
+func dbPoolDesc(name, help string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, []string{"pool"}, nil)
+}
+
+var (
+	dbAcquiredConns     = dbPoolDesc("acquired_connections", "Connections currently in use.")
+	dbIdleConns         = dbPoolDesc("idle_connections", "Idle connections in the pool.")
+	dbTotalConns        = dbPoolDesc("total_connections", "Total connections in the pool.")
+	dbMaxConns          = dbPoolDesc("max_connections", "Maximum size of the pool.")
+	dbAcquires          = dbPoolDesc("acquires_total", "Successful connection acquisitions.")
+	dbAcquireDuration   = dbPoolDesc("acquire_duration_seconds_total", "Total time spent acquiring connections.")
+	dbEmptyAcquires     = dbPoolDesc("empty_acquires_total", "Acquisitions that had to wait because the pool was empty.")
+	dbCanceledAcquires  = dbPoolDesc("canceled_acquires_total", "Acquisitions canceled by the context.")
+	dbNewConns          = dbPoolDesc("new_connections_total", "Connections opened.")
+	dbLifetimeDestroyed = dbPoolDesc("max_lifetime_destroyed_total", "Connections closed because they reached DB_MAX_CONN_LIFETIME.")
+	dbIdleDestroyed     = dbPoolDesc("max_idle_destroyed_total", "Connections closed because they reached DB_MAX_CONN_IDLE_TIME.")
+)
+
+// dbPoolCollector はスクレイプのたびに登録済みのプールから統計を読み取ります
+type dbPoolCollector struct {
+	mu    sync.Mutex
+	pools map[string]PoolStatter
+}
+
+func (c *dbPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		dbAcquiredConns, dbIdleConns, dbTotalConns, dbMaxConns,
+		dbAcquires, dbAcquireDuration, dbEmptyAcquires, dbCanceledAcquires,
+		dbNewConns, dbLifetimeDestroyed, dbIdleDestroyed,
+	} {
+		ch <- desc
+	}
+}
+
+func (c *dbPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for name, pool := range c.pools {
+		stat := pool.Stats()
+		if stat == nil {
+			continue
+		}
+
+		gauge := func(desc *prometheus.Desc, value float64) {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, name)
+		}
+		counter := func(desc *prometheus.Desc, value float64) {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, value, name)
+		}
+
+		gauge(dbAcquiredConns, float64(stat.AcquiredConns()))
+		gauge(dbIdleConns, float64(stat.IdleConns()))
+		gauge(dbTotalConns, float64(stat.TotalConns()))
+		gauge(dbMaxConns, float64(stat.MaxConns()))
+		counter(dbAcquires, float64(stat.AcquireCount()))
+		counter(dbAcquireDuration, stat.AcquireDuration().Seconds())
+		counter(dbEmptyAcquires, float64(stat.EmptyAcquireCount()))
+		counter(dbCanceledAcquires, float64(stat.CanceledAcquireCount()))
+		counter(dbNewConns, float64(stat.NewConnsCount()))
+		counter(dbLifetimeDestroyed, float64(stat.MaxLifetimeDestroyCount()))
+		counter(dbIdleDestroyed, float64(stat.MaxIdleDestroyCount()))
+	}
+}
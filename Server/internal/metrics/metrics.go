@@ -0,0 +1,121 @@
+// Package metrics は Prometheus 形式のメトリクスを提供します。
+//
+// コレクターはパッケージ専用の Registry に登録され、Handler で公開します。
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "os2516"
+
+// Registry はアプリケーションのメトリクスを保持します
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route, method and status.",
+	}, []string{"route", "method", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route, method and status.",
+		Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+	}, []string{"route", "method", "status"})
+
+	websocketConnections = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "websocket_connections",
+		Help:      "Active WebSocket connections by endpoint.",
+	}, []string{"endpoint"})
+
+	signIns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_signins_total",
+		Help:      "Sign-in attempts by result (success or failure).",
+	}, []string{"result"})
+
+	battlesCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "battle_sessions_created_total",
+		Help:      "Battle sessions created.",
+	})
+
+	battleEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "battle_events_total",
+		Help:      "Battle events emitted by type (session_started, geofence_penalty, forfeit, ...).",
+	}, []string{"type"})
+
+	rateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_total",
+		Help:      "Requests rejected with 429 by rate limit policy.",
+	}, []string{"policy"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		websocketConnections,
+		signIns,
+		battlesCreated,
+		battleEvents,
+		rateLimited,
+	)
+}
+
+// Handler は Registry の内容を Prometheus のテキスト形式で返すハンドラーです
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// ObserveHTTPRequest は 1 リクエストの結果を記録します
+func ObserveHTTPRequest(route, method string, status int, elapsed time.Duration) {
+	code := strconv.Itoa(status)
+	httpRequests.WithLabelValues(route, method, code).Inc()
+	httpDuration.WithLabelValues(route, method, code).Observe(elapsed.Seconds())
+}
+
+// WebSocketConnected は WebSocket 接続の開始を記録し、切断時に呼ぶ関数を返します
+func WebSocketConnected(endpoint string) (disconnected func()) {
+	gauge := websocketConnections.WithLabelValues(endpoint)
+	gauge.Inc()
+	return gauge.Dec
+}
+
+// SignIn はサインインの成否を記録します
+func SignIn(success bool) {
+	result := "failure"
+	if success {
+		result = "success"
+	}
+	signIns.WithLabelValues(result).Inc()
+}
+
+// BattleCreated は対戦セッションの作成を記録します
+func BattleCreated() {
+	battlesCreated.Inc()
+}
+
+// BattleEvent は対戦イベントの発生を記録します
+func BattleEvent(eventType string) {
+	battleEvents.WithLabelValues(eventType).Inc()
+}
+
+// RateLimited はレート制限で拒否したリクエストを記録します
+func RateLimited(policy string) {
+	rateLimited.WithLabelValues(policy).Inc()
+}
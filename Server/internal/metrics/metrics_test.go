@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMiddleware_LabelsByRoutePattern(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /game", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	handler := Middleware(mux)
+
+	before := testutil.ToFloat64(httpRequests.WithLabelValues("/game", http.MethodGet, "400"))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/game?lat=x", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/no/such/path", nil))
+
+	if got := testutil.ToFloat64(httpRequests.WithLabelValues("/game", http.MethodGet, "400")); got != before+1 {
+		t.Fatalf("/game requests = %v, want %v", got, before+1)
+	}
+	if got := testutil.ToFloat64(httpRequests.WithLabelValues(unmatchedRoute, http.MethodGet, "404")); got < 1 {
+		t.Fatalf("unmatched requests = %v, want >= 1", got)
+	}
+}
+
+func TestRequireToken(t *testing.T) {
+	handler := RequireToken("secret", Handler())
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("without token: status = %d, want 401", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "os2516_http_requests_total") {
+		t.Fatalf("with token: status = %d, body missing metrics", rec.Code)
+	}
+}
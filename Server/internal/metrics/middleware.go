@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"server/internal/apierror"
+)
+
+// unmatchedRoute はどのパターンにも一致しなかったリクエストのラベルです（パスをそのまま使うとラベルが増え続けるため）
+const unmatchedRoute = "unmatched"
+
+// Middleware はリクエスト数とレイテンシを記録します。
+// ルートのラベルには ServeMux が設定する r.Pattern を使うため、ServeMux の直前に置きます。
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(recorder, r)
+
+		route := routeLabel(r.Pattern)
+		if recorder.hijacked {
+			// WebSocket は接続が切れるまで戻らないため、レイテンシには含めない
+			httpRequests.WithLabelValues(route, r.Method, strconv.Itoa(http.StatusSwitchingProtocols)).Inc()
+			return
+		}
+		ObserveHTTPRequest(route, r.Method, recorder.statusCode(), time.Since(start))
+	})
+}
+
+// RequireToken は Authorization: Bearer <token> が一致する場合のみ next を呼びます
+func RequireToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			apierror.Write(w, r, apierror.New(apierror.CodeUnauthorized, "Metrics token required"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// statusRecorder はステータスコードを記録します
+type statusRecorder struct {
+	http.ResponseWriter
+	status   int
+	hijacked bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Hijack は WebSocket のアップグレードのために元の接続を引き渡します
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil {
+		r.hijacked = true
+	}
+	return conn, rw, err
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap は http.ResponseController から元の ResponseWriter を参照できるようにします
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (r *statusRecorder) statusCode() int {
+	switch {
+	case r.hijacked:
+		return http.StatusSwitchingProtocols
+	case r.status == 0:
+		return http.StatusOK
+	default:
+		return r.status
+	}
+}
+
+// routeLabel はパターンからメソッドを除いたパスを返します（メソッドは別のラベルに記録するため）
+func routeLabel(pattern string) string {
+	if pattern == "" {
+		return unmatchedRoute
+	}
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		return path
+	}
+	return pattern
+}
@@ -0,0 +1,62 @@
+// Package openapi はサーバーの OpenAPI 3 ドキュメント（openapi.yaml）を読み込み、JSON で配信します。
+// ルートやレスポンスを変更したら openapi.yaml も更新してください。internal/api の契約テストが差分を検出します。
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"server/internal/apierror"
+)
+
+//go:embed openapi.yaml
+var specYAML []byte
+
+var (
+	loadOnce sync.Once
+	loaded   *openapi3.T
+	loadErr  error
+)
+
+// Load は埋め込まれたドキュメントを読み込み、仕様として正しいかを検証して返します
+func Load() (*openapi3.T, error) {
+	loadOnce.Do(func() {
+		loader := openapi3.NewLoader()
+		doc, err := loader.LoadFromData(specYAML)
+		if err != nil {
+			loadErr = fmt.Errorf("load openapi spec: %w", err)
+			return
+		}
+		if err := doc.Validate(context.Background()); err != nil {
+			loadErr = fmt.Errorf("validate openapi spec: %w", err)
+			return
+		}
+		loaded = doc
+	})
+	return loaded, loadErr
+}
+
+// Handler はドキュメントを JSON で返します
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		doc, err := Load()
+		if err != nil {
+			apierror.Write(w, r, apierror.Internal(err))
+			return
+		}
+		body, err := json.Marshal(doc)
+		if err != nil {
+			apierror.Write(w, r, apierror.Internal(err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	})
+}
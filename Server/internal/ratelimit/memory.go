@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// MemoryStore はプロセス内にバケットを保持する Store です（インスタンスごとに独立して制限します）
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// NewMemoryStore は MemoryStore を作成します
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*bucket{}, now: time.Now}
+}
+
+// Take はトークンを補充してから 1 つ消費します
+func (s *MemoryStore) Take(_ context.Context, key string, limit int, period time.Duration) (float64, bool, error) {
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit), updatedAt: now}
+		s.buckets[key] = b
+	}
+	if elapsed := now.Sub(b.updatedAt).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(limit), b.tokens+elapsed*float64(limit)/period.Seconds())
+	}
+	b.updatedAt = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return b.tokens, allowed, nil
+}
+
+// Sweep は before より前から使われていないバケットを削除します
+func (s *MemoryStore) Sweep(_ context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, b := range s.buckets {
+		if b.updatedAt.Before(before) {
+			delete(s.buckets, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+// Package ratelimit はトークンバケット方式のレート制限ミドルウェアです。
+//
+// ルートごとに Policy を指定し、認証済みのリクエストはユーザー ID、それ以外はクライアントの IP アドレスごとに制限します。
+// バケットの状態は Store に保存し、単一インスタンスでは MemoryStore、複数インスタンスでは PostgreSQL で共有します。
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"server/internal/apierror"
+	"server/internal/auth"
+	"server/internal/logging"
+	"server/internal/metrics"
+)
+
+// Policy は 1 つの制限です。Period ごとに Limit 回まで（バケットの容量も Limit）リクエストを受け付けます。
+type Policy struct {
+	Name   string // メトリクスとバケットのキーに使う名前（例: "hpmp_update"）
+	Limit  int
+	Period time.Duration
+}
+
+// rate は 1 秒あたりに補充するトークン数です
+func (p Policy) rate() float64 {
+	return float64(p.Limit) / p.Period.Seconds()
+}
+
+// decision は 1 リクエストの判定結果です
+type decision struct {
+	Allowed   bool
+	Remaining int           // 残りのトークン数
+	Reset     time.Duration // バケットが満杯に戻るまでの時間
+	Retry     time.Duration // 拒否した場合に次のトークンが補充されるまでの時間
+}
+
+// Store はバケットの状態を保持するバックエンドです
+type Store interface {
+	// Take はバケットを補充してからトークンを 1 つ消費できれば消費し、残りのトークン数と消費できたかを返します。
+	// バケットは Limit 個のトークンで始まり、1 秒あたり Limit / Period 個補充されます（上限は Limit）。
+	Take(ctx context.Context, key string, limit int, period time.Duration) (tokens float64, allowed bool, err error)
+	// Sweep は before より前から更新のないバケットを削除します（満杯に戻ったバケットは保持する必要がない）
+	Sweep(ctx context.Context, before time.Time) error
+}
+
+// Limiter は Store を使ってリクエストを制限します
+type Limiter struct {
+	store          Store
+	trustedProxies int
+}
+
+// NewLimiter は Limiter を作成します。
+// trustedProxies はサーバーの手前にある信頼できるプロキシの段数で、0 の場合は X-Forwarded-For を使わずに接続元のアドレスで制限します。
+func NewLimiter(store Store, trustedProxies int) *Limiter {
+	return &Limiter{store: store, trustedProxies: trustedProxies}
+}
+
+// Middleware は policy でリクエストを制限するミドルウェアを返します。
+// ユーザー ID で制限するため、認証が必要なルートでは RequireAuth の内側に置きます。
+func (l *Limiter) Middleware(policy Policy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokens, allowed, err := l.store.Take(r.Context(), l.key(policy, r), policy.Limit, policy.Period)
+			if err != nil {
+				// バックエンドの障害で API 全体を止めないよう、制限せずに通す
+				logging.FromContext(r.Context()).Warn("rate limit check failed", "policy", policy.Name, "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			result := decide(policy, tokens, allowed)
+			setHeaders(w.Header(), policy, result)
+			if !result.Allowed {
+				metrics.RateLimited(policy.Name)
+				w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(result.Retry)))
+				apierror.Write(w, r, apierror.New(apierror.CodeRateLimited, "too many requests, retry later"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Run は interval ごとに使われなくなったバケットを削除します。ctx がキャンセルされると戻ります。
+func (l *Limiter) Run(ctx context.Context, interval, idle time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := l.store.Sweep(ctx, now.Add(-idle)); err != nil {
+				logging.FromContext(ctx).Warn("failed to sweep rate limit buckets", "error", err)
+			}
+		}
+	}
+}
+
+// setHeaders は IETF の RateLimit ヘッダー（draft-ietf-httpapi-ratelimit-headers）を設定します
+func setHeaders(header http.Header, policy Policy, result decision) {
+	header.Set("RateLimit-Limit", strconv.Itoa(policy.Limit))
+	header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+	header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limit, ceilSeconds(policy.Period)))
+}
+
+// key はバケットのキーです。認証済みならユーザー ID、それ以外はクライアントの IP アドレスを使います。
+func (l *Limiter) key(policy Policy, r *http.Request) string {
+	if userID, ok := auth.GetUserIDFromContext(r.Context()); ok {
+		return policy.Name + ":user:" + userID.String()
+	}
+	return policy.Name + ":ip:" + ClientIP(r, l.trustedProxies)
+}
+
+// ClientIP はリクエスト元の IP アドレスを返します。
+// プロキシは X-Forwarded-For の末尾に接続元を追加するため、trustedProxies 段のプロキシの手前にいるクライアントは末尾から trustedProxies 番目の値です。
+// それより前の値はクライアントが偽装できるため使いません。trustedProxies が 0 の場合や値が足りない場合は接続元のアドレスを返します。
+func ClientIP(r *http.Request, trustedProxies int) string {
+	if trustedProxies > 0 {
+		var forwarded []string
+		for _, header := range r.Header.Values("X-Forwarded-For") {
+			forwarded = append(forwarded, strings.Split(header, ",")...)
+		}
+		if len(forwarded) >= trustedProxies {
+			if ip := strings.TrimSpace(forwarded[len(forwarded)-trustedProxies]); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// decide はトークン数（補充済み）から判定結果を作ります
+func decide(policy Policy, tokens float64, allowed bool) decision {
+	rate := policy.rate()
+	result := decision{
+		Allowed:   allowed,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((float64(policy.Limit) - tokens) / rate),
+	}
+	if !allowed {
+		result.Retry = seconds((1 - tokens) / rate)
+	}
+	return result
+}
+
+func seconds(s float64) time.Duration {
+	if s <= 0 {
+		return 0
+	}
+	return time.Duration(s * float64(time.Second))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,107 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"server/internal/auth"
+)
+
+func TestMemoryStore_RefillsOverTime(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, allowed, _ := store.Take(ctx, "k", 3, 3*time.Second); !allowed {
+			t.Fatalf("take %d: denied within the burst", i)
+		}
+	}
+	if _, allowed, _ := store.Take(ctx, "k", 3, 3*time.Second); allowed {
+		t.Fatal("take over the burst was allowed")
+	}
+
+	// 1 秒で 1 トークン補充される
+	now = now.Add(time.Second)
+	if tokens, allowed, _ := store.Take(ctx, "k", 3, 3*time.Second); !allowed || tokens != 0 {
+		t.Fatalf("after refill: tokens = %v, allowed = %v", tokens, allowed)
+	}
+
+	// 長時間使わなくても容量を超えて貯まらない
+	now = now.Add(time.Hour)
+	if tokens, _, _ := store.Take(ctx, "k", 3, 3*time.Second); tokens != 2 {
+		t.Fatalf("after idle: tokens = %v, want 2", tokens)
+	}
+
+	if err := store.Sweep(ctx, now.Add(time.Second)); err != nil || len(store.buckets) != 0 {
+		t.Fatalf("sweep left %d buckets (err: %v)", len(store.buckets), err)
+	}
+}
+
+func TestMiddleware_LimitsByUserOrIP(t *testing.T) {
+	limiter := NewLimiter(NewMemoryStore(), 0)
+	handler := limiter.Middleware(Policy{Name: "test", Limit: 2, Period: time.Minute})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func(ip string, userID *uuid.UUID) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/hp/update", nil)
+		req.RemoteAddr = ip + ":12345"
+		if userID != nil {
+			req = req.WithContext(context.WithValue(req.Context(), auth.UserIDKey, *userID))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	user := uuid.New()
+	for i := 0; i < 2; i++ {
+		if rec := send("192.0.2.1", &user); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d", i, rec.Code)
+		}
+	}
+
+	rec := send("192.0.2.99", &user)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("same user from another IP: status = %d, want 429", rec.Code)
+	}
+	if rec.Header().Get("RateLimit-Limit") != "2" || rec.Header().Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("headers = %v", rec.Header())
+	}
+	if rec.Header().Get("Retry-After") != "30" || rec.Header().Get("RateLimit-Policy") != "2;w=60" {
+		t.Fatalf("Retry-After = %q, RateLimit-Policy = %q", rec.Header().Get("Retry-After"), rec.Header().Get("RateLimit-Policy"))
+	}
+
+	// 未認証のリクエストは IP アドレスごとに数える
+	if rec := send("192.0.2.1", nil); rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Remaining") != "1" {
+		t.Fatalf("anonymous: status = %d, remaining = %q", rec.Code, rec.Header().Get("RateLimit-Remaining"))
+	}
+}
+
+func TestClientIP_TrustsOnlyConfiguredProxies(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/game", nil)
+	req.RemoteAddr = "10.0.0.1:12345"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7, 198.51.100.2")
+
+	testCases := []struct {
+		trustedProxies int
+		want           string
+	}{
+		{0, "10.0.0.1"},     // プロキシを信頼しない場合はヘッダーを無視する
+		{1, "198.51.100.2"}, // プロキシが末尾に追加した値
+		{2, "203.0.113.7"},
+		{3, "10.0.0.1"}, // 値が足りない場合は接続元
+	}
+	for _, tc := range testCases {
+		if got := ClientIP(req, tc.trustedProxies); got != tc.want {
+			t.Errorf("ClientIP(trustedProxies=%d) = %q, want %q", tc.trustedProxies, got, tc.want)
+		}
+	}
+}
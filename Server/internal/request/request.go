@@ -0,0 +1,149 @@
+// Package request は JSON リクエストボディの読み込みと検証を共通化します。
+//
+// ペイロードの構造体は validate タグで検証ルールを宣言します（例: `validate:"required,min=0,max=1000"`）。
+// 0 や空文字も有効な値として受け付けつつ未指定を区別したいフィールドはポインターにします。
+package request
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"server/internal/apierror"
+)
+
+// DefaultMaxBytes はリクエストボディの既定の上限です
+const DefaultMaxBytes int64 = 64 << 10
+
+// Normalizer は検証の前に値を整えるペイロードが実装します（前後の空白の除去など）
+type Normalizer interface {
+	Normalize()
+}
+
+type options struct {
+	maxBytes       int64
+	strict         bool
+	validationCode apierror.Code
+}
+
+// Option は Decode の動作を変更します
+type Option func(*options)
+
+// MaxBytes はボディの上限を変更します
+func MaxBytes(n int64) Option {
+	return func(o *options) { o.maxBytes = n }
+}
+
+// DisallowUnknownFields は構造体にないフィールドを含むボディを拒否します
+func DisallowUnknownFields() Option {
+	return func(o *options) { o.strict = true }
+}
+
+// ValidationCode は検証ルール違反のエラーコードを変更します（既定は validation_failed）
+func ValidationCode(code apierror.Code) Option {
+	return func(o *options) { o.validationCode = code }
+}
+
+// Decode はボディを dst に読み込み、validate タグのルールで検証します。
+// 失敗した場合は *apierror.Error を返し、検証エラーはフィールドごとに Fields に含めます。
+func Decode(w http.ResponseWriter, r *http.Request, dst any, opts ...Option) error {
+	o := options{maxBytes: DefaultMaxBytes, validationCode: apierror.CodeValidation}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || (mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json")) {
+			return apierror.New(apierror.CodeUnsupportedMedia, "Content-Type must be application/json")
+		}
+	}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, o.maxBytes))
+	if o.strict {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(dst); err != nil {
+		return decodeError(err)
+	}
+	if err := decoder.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return decodeError(err)
+		}
+		return apierror.New(apierror.CodeInvalidBody, "request body must contain a single JSON object")
+	}
+
+	if normalizer, ok := dst.(Normalizer); ok {
+		normalizer.Normalize()
+	}
+	return validateStruct(dst, o.validationCode)
+}
+
+func decodeError(err error) error {
+	var (
+		maxBytesErr *http.MaxBytesError
+		syntaxErr   *json.SyntaxError
+		typeErr     *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return apierror.New(apierror.CodePayloadTooLarge, fmt.Sprintf("request body must not exceed %d bytes", maxBytesErr.Limit))
+	case errors.Is(err, io.EOF):
+		return apierror.New(apierror.CodeInvalidBody, "request body is required")
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return apierror.Wrap(err, apierror.CodeInvalidBody, "request body is not valid JSON")
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		field := fieldError(typeErr.Field, "type", fmt.Sprintf("%s must be %s", typeErr.Field, jsonType(typeErr.Type)))
+		return &apierror.Error{
+			Status:  http.StatusBadRequest,
+			Code:    apierror.CodeInvalidBody,
+			Message: field.Message,
+			Fields:  []apierror.FieldError{field},
+			Err:     err,
+		}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		name := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		field := fieldError(name, "unknown", fmt.Sprintf("%s is not a known field", name))
+		return &apierror.Error{
+			Status:  http.StatusBadRequest,
+			Code:    apierror.CodeInvalidBody,
+			Message: field.Message,
+			Fields:  []apierror.FieldError{field},
+		}
+	default:
+		// time.Time の書式違反など
+		return apierror.Wrap(err, apierror.CodeInvalidBody, "request body contains an invalid value")
+	}
+}
+
+// fieldError は 1 フィールドの検証エラーを作成します
+func fieldError(field, code, message string) apierror.FieldError {
+	return apierror.FieldError{Field: field, Code: code, Message: message}
+}
+
+func jsonType(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
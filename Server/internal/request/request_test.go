@@ -0,0 +1,104 @@
+package request
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"server/internal/apierror"
+)
+
+type updatePayload struct {
+	HP    *int   `json:"hp" validate:"required,min=0,max=1000"`
+	Email string `json:"email" validate:"omitempty,email"`
+}
+
+func decode(t *testing.T, body string, opts ...Option) (*updatePayload, *apierror.Error) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPut, "/api/hp/update", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	var payload updatePayload
+	err := Decode(httptest.NewRecorder(), req, &payload, opts...)
+	if err == nil {
+		return &payload, nil
+	}
+	var apiErr *apierror.Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Decode returned %T, want *apierror.Error", err)
+	}
+	return nil, apiErr
+}
+
+func TestDecode_ZeroIsNotMissing(t *testing.T) {
+	payload, apiErr := decode(t, `{"hp":0}`)
+	if apiErr != nil {
+		t.Fatalf("unexpected error: %v", apiErr)
+	}
+	if *payload.HP != 0 {
+		t.Fatalf("hp = %d, want 0", *payload.HP)
+	}
+
+	_, apiErr = decode(t, `{}`)
+	if apiErr == nil || apiErr.Code != apierror.CodeValidation {
+		t.Fatalf("missing hp: got %v, want validation_failed", apiErr)
+	}
+	if len(apiErr.Fields) != 1 || apiErr.Fields[0].Field != "hp" || apiErr.Fields[0].Code != "required" {
+		t.Fatalf("fields = %+v, want hp/required", apiErr.Fields)
+	}
+}
+
+func TestDecode_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		opts       []Option
+		wantCode   apierror.Code
+		wantStatus int
+		wantField  string
+	}{
+		{"rules", `{"hp":1001,"email":"nope"}`, nil, apierror.CodeValidation, http.StatusBadRequest, "hp"},
+		{"custom code", `{"hp":-1}`, []Option{ValidationCode(apierror.CodeInvalidHP)}, apierror.CodeInvalidHP, http.StatusBadRequest, "hp"},
+		{"wrong type", `{"hp":"full"}`, nil, apierror.CodeInvalidBody, http.StatusBadRequest, "hp"},
+		{"unknown field (strict)", `{"hp":1,"mp":2}`, []Option{DisallowUnknownFields()}, apierror.CodeInvalidBody, http.StatusBadRequest, "mp"},
+		{"malformed", `{"hp":`, nil, apierror.CodeInvalidBody, http.StatusBadRequest, ""},
+		{"empty", ``, nil, apierror.CodeInvalidBody, http.StatusBadRequest, ""},
+		{"trailing data", `{"hp":1}{"hp":2}`, nil, apierror.CodeInvalidBody, http.StatusBadRequest, ""},
+		{"too large", `{"hp":1,"email":"` + strings.Repeat("a", 100) + `"}`, []Option{MaxBytes(32)}, apierror.CodePayloadTooLarge, http.StatusRequestEntityTooLarge, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, apiErr := decode(t, tt.body, tt.opts...)
+			if apiErr == nil {
+				t.Fatal("expected an error")
+			}
+			if apiErr.Code != tt.wantCode || apiErr.Status != tt.wantStatus {
+				t.Fatalf("got %s/%d, want %s/%d", apiErr.Code, apiErr.Status, tt.wantCode, tt.wantStatus)
+			}
+			if tt.wantField != "" && (len(apiErr.Fields) == 0 || apiErr.Fields[0].Field != tt.wantField) {
+				t.Fatalf("fields = %+v, want first field %q", apiErr.Fields, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestDecode_UnknownFieldsAllowedByDefault(t *testing.T) {
+	if _, apiErr := decode(t, `{"hp":1,"mp":2}`); apiErr != nil {
+		t.Fatalf("unexpected error: %v", apiErr)
+	}
+}
+
+func TestDecode_RejectsNonJSONContentType(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPut, "/api/hp/update", strings.NewReader("hp=1"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var payload updatePayload
+	err := Decode(httptest.NewRecorder(), req, &payload)
+	var apiErr *apierror.Error
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusUnsupportedMediaType {
+		t.Fatalf("err = %v, want 415", err)
+	}
+}
@@ -0,0 +1,121 @@
+package request
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+
+	"server/internal/apierror"
+)
+
+var validate = newValidator()
+
+// slugPattern は ID などに使う英小文字・数字・アンダースコア・ハイフンの文字列です
+var slugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	// エラーのフィールド名を JSON の名前にする
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+	v.RegisterValidation("slug", func(field validator.FieldLevel) bool {
+		return slugPattern.MatchString(field.Field().String())
+	})
+	return v
+}
+
+func validateStruct(v any, code apierror.Code) error {
+	err := validate.Struct(v)
+	if err == nil {
+		return nil
+	}
+
+	var invalid *validator.InvalidValidationError
+	if errors.As(err, &invalid) {
+		// 構造体以外を渡したプログラムの誤り
+		return apierror.Internal(err)
+	}
+	var violations validator.ValidationErrors
+	if !errors.As(err, &violations) {
+		return apierror.Internal(err)
+	}
+
+	fields := make([]apierror.FieldError, 0, len(violations))
+	messages := make([]string, 0, len(violations))
+	for _, violation := range violations {
+		field := fieldError(fieldPath(violation), violation.Tag(), fieldMessage(violation))
+		fields = append(fields, field)
+		messages = append(messages, field.Message)
+	}
+	return &apierror.Error{
+		Status:  http.StatusBadRequest,
+		Code:    code,
+		Message: strings.Join(messages, "; "),
+		Fields:  fields,
+	}
+}
+
+// fieldPath は先頭の構造体名を除いた JSON のパスを返します（例: "SignUpRequest.email" → "email"）
+func fieldPath(violation validator.FieldError) string {
+	_, path, found := strings.Cut(violation.Namespace(), ".")
+	if !found {
+		return violation.Field()
+	}
+	return path
+}
+
+func fieldMessage(violation validator.FieldError) string {
+	field := violation.Field()
+	param := violation.Param()
+	isString := violation.Kind() == reflect.String
+
+	switch violation.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", field)
+	case "min", "gte":
+		if isString {
+			return fmt.Sprintf("%s must be at least %s characters", field, param)
+		}
+		return fmt.Sprintf("%s must be at least %s", field, param)
+	case "max", "lte":
+		if isString {
+			return fmt.Sprintf("%s must be at most %s characters", field, param)
+		}
+		return fmt.Sprintf("%s must be at most %s", field, param)
+	case "gt":
+		return fmt.Sprintf("%s must be greater than %s", field, param)
+	case "gtfield":
+		return fmt.Sprintf("%s must be after %s", field, jsonFieldName(param))
+	case "email":
+		return fmt.Sprintf("%s must be a valid email address", field)
+	case "uuid", "uuid4":
+		return fmt.Sprintf("%s must be a UUID", field)
+	case "slug":
+		return fmt.Sprintf("%s must contain only lowercase letters, digits, '_' and '-'", field)
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", field, strings.ReplaceAll(param, " ", ", "))
+	default:
+		return fmt.Sprintf("%s is invalid", field)
+	}
+}
+
+// jsonFieldName は gtfield などのパラメーター（Go のフィールド名）をレスポンス向けの名前にします
+func jsonFieldName(name string) string {
+	if name == "" {
+		return name
+	}
+	return strings.ToLower(name[:1]) + name[1:]
+}
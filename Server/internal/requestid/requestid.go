@@ -0,0 +1,42 @@
+// Package requestid はリクエストごとの ID を X-Request-ID ヘッダーと context で受け渡します。
+package requestid
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+
+	"github.com/google/uuid"
+)
+
+// Header はリクエスト ID を受け渡す HTTP ヘッダーです
+const Header = "X-Request-ID"
+
+type contextKey struct{}
+
+// validID はクライアントから受け取る ID として許可する形式です（ログへの注入を防ぐ）
+var validID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// Middleware は X-Request-ID を受け取るか生成して context とレスポンスヘッダーに設定します
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(Header)
+		if !validID.MatchString(id) {
+			id = uuid.NewString()
+		}
+
+		w.Header().Set(Header, id)
+		next.ServeHTTP(w, r.WithContext(WithID(r.Context(), id)))
+	})
+}
+
+// WithID は context にリクエスト ID を設定します
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext は context からリクエスト ID を取得します（未設定なら空文字）
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
@@ -0,0 +1,41 @@
+package tracing
+
+import (
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// untracedPaths はスパンを作らないパスです（ヘルスチェックとスクレイプでトレースが埋もれるため）
+var untracedPaths = map[string]bool{
+	"/health":  true,
+	"/readyz":  true,
+	"/metrics": true,
+}
+
+// Middleware は受信ヘッダーのトレースコンテキストを引き継いでリクエストごとにスパンを作成します。
+// スパン名には mux のルーティングパターンを使います（例: "GET /game"）。
+func Middleware(mux *http.ServeMux, next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "http.server",
+		otelhttp.WithFilter(func(r *http.Request) bool {
+			return !untracedPaths[r.URL.Path]
+		}),
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return spanName(mux, r)
+		}),
+	)
+}
+
+func spanName(mux *http.ServeMux, r *http.Request) string {
+	_, pattern := mux.Handler(r)
+	switch {
+	case pattern == "":
+		return r.Method + " unmatched"
+	case strings.Contains(pattern, " "):
+		// Go 1.22 形式のパターンは既にメソッドを含む
+		return pattern
+	default:
+		return r.Method + " " + pattern
+	}
+}
@@ -0,0 +1,49 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware_ContinuesIncomingTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/game", func(w http.ResponseWriter, r *http.Request) {
+		_, span := Start(r.Context(), "BattleStageRepository.FindNearby")
+		span.End()
+	})
+	handler := Middleware(mux, mux)
+
+	req := httptest.NewRequest(http.MethodGet, "/game?lat=35&lng=139", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("ended spans = %d, want 2 (/health must not be traced)", len(spans))
+	}
+
+	child, server := spans[0], spans[1]
+	if server.Name() != "GET /game" {
+		t.Errorf("server span name = %q, want %q", server.Name(), "GET /game")
+	}
+	if got := server.SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace id = %s, want the incoming trace id", got)
+	}
+	if child.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Errorf("repository span is not a child of the server span")
+	}
+}
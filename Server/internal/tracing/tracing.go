@@ -0,0 +1,98 @@
+// Package tracing は OpenTelemetry のトレースの初期化とスパン作成の補助を提供します。
+//
+// エクスポーターは OTEL_TRACES_EXPORTER で選び、接続先やサンプリングは OpenTelemetry 標準の
+// 環境変数（OTEL_EXPORTER_OTLP_ENDPOINT, OTEL_TRACES_SAMPLER など）で設定します。
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName はこのサーバーが作るスパンの計装名です
+const instrumentationName = "server"
+
+// エクスポーターの種類
+const (
+	ExporterNone   = "none"   // スパンを出力しない（トレースコンテキストの伝搬のみ行う）
+	ExporterOTLP   = "otlp"   // OTLP/HTTP で送信
+	ExporterStdout = "stdout" // 標準エラー出力に JSON で出力（ローカル向け）
+)
+
+// Options はトレースの設定です
+type Options struct {
+	Exporter    string
+	ServiceName string
+}
+
+// Setup はグローバルな TracerProvider と伝搬方式を設定し、終了時に呼ぶ関数を返します
+func Setup(ctx context.Context, opts Options) (shutdown func(context.Context) error, err error) {
+	// 受け取った traceparent / baggage は、エクスポートしない場合も下流とログへ伝搬する
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	switch opts.Exporter {
+	case ExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx)
+	case ExporterStdout:
+		// ログの JSON 行と混ざらないよう標準エラー出力に書く
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stderr))
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", opts.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create %s trace exporter: %w", opts.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(opts.ServiceName),
+	))
+	if err != nil && !errors.Is(err, resource.ErrPartialResource) && !errors.Is(err, resource.ErrSchemaURLConflict) {
+		return nil, fmt.Errorf("build trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Tracer はこのサーバーのスパンを作る Tracer です
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start は ctx の子スパンを開始します
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, opts...)
+}
+
+// RecordError は err をスパンに記録し、ステータスをエラーにします。err が nil の場合は何もしません。
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
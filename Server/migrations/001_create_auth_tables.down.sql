@@ -0,0 +1,4 @@
+-- 001_create_auth_tables の取り消し
+
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS users;
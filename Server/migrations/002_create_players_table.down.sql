@@ -0,0 +1,3 @@
+-- 002_create_players_table の取り消し
+
+DROP TABLE IF EXISTS players;
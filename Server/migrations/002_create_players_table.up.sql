@@ -14,6 +14,31 @@ CREATE TABLE IF NOT EXISTS players (
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
+-- 旧 /sql/game_tables.sql で作成済みの環境との差分を揃える
+-- （id の既定値、hp/mp 列、user_id の ON DELETE CASCADE）
+ALTER TABLE players ALTER COLUMN id SET DEFAULT gen_random_uuid();
+ALTER TABLE players ADD COLUMN IF NOT EXISTS hp SMALLINT NOT NULL DEFAULT 100;
+ALTER TABLE players ADD COLUMN IF NOT EXISTS mp SMALLINT NOT NULL DEFAULT 100;
+
+DO $$
+DECLARE
+    fk_name TEXT;
+BEGIN
+    SELECT conname INTO fk_name
+    FROM pg_constraint
+    WHERE conrelid = 'players'::regclass
+      AND contype = 'f'
+      AND confrelid = 'users'::regclass
+      AND confdeltype <> 'c';
+
+    IF fk_name IS NOT NULL THEN
+        EXECUTE format('ALTER TABLE players DROP CONSTRAINT %I', fk_name);
+        ALTER TABLE players
+            ADD CONSTRAINT players_user_id_fkey
+            FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+    END IF;
+END $$;
+
 -- インデックスの作成
 CREATE INDEX IF NOT EXISTS idx_players_user_id ON players(user_id);
 CREATE INDEX IF NOT EXISTS idx_players_display_name ON players(display_name);
@@ -0,0 +1,10 @@
+-- 003_create_game_tables の取り消し
+
+DROP TABLE IF EXISTS public.game_events;
+ALTER TABLE IF EXISTS public.game_sessions
+    DROP CONSTRAINT IF EXISTS game_sessions_winner_user_fk;
+DROP TABLE IF EXISTS public.game_users;
+DROP TABLE IF EXISTS public.game_sessions;
+DROP TABLE IF EXISTS public.battle_stages;
+DROP TYPE IF EXISTS public.game_event_type;
+DROP TYPE IF EXISTS public.game_event_category;
@@ -11,18 +11,7 @@ BEGIN
 END
 $$;
 
--- Player master (referenced by game users and events)
-CREATE TABLE IF NOT EXISTS public.players (
-    id UUID PRIMARY KEY,
-    user_id UUID UNIQUE REFERENCES public.users(id),
-    display_name TEXT NOT NULL,
-    hp SMALLINT DEFAULT 100 NOT NULL,
-    mp SMALLINT DEFAULT 100 NOT NULL,
-    rank SMALLINT DEFAULT 0,
-    avatar_url TEXT,
-    created_at TIMESTAMPTZ DEFAULT now(),
-    updated_at TIMESTAMPTZ DEFAULT now()
-);
+-- players は 002_create_players_table で作成済み
 
 -- Battle stage definitions with geo information
 CREATE TABLE IF NOT EXISTS public.battle_stages (
@@ -50,6 +39,34 @@ CREATE TABLE IF NOT EXISTS public.game_sessions (
     referee_note TEXT
 );
 
+-- battle_stage_id が追加される前に作成された game_sessions 向け
+ALTER TABLE public.game_sessions
+    ADD COLUMN IF NOT EXISTS battle_stage_id UUID;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1
+        FROM pg_constraint
+        WHERE conname = 'game_sessions_battle_stage_fk'
+    ) AND NOT EXISTS (
+        SELECT 1
+        FROM pg_constraint
+        WHERE conrelid = 'public.game_sessions'::regclass
+          AND contype = 'f'
+          AND confrelid = 'public.battle_stages'::regclass
+    ) THEN
+        ALTER TABLE public.game_sessions
+            ADD CONSTRAINT game_sessions_battle_stage_fk
+            FOREIGN KEY (battle_stage_id) REFERENCES public.battle_stages(id);
+    END IF;
+END
+$$;
+
+ALTER TABLE public.game_sessions
+    DROP COLUMN IF EXISTS arena_lat,
+    DROP COLUMN IF EXISTS arena_lng;
+
 -- Session participants
 CREATE TABLE IF NOT EXISTS public.game_users (
     id UUID PRIMARY KEY,
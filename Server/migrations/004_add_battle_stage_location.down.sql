@@ -0,0 +1,7 @@
+-- 004_add_battle_stage_location の取り消し
+-- postgis 拡張は他で使われている可能性があるため残す
+
+DROP INDEX IF EXISTS public.idx_battle_stages_location;
+DROP TRIGGER IF EXISTS battle_stages_sync_location ON public.battle_stages;
+DROP FUNCTION IF EXISTS public.battle_stages_sync_location();
+ALTER TABLE public.battle_stages DROP COLUMN IF EXISTS location;
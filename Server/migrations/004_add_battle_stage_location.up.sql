@@ -0,0 +1,30 @@
+-- バトルステージ検索用の空間インデックス
+-- latitude / longitude から geography 列を作成し、GiST インデックスで半径検索の候補を絞り込む
+
+CREATE EXTENSION IF NOT EXISTS postgis;
+
+ALTER TABLE public.battle_stages
+    ADD COLUMN IF NOT EXISTS location geography(Point, 4326);
+
+-- 既存行のバックフィル
+UPDATE public.battle_stages
+SET location = ST_SetSRID(ST_MakePoint(longitude::double precision, latitude::double precision), 4326)::geography
+WHERE location IS NULL;
+
+-- latitude / longitude の更新に location を追従させる
+CREATE OR REPLACE FUNCTION public.battle_stages_sync_location()
+RETURNS trigger AS $$
+BEGIN
+    NEW.location := ST_SetSRID(ST_MakePoint(NEW.longitude::double precision, NEW.latitude::double precision), 4326)::geography;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS battle_stages_sync_location ON public.battle_stages;
+CREATE TRIGGER battle_stages_sync_location
+    BEFORE INSERT OR UPDATE OF latitude, longitude ON public.battle_stages
+    FOR EACH ROW
+    EXECUTE FUNCTION public.battle_stages_sync_location();
+
+CREATE INDEX IF NOT EXISTS idx_battle_stages_location
+    ON public.battle_stages USING GIST (location);
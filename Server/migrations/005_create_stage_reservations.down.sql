@@ -0,0 +1,4 @@
+-- 005_create_stage_reservations の取り消し
+-- btree_gist 拡張は他で使われている可能性があるため残す
+
+DROP TABLE IF EXISTS public.stage_reservations;
@@ -0,0 +1,21 @@
+-- バトルステージの時間枠予約
+-- 同じステージで時間枠が重なる予約は排他制約で拒否する
+
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+CREATE TABLE IF NOT EXISTS public.stage_reservations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    battle_stage_id UUID NOT NULL REFERENCES public.battle_stages(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    CONSTRAINT stage_reservations_window_check CHECK (ends_at > starts_at),
+    CONSTRAINT stage_reservations_no_overlap EXCLUDE USING GIST (
+        battle_stage_id WITH =,
+        tstzrange(starts_at, ends_at, '[)') WITH &&
+    )
+);
+
+CREATE INDEX IF NOT EXISTS idx_stage_reservations_user_id
+    ON public.stage_reservations (user_id);
@@ -0,0 +1,3 @@
+-- 006_create_rate_limit_buckets の取り消し
+
+DROP TABLE IF EXISTS public.rate_limit_buckets;
@@ -0,0 +1,12 @@
+-- レート制限のトークンバケット（複数インスタンスで共有する）
+-- allowed は直前の Take の判定結果で、UPSERT の RETURNING で返すために保持する
+
+CREATE TABLE IF NOT EXISTS public.rate_limit_buckets (
+    key TEXT PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    allowed BOOLEAN NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT clock_timestamp()
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_updated_at
+    ON public.rate_limit_buckets (updated_at);
@@ -0,0 +1,3 @@
+-- 007_create_idempotency_keys の取り消し
+
+DROP TABLE IF EXISTS public.idempotency_keys;
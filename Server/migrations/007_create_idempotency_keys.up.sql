@@ -0,0 +1,17 @@
+-- Idempotency-Key ごとの最初のリクエストとレスポンス
+-- status_code が NULL の間は最初のリクエストを処理中
+
+CREATE TABLE IF NOT EXISTS public.idempotency_keys (
+    user_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    key TEXT NOT NULL,
+    fingerprint TEXT NOT NULL,
+    status_code INTEGER,
+    response_headers JSONB,
+    response_body BYTEA,
+    created_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at
+    ON public.idempotency_keys (expires_at);
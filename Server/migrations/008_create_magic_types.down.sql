@@ -0,0 +1,3 @@
+-- 008_create_magic_types の取り消し
+
+DROP TABLE IF EXISTS public.magic_types;
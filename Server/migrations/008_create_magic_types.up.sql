@@ -0,0 +1,15 @@
+-- 魔法の定義
+-- 初期データはサーバー起動時に埋め込みの magic_types.json から投入する（テーブルが空の場合のみ）
+
+CREATE TABLE IF NOT EXISTS public.magic_types (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    mp_cost INTEGER NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    damage INTEGER NOT NULL,
+    sound TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT magic_types_mp_cost_check CHECK (mp_cost BETWEEN 1 AND 1000),
+    CONSTRAINT magic_types_damage_check CHECK (damage BETWEEN 1 AND 1000)
+);
@@ -0,0 +1,7 @@
+-- 009_add_magic_type_chants の取り消し
+
+ALTER TABLE public.magic_types
+    DROP CONSTRAINT IF EXISTS magic_types_chant_check,
+    DROP CONSTRAINT IF EXISTS magic_types_chant_match_check,
+    DROP COLUMN IF EXISTS chant_match,
+    DROP COLUMN IF EXISTS chant;
@@ -0,0 +1,33 @@
+-- 魔法の詠唱と照合方法（exact: 完全一致 / fuzzy: 編集距離 / keyword: 語句の一致）
+
+ALTER TABLE public.magic_types
+    ADD COLUMN IF NOT EXISTS chant TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS chant_match TEXT NOT NULL DEFAULT 'fuzzy';
+
+-- 初期データの魔法には magic_types.json と同じ詠唱を、それ以外は魔法の名前を詠唱とする
+UPDATE public.magic_types AS m
+SET chant = v.chant, chant_match = v.chant_match
+FROM (VALUES
+    ('fireball', '紅蓮の炎よ、我が敵を焼き尽くせ', 'fuzzy'),
+    ('thunderbolt', '天より降りし雷光、槍となりて貫け', 'keyword'),
+    ('wind_cutter', '風よ刃となれ', 'exact')
+) AS v (id, chant, chant_match)
+WHERE m.id = v.id AND m.chant = '';
+
+UPDATE public.magic_types SET chant = name WHERE chant = '';
+
+ALTER TABLE public.magic_types
+    ALTER COLUMN chant DROP DEFAULT;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'magic_types_chant_match_check') THEN
+        ALTER TABLE public.magic_types
+            ADD CONSTRAINT magic_types_chant_match_check CHECK (chant_match IN ('exact', 'fuzzy', 'keyword'));
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'magic_types_chant_check') THEN
+        ALTER TABLE public.magic_types
+            ADD CONSTRAINT magic_types_chant_check CHECK (chant <> '');
+    END IF;
+END
+$$;
@@ -0,0 +1,9 @@
+-- 010_add_magic_type_timings の取り消し
+
+ALTER TABLE public.magic_types
+    DROP CONSTRAINT IF EXISTS magic_types_stun_ms_check,
+    DROP CONSTRAINT IF EXISTS magic_types_cast_time_ms_check,
+    DROP CONSTRAINT IF EXISTS magic_types_cooldown_ms_check,
+    DROP COLUMN IF EXISTS stun_ms,
+    DROP COLUMN IF EXISTS cast_time_ms,
+    DROP COLUMN IF EXISTS cooldown_ms;
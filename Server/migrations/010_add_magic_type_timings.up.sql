@@ -0,0 +1,34 @@
+-- 魔法の再使用までの時間（cooldown_ms）、唱え始めてから発動するまでの時間（cast_time_ms）、
+-- 命中した相手を行動不能にする時間（stun_ms）
+
+ALTER TABLE public.magic_types
+    ADD COLUMN IF NOT EXISTS cooldown_ms INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS cast_time_ms INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS stun_ms INTEGER NOT NULL DEFAULT 0;
+
+-- 初期データの魔法には magic_types.json と同じ値を設定する
+UPDATE public.magic_types AS m
+SET cooldown_ms = v.cooldown_ms, cast_time_ms = v.cast_time_ms, stun_ms = v.stun_ms
+FROM (VALUES
+    ('fireball', 3000, 1000, 0),
+    ('thunderbolt', 5000, 1500, 1500),
+    ('wind_cutter', 2000, 0, 0)
+) AS v (id, cooldown_ms, cast_time_ms, stun_ms)
+WHERE m.id = v.id;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'magic_types_cooldown_ms_check') THEN
+        ALTER TABLE public.magic_types
+            ADD CONSTRAINT magic_types_cooldown_ms_check CHECK (cooldown_ms BETWEEN 0 AND 60000);
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'magic_types_cast_time_ms_check') THEN
+        ALTER TABLE public.magic_types
+            ADD CONSTRAINT magic_types_cast_time_ms_check CHECK (cast_time_ms BETWEEN 0 AND 10000);
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'magic_types_stun_ms_check') THEN
+        ALTER TABLE public.magic_types
+            ADD CONSTRAINT magic_types_stun_ms_check CHECK (stun_ms BETWEEN 0 AND 10000);
+    END IF;
+END
+$$;
@@ -0,0 +1,13 @@
+-- 011_add_defense_event_categories の取り消し
+-- enum の値は削除できないため、guard / shield の行を削除してから型を作り直す
+
+DELETE FROM public.game_events WHERE category::text IN ('guard', 'shield');
+
+ALTER TYPE public.game_event_category RENAME TO game_event_category_old;
+CREATE TYPE public.game_event_category AS ENUM ('attack', 'heal');
+
+ALTER TABLE public.game_events
+    ALTER COLUMN category TYPE public.game_event_category
+    USING category::text::public.game_event_category;
+
+DROP TYPE public.game_event_category_old;
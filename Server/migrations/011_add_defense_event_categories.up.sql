@@ -0,0 +1,5 @@
+-- 防御行動（ガード / シールド）の game_events のカテゴリ
+-- ADD VALUE はトランザクション内でも実行できる（PostgreSQL 12 以降）が、追加した値は同じトランザクションでは使えない
+
+ALTER TYPE public.game_event_category ADD VALUE IF NOT EXISTS 'guard';
+ALTER TYPE public.game_event_category ADD VALUE IF NOT EXISTS 'shield';
@@ -0,0 +1,18 @@
+-- 012_record_battle_events の取り消し
+-- enum の値は削除できないため、defend / break の行を削除してから型を作り直す
+
+DELETE FROM public.game_events WHERE type::text IN ('defend', 'break');
+
+ALTER TABLE public.game_events
+    DROP COLUMN IF EXISTS mitigated,
+    DROP COLUMN IF EXISTS amount,
+    DROP COLUMN IF EXISTS magic_type_id;
+
+ALTER TYPE public.game_event_type RENAME TO game_event_type_old;
+CREATE TYPE public.game_event_type AS ENUM ('fire');
+
+ALTER TABLE public.game_events
+    ALTER COLUMN type TYPE public.game_event_type
+    USING type::text::public.game_event_type;
+
+DROP TYPE public.game_event_type_old;
@@ -0,0 +1,11 @@
+-- 対戦の経過を game_events に記録するための値と列
+-- type は既存の fire（魔法の発動）に、防御を張った（defend）・シールドを使い切った（break）を追加する
+-- 参加者（trigger_id / target_id）は players の ID で、魔法（magic_type_id）は削除されても記録を残すため外部キーにしない
+
+ALTER TYPE public.game_event_type ADD VALUE IF NOT EXISTS 'defend';
+ALTER TYPE public.game_event_type ADD VALUE IF NOT EXISTS 'break';
+
+ALTER TABLE public.game_events
+    ADD COLUMN IF NOT EXISTS magic_type_id TEXT,
+    ADD COLUMN IF NOT EXISTS amount INTEGER,
+    ADD COLUMN IF NOT EXISTS mitigated INTEGER;
@@ -0,0 +1,7 @@
+-- 013_add_player_max_hp の取り消し
+
+ALTER TABLE players
+    DROP CONSTRAINT IF EXISTS players_hp_check,
+    DROP CONSTRAINT IF EXISTS players_max_hp_check,
+    DROP COLUMN IF EXISTS hp_updated_at,
+    DROP COLUMN IF EXISTS max_hp;
@@ -0,0 +1,22 @@
+-- プレイヤーごとの最大HP（max_hp）と、自然回復の起点になる最後にHPが変わった時刻（hp_updated_at）
+
+ALTER TABLE players
+    ADD COLUMN IF NOT EXISTS max_hp SMALLINT NOT NULL DEFAULT 100,
+    ADD COLUMN IF NOT EXISTS hp_updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();
+
+-- 旧上限（1000）まで HP を上げていたプレイヤーが HP を失わないよう、最大HPを現在の HP に合わせる
+UPDATE players SET max_hp = LEAST(hp, 1000) WHERE hp > max_hp;
+UPDATE players SET hp_updated_at = updated_at WHERE updated_at IS NOT NULL;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'players_max_hp_check') THEN
+        ALTER TABLE players
+            ADD CONSTRAINT players_max_hp_check CHECK (max_hp BETWEEN 1 AND 1000);
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'players_hp_check') THEN
+        ALTER TABLE players
+            ADD CONSTRAINT players_hp_check CHECK (hp BETWEEN 0 AND max_hp);
+    END IF;
+END
+$$;
@@ -0,0 +1,14 @@
+-- 014_add_magic_type_kinds の取り消し（回復魔法はダメージの制約を満たさないため削除する）
+
+DELETE FROM public.magic_types WHERE kind = 'heal';
+
+ALTER TABLE public.magic_types
+    DROP CONSTRAINT IF EXISTS magic_types_kind_check,
+    DROP CONSTRAINT IF EXISTS magic_types_heal_check,
+    DROP CONSTRAINT IF EXISTS magic_types_damage_check,
+    DROP COLUMN IF EXISTS heal,
+    DROP COLUMN IF EXISTS target,
+    DROP COLUMN IF EXISTS kind;
+
+ALTER TABLE public.magic_types
+    ADD CONSTRAINT magic_types_damage_check CHECK (damage BETWEEN 1 AND 1000);
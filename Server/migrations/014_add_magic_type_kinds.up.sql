@@ -0,0 +1,36 @@
+-- 魔法の種類（attack: 攻撃 / heal: 回復）と対象（enemy: 相手 / self: 本人 / ally: 本人を含む味方）、回復量（heal）
+
+ALTER TABLE public.magic_types
+    ADD COLUMN IF NOT EXISTS kind TEXT NOT NULL DEFAULT 'attack',
+    ADD COLUMN IF NOT EXISTS target TEXT NOT NULL DEFAULT 'enemy',
+    ADD COLUMN IF NOT EXISTS heal INTEGER NOT NULL DEFAULT 0;
+
+-- 回復魔法はダメージを持たないため、ダメージの下限を種類ごとの制約に置き換える
+ALTER TABLE public.magic_types
+    DROP CONSTRAINT IF EXISTS magic_types_damage_check;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'magic_types_damage_check') THEN
+        ALTER TABLE public.magic_types
+            ADD CONSTRAINT magic_types_damage_check CHECK (damage BETWEEN 0 AND 1000);
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'magic_types_heal_check') THEN
+        ALTER TABLE public.magic_types
+            ADD CONSTRAINT magic_types_heal_check CHECK (heal BETWEEN 0 AND 1000);
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'magic_types_kind_check') THEN
+        ALTER TABLE public.magic_types
+            ADD CONSTRAINT magic_types_kind_check CHECK (
+                (kind = 'attack' AND target = 'enemy' AND damage >= 1 AND heal = 0)
+                OR (kind = 'heal' AND target IN ('self', 'ally') AND heal >= 1 AND damage = 0 AND stun_ms = 0)
+            );
+    END IF;
+END
+$$;
+
+-- 初期データの回復魔法を追加する（テーブルが空の場合は起動時に magic_types.json から投入されるため追加しない）
+INSERT INTO public.magic_types (id, name, kind, target, mp_cost, description, damage, heal, sound, chant, chant_match, cooldown_ms, cast_time_ms, stun_ms)
+SELECT 'heal', 'ヒール', 'heal', 'self', 30, '癒やしの光で自身の傷を塞ぎ、HP を回復する。', 0, 25, '', '癒やしの光よ、この身の傷を塞げ', 'fuzzy', 8000, 1500, 0
+WHERE EXISTS (SELECT 1 FROM public.magic_types)
+ON CONFLICT (id) DO NOTHING;
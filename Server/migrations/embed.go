@@ -0,0 +1,10 @@
+// Package migrations はバイナリに埋め込むデータベースマイグレーションです。
+// ファイル名は NNN_name.up.sql / NNN_name.down.sql の形式で、番号順に適用されます。
+package migrations
+
+import "embed"
+
+// FS は埋め込まれたマイグレーションファイルです
+//
+//go:embed *.sql
+var FS embed.FS
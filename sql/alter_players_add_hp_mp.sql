@@ -1,5 +0,0 @@
-ALTER TABLE public.players
-    ADD COLUMN IF NOT EXISTS hp SMALLINT NOT NULL DEFAULT 100;
-
-ALTER TABLE public.players
-    ADD COLUMN IF NOT EXISTS mp SMALLINT NOT NULL DEFAULT 100;